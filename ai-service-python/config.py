@@ -12,6 +12,14 @@ class Config:
     DEEPSEEK_API_KEY = os.getenv("DEEPSEEK_API_KEY")
     DEEPSEEK_BASE_URL = os.getenv("DEEPSEEK_BASE_URL", "https://api.deepseek.com")
     EMBEDDING_MODEL_NAME = 'AI-ModelScope/all-MiniLM-L6-v2'
+    # 额外的向量模型路径: EMBEDDING_MODEL_PATHS="名称=模型路径,..."
+    # 名称与 Go 端 EMBEDDING_MODELS (名称:维度[:距离[:集合]]) 中的名称一致；两边格式不同，
+    # 所以用不同的变量名，同一个 .env 可以同时给两个服务用
+    # 请求里不带模型名 (或名称为 default) 时使用 EMBEDDING_MODEL_NAME
+    EMBEDDING_MODEL_PATHS = dict(
+        item.split("=", 1) for item in os.getenv("EMBEDDING_MODEL_PATHS", "").split(",") if "=" in item
+    )
+    RERANK_MODEL_NAME = os.getenv("RERANK_MODEL_NAME", "BAAI/bge-reranker-base")
 
     # 业务参数
     CHUNK_SIZE = 500  # 增大一点，适配 Docling 的段落感
@@ -1,12 +1,18 @@
 from sentence_transformers import SentenceTransformer
 from config import Config
 import logging
+import threading
 
 class EmbeddingModel:
     _instance = None
+    # 按名称加载的其他模型 (重建索引切换模型时使用)
+    _named = {}
+    _lock = threading.Lock()
 
     @classmethod
-    def get_instance(cls):
+    def get_instance(cls, name: str = ""):
+        if name and name != "default":
+            return cls._get_named(name)
         if cls._instance is None:
             logging.info("📥 Loading Embedding Model...")
             try:
@@ -17,7 +23,19 @@ class EmbeddingModel:
             logging.info("✅ Embedding Model Loaded")
         return cls._instance
 
+    @classmethod
+    def _get_named(cls, name: str):
+        with cls._lock:
+            if name not in cls._named:
+                path = Config.EMBEDDING_MODEL_PATHS.get(name)
+                if path is None:
+                    raise ValueError(f"未配置向量模型: {name}")
+                logging.info(f"📥 Loading Embedding Model {name} ({path})...")
+                cls._named[name] = SentenceTransformer(path)
+                logging.info(f"✅ Embedding Model {name} Loaded")
+            return cls._named[name]
+
     @staticmethod
-    def encode(text: str):
-        model = EmbeddingModel.get_instance()
-        return model.encode(text).tolist()
\ No newline at end of file
+    def encode(text: str, model_name: str = ""):
+        model = EmbeddingModel.get_instance(model_name)
+        return model.encode(text).tolist()
@@ -0,0 +1,28 @@
+from sentence_transformers import CrossEncoder
+from config import Config
+import logging
+
+class RerankModel:
+    _instance = None
+    _failed = False
+
+    @classmethod
+    def get_instance(cls):
+        # 模型加载失败后不再反复重试，直接返回 None 让调用方降级
+        if cls._instance is None and not cls._failed:
+            logging.info("📥 Loading Rerank Model...")
+            try:
+                cls._instance = CrossEncoder(Config.RERANK_MODEL_NAME)
+                logging.info("✅ Rerank Model Loaded")
+            except Exception as e:
+                cls._failed = True
+                logging.warning(f"⚠️ Rerank 模型加载失败，将跳过重排序: {e}")
+        return cls._instance
+
+    @staticmethod
+    def score(query: str, documents: list):
+        model = RerankModel.get_instance()
+        if model is None:
+            return None
+        pairs = [(query, doc) for doc in documents]
+        return model.predict(pairs).tolist()
@@ -24,7 +24,7 @@ _sym_db = _symbol_database.Default()
 
 
 
-DESCRIPTOR = _descriptor_pool.Default().AddSerializedFile(b'\n\x11rag_service.proto\x12\x06rag.v1\"B\n\nAskRequest\x12\r\n\x05query\x18\x01 \x01(\t\x12\x12\n\nsession_id\x18\x02 \x01(\t\x12\x11\n\tuse_graph\x18\x03 \x01(\x08\"9\n\x0b\x41skResponse\x12\x14\n\x0c\x61nswer_delta\x18\x01 \x01(\t\x12\x14\n\x0cthinking_log\x18\x02 \x01(\t\";\n\x0c\x45mbedRequest\x12\x0e\n\x04text\x18\x01 \x01(\tH\x00\x12\x13\n\timage_url\x18\x02 \x01(\tH\x00\x42\x06\n\x04\x64\x61ta\"\x1f\n\rEmbedResponse\x12\x0e\n\x06vector\x18\x01 \x03(\x02\"7\n\x0cParseRequest\x12\x14\n\x0c\x66ile_content\x18\x01 \x01(\x0c\x12\x11\n\tfile_name\x18\x02 \x01(\t\"1\n\rParseResponse\x12 \n\x06\x63hunks\x18\x01 \x03(\x0b\x32\x10.rag.v1.DocChunk\"@\n\x08\x44ocChunk\x12\x0f\n\x07\x63ontent\x18\x01 \x01(\t\x12\x0e\n\x06vector\x18\x02 \x03(\x02\x12\x13\n\x0bpage_number\x18\x03 \x01(\x05\x32\xbc\x01\n\nLLMService\x12\x36\n\tAskStream\x12\x12.rag.v1.AskRequest\x1a\x13.rag.v1.AskResponse0\x01\x12\x38\n\tEmbedData\x12\x14.rag.v1.EmbedRequest\x1a\x15.rag.v1.EmbedResponse\x12<\n\rParseAndEmbed\x12\x14.rag.v1.ParseRequest\x1a\x15.rag.v1.ParseResponseB\x1bZ\x19\x43himera-RAG/api/rag/v1;v1b\x06proto3')
+DESCRIPTOR = _descriptor_pool.Default().AddSerializedFile(b'\n\x11rag_service.proto\x12\x06rag.v1\"B\n\nAskRequest\x12\r\n\x05query\x18\x01 \x01(\t\x12\x12\n\nsession_id\x18\x02 \x01(\t\x12\x11\n\tuse_graph\x18\x03 \x01(\x08\"9\n\x0b\x41skResponse\x12\x14\n\x0c\x61nswer_delta\x18\x01 \x01(\t\x12\x14\n\x0cthinking_log\x18\x02 \x01(\t\"J\n\x0c\x45mbedRequest\x12\x0e\n\x04text\x18\x01 \x01(\tH\x00\x12\x13\n\timage_url\x18\x02 \x01(\tH\x00\x12\r\n\x05model\x18\x03 \x01(\tB\x06\n\x04\x64\x61ta\"\x1f\n\rEmbedResponse\x12\x0e\n\x06vector\x18\x01 \x03(\x02\"P\n\x0cParseRequest\x12\x14\n\x0c\x66ile_content\x18\x01 \x01(\x0c\x12\x11\n\tfile_name\x18\x02 \x01(\t\x12\x17\n\x0f\x65mbedding_model\x18\x03 \x01(\t\"1\n\rParseResponse\x12 \n\x06\x63hunks\x18\x01 \x03(\x0b\x32\x10.rag.v1.DocChunk\"U\n\x08\x44ocChunk\x12\x0f\n\x07\x63ontent\x18\x01 \x01(\t\x12\x0e\n\x06vector\x18\x02 \x03(\x02\x12\x13\n\x0bpage_number\x18\x03 \x01(\x05\x12\x13\n\x0b\x63hunk_index\x18\x04 \x01(\x05\"Z\n\x12ParseStreamRequest\x12)\n\x08metadata\x18\x01 \x01(\x0b\x32\x15.rag.v1.ParseMetadataH\x00\x12\x0e\n\x04\x64\x61ta\x18\x02 \x01(\x0cH\x00\x42\t\n\x07payload\"\xc3\x01\n\rParseMetadata\x12\x11\n\tfile_name\x18\x01 \x01(\t\x12\x11\n\tfile_size\x18\x02 \x01(\x03\x12\x0e\n\x06parser\x18\x03 \x01(\t\x12\x33\n\x07options\x18\x04 \x03(\x0b\x32\".rag.v1.ParseMetadata.OptionsEntry\x12\x17\n\x0f\x65mbedding_model\x18\x05 \x01(\t\x1a.\n\x0cOptionsEntry\x12\x0b\n\x03key\x18\x01 \x01(\t\x12\r\n\x05value\x18\x02 \x01(\t:\x02\x38\x01\"n\n\x13ParseStreamResponse\x12!\n\x05\x63hunk\x18\x01 \x01(\x0b\x32\x10.rag.v1.DocChunkH\x00\x12)\n\x08progress\x18\x02 \x01(\x0b\x32\x15.rag.v1.ParseProgressH\x00\x42\t\n\x07payload\"E\n\rParseProgress\x12\r\n\x05stage\x18\x01 \x01(\t\x12\x0f\n\x07percent\x18\x02 \x01(\x05\x12\x14\n\x0ctotal_chunks\x18\x03 \x01(\x05\"@\n\rRerankRequest\x12\r\n\x05query\x18\x01 \x01(\t\x12\x11\n\tdocuments\x18\x02 \x03(\t\x12\r\n\x05top_n\x18\x03 \x01(\x05\"7\n\x0eRerankResponse\x12%\n\x07results\x18\x01 \x03(\x0b\x32\x14.rag.v1.RerankResult\",\n\x0cRerankResult\x12\r\n\x05index\x18\x01 \x01(\x05\x12\r\n\x05score\x18\x02 \x01(\x02\x32\xc9\x02\n\nLLMService\x12\x36\n\tAskStream\x12\x12.rag.v1.AskRequest\x1a\x13.rag.v1.AskResponse0\x01\x12\x38\n\tEmbedData\x12\x14.rag.v1.EmbedRequest\x1a\x15.rag.v1.EmbedResponse\x12<\n\rParseAndEmbed\x12\x14.rag.v1.ParseRequest\x1a\x15.rag.v1.ParseResponse\x12R\n\x13ParseAndEmbedStream\x12\x1a.rag.v1.ParseStreamRequest\x1a\x1b.rag.v1.ParseStreamResponse(\x01\x30\x01\x12\x37\n\x06Rerank\x12\x15.rag.v1.RerankRequest\x1a\x16.rag.v1.RerankResponseB\x1bZ\x19\x43himera-RAG/api/rag/v1;v1b\x06proto3')
 
 _globals = globals()
 _builder.BuildMessageAndEnumDescriptors(DESCRIPTOR, _globals)
@@ -32,20 +32,38 @@ _builder.BuildTopDescriptorsAndMessages(DESCRIPTOR, 'rag_service_pb2', _globals)
 if not _descriptor._USE_C_DESCRIPTORS:
   _globals['DESCRIPTOR']._loaded_options = None
   _globals['DESCRIPTOR']._serialized_options = b'Z\031Chimera-RAG/api/rag/v1;v1'
+  _globals['_PARSEMETADATA_OPTIONSENTRY']._loaded_options = None
+  _globals['_PARSEMETADATA_OPTIONSENTRY']._serialized_options = b'8\001'
   _globals['_ASKREQUEST']._serialized_start=29
   _globals['_ASKREQUEST']._serialized_end=95
   _globals['_ASKRESPONSE']._serialized_start=97
   _globals['_ASKRESPONSE']._serialized_end=154
   _globals['_EMBEDREQUEST']._serialized_start=156
-  _globals['_EMBEDREQUEST']._serialized_end=215
-  _globals['_EMBEDRESPONSE']._serialized_start=217
-  _globals['_EMBEDRESPONSE']._serialized_end=248
-  _globals['_PARSEREQUEST']._serialized_start=250
-  _globals['_PARSEREQUEST']._serialized_end=305
-  _globals['_PARSERESPONSE']._serialized_start=307
-  _globals['_PARSERESPONSE']._serialized_end=356
-  _globals['_DOCCHUNK']._serialized_start=358
-  _globals['_DOCCHUNK']._serialized_end=422
-  _globals['_LLMSERVICE']._serialized_start=425
-  _globals['_LLMSERVICE']._serialized_end=613
+  _globals['_EMBEDREQUEST']._serialized_end=230
+  _globals['_EMBEDRESPONSE']._serialized_start=232
+  _globals['_EMBEDRESPONSE']._serialized_end=263
+  _globals['_PARSEREQUEST']._serialized_start=265
+  _globals['_PARSEREQUEST']._serialized_end=345
+  _globals['_PARSERESPONSE']._serialized_start=347
+  _globals['_PARSERESPONSE']._serialized_end=396
+  _globals['_DOCCHUNK']._serialized_start=398
+  _globals['_DOCCHUNK']._serialized_end=483
+  _globals['_PARSESTREAMREQUEST']._serialized_start=485
+  _globals['_PARSESTREAMREQUEST']._serialized_end=575
+  _globals['_PARSEMETADATA']._serialized_start=578
+  _globals['_PARSEMETADATA']._serialized_end=773
+  _globals['_PARSEMETADATA_OPTIONSENTRY']._serialized_start=727
+  _globals['_PARSEMETADATA_OPTIONSENTRY']._serialized_end=773
+  _globals['_PARSESTREAMRESPONSE']._serialized_start=775
+  _globals['_PARSESTREAMRESPONSE']._serialized_end=885
+  _globals['_PARSEPROGRESS']._serialized_start=887
+  _globals['_PARSEPROGRESS']._serialized_end=956
+  _globals['_RERANKREQUEST']._serialized_start=958
+  _globals['_RERANKREQUEST']._serialized_end=1022
+  _globals['_RERANKRESPONSE']._serialized_start=1024
+  _globals['_RERANKRESPONSE']._serialized_end=1079
+  _globals['_RERANKRESULT']._serialized_start=1081
+  _globals['_RERANKRESULT']._serialized_end=1125
+  _globals['_LLMSERVICE']._serialized_start=1128
+  _globals['_LLMSERVICE']._serialized_end=1457
 # @@protoc_insertion_point(module_scope)
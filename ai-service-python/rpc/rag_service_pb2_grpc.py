@@ -49,6 +49,16 @@ class LLMServiceStub(object):
                 request_serializer=rag__service__pb2.ParseRequest.SerializeToString,
                 response_deserializer=rag__service__pb2.ParseResponse.FromString,
                 _registered_method=True)
+        self.ParseAndEmbedStream = channel.stream_stream(
+                '/rag.v1.LLMService/ParseAndEmbedStream',
+                request_serializer=rag__service__pb2.ParseStreamRequest.SerializeToString,
+                response_deserializer=rag__service__pb2.ParseStreamResponse.FromString,
+                _registered_method=True)
+        self.Rerank = channel.unary_unary(
+                '/rag.v1.LLMService/Rerank',
+                request_serializer=rag__service__pb2.RerankRequest.SerializeToString,
+                response_deserializer=rag__service__pb2.RerankResponse.FromString,
+                _registered_method=True)
 
 
 class LLMServiceServicer(object):
@@ -73,6 +83,20 @@ class LLMServiceServicer(object):
         context.set_details('Method not implemented!')
         raise NotImplementedError('Method not implemented!')
 
+    def ParseAndEmbedStream(self, request_iterator, context):
+        """流式解析：客户端分片上传文件，服务端边解析边返回切片和进度，不受单条消息大小限制
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def Rerank(self, request, context):
+        """重排序：用 Cross-Encoder 对候选片段逐一打分
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
 
 def add_LLMServiceServicer_to_server(servicer, server):
     rpc_method_handlers = {
@@ -91,6 +115,16 @@ def add_LLMServiceServicer_to_server(servicer, server):
                     request_deserializer=rag__service__pb2.ParseRequest.FromString,
                     response_serializer=rag__service__pb2.ParseResponse.SerializeToString,
             ),
+            'ParseAndEmbedStream': grpc.stream_stream_rpc_method_handler(
+                    servicer.ParseAndEmbedStream,
+                    request_deserializer=rag__service__pb2.ParseStreamRequest.FromString,
+                    response_serializer=rag__service__pb2.ParseStreamResponse.SerializeToString,
+            ),
+            'Rerank': grpc.unary_unary_rpc_method_handler(
+                    servicer.Rerank,
+                    request_deserializer=rag__service__pb2.RerankRequest.FromString,
+                    response_serializer=rag__service__pb2.RerankResponse.SerializeToString,
+            ),
     }
     generic_handler = grpc.method_handlers_generic_handler(
             'rag.v1.LLMService', rpc_method_handlers)
@@ -182,3 +216,57 @@ class LLMService(object):
             timeout,
             metadata,
             _registered_method=True)
+
+    @staticmethod
+    def ParseAndEmbedStream(request_iterator,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.stream_stream(
+            request_iterator,
+            target,
+            '/rag.v1.LLMService/ParseAndEmbedStream',
+            rag__service__pb2.ParseStreamRequest.SerializeToString,
+            rag__service__pb2.ParseStreamResponse.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+    @staticmethod
+    def Rerank(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/rag.v1.LLMService/Rerank',
+            rag__service__pb2.RerankRequest.SerializeToString,
+            rag__service__pb2.RerankResponse.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
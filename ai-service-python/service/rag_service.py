@@ -1,6 +1,8 @@
 import sys
 import os
 import logging
+import tempfile
+import grpc
 
 # 确保能导入 rpc 目录
 sys.path.append(os.path.join(os.path.dirname(os.path.dirname(__file__)), 'rpc'))
@@ -11,6 +13,7 @@ import rag_service_pb2_grpc
 # 引入核心组件
 from core.llm import LLMClient
 from core.embedding import EmbeddingModel
+from core.reranker import RerankModel
 from tools.pdf_parser import PDFParser
 
 class ChimeraLLMService(rag_service_pb2_grpc.LLMServiceServicer):
@@ -62,7 +65,10 @@ class ChimeraLLMService(rag_service_pb2_grpc.LLMServiceServicer):
     def EmbedData(self, request, context):
         text = request.text
         # 调用 core 层的 Embedding
-        vector = EmbeddingModel.encode(text)
+        try:
+            vector = EmbeddingModel.encode(text, request.model)
+        except ValueError as e:
+            context.abort(grpc.StatusCode.INVALID_ARGUMENT, str(e))
         return rag_service_pb2.EmbedResponse(vector=vector)
 
     # ----------------------------------------------------------------
@@ -85,7 +91,7 @@ class ChimeraLLMService(rag_service_pb2_grpc.LLMServiceServicer):
         grpc_chunks = []
         for item in raw_chunks:
             # 向量化
-            vector = EmbeddingModel.encode(item['content'])
+            vector = EmbeddingModel.encode(item['content'], request.embedding_model)
 
             grpc_chunks.append(rag_service_pb2.DocChunk(
                 content=item['content'],
@@ -94,4 +100,95 @@ class ChimeraLLMService(rag_service_pb2_grpc.LLMServiceServicer):
             ))
 
         logging.info(f"[Parse] 完成! 返回 {len(grpc_chunks)} 个 Chunk 给 Go 端")
-        return rag_service_pb2.ParseResponse(chunks=grpc_chunks)
\ No newline at end of file
+        return rag_service_pb2.ParseResponse(chunks=grpc_chunks)
+
+    # ----------------------------------------------------------------
+    # 3.5 流式文档解析接口
+    # ----------------------------------------------------------------
+    def ParseAndEmbedStream(self, request_iterator, context):
+        """
+        第一条消息是元数据，之后是文件分片。
+        分片先落到临时文件 (内存占用与文件大小无关)，解析完成后逐个切片向量化并立即返回
+        """
+        meta = None
+        received = 0
+        last_percent = -1
+
+        with tempfile.NamedTemporaryFile(suffix=".pdf", delete=True) as tmp:
+            # 1. 接收文件
+            for req in request_iterator:
+                kind = req.WhichOneof("payload")
+                if kind == "metadata":
+                    meta = req.metadata
+                    logging.info(f"[ParseStream] 开始接收: {meta.file_name}, 大小: {meta.file_size} bytes, parser: {meta.parser}")
+                    continue
+                if kind != "data":
+                    continue
+                if meta is None:
+                    context.abort(grpc.StatusCode.INVALID_ARGUMENT, "metadata must be sent first")
+
+                tmp.write(req.data)
+                received += len(req.data)
+                if meta.file_size > 0:
+                    percent = min(100, received * 100 // meta.file_size)
+                    if percent // 10 != last_percent // 10:
+                        last_percent = percent
+                        yield self._progress("receiving", percent)
+
+            if meta is None:
+                context.abort(grpc.StatusCode.INVALID_ARGUMENT, "empty request stream")
+            tmp.flush()
+
+            # 2. 解析 + 切片 (Docling 直接读临时文件)
+            yield self._progress("parsing", 0)
+            raw_chunks = PDFParser.parse_and_chunk(file_source=tmp.name, filename=meta.file_name)
+            yield self._progress("parsing", 100)
+
+        if not raw_chunks:
+            logging.warning("⚠️ 解析结果为空")
+            return
+
+        # 3. 逐个向量化，算完一个返回一个，Go 端边收边写入向量库
+        total = len(raw_chunks)
+        yield self._progress("embedding", 0, total)
+        for idx, item in enumerate(raw_chunks):
+            vector = EmbeddingModel.encode(item['content'], meta.embedding_model)
+            yield rag_service_pb2.ParseStreamResponse(chunk=rag_service_pb2.DocChunk(
+                content=item['content'],
+                vector=vector,
+                page_number=item['page'],
+                chunk_index=idx,
+            ))
+            done = idx + 1
+            if done == total or done % 20 == 0:
+                yield self._progress("embedding", done * 100 // total, total)
+
+        logging.info(f"[ParseStream] 完成! 共返回 {total} 个 Chunk")
+
+    @staticmethod
+    def _progress(stage, percent, total_chunks=0):
+        return rag_service_pb2.ParseStreamResponse(progress=rag_service_pb2.ParseProgress(
+            stage=stage, percent=percent, total_chunks=total_chunks,
+        ))
+
+    # ----------------------------------------------------------------
+    # 4. 重排序接口 (Cross-Encoder)
+    # ----------------------------------------------------------------
+    def Rerank(self, request, context):
+        documents = list(request.documents)
+        if not documents:
+            return rag_service_pb2.RerankResponse(results=[])
+
+        scores = RerankModel.score(request.query, documents)
+        if scores is None:
+            # 模型不可用时返回 UNIMPLEMENTED，Go 端会自动降级为原始顺序
+            context.abort(grpc.StatusCode.UNIMPLEMENTED, "rerank model not available")
+
+        ranked = sorted(enumerate(scores), key=lambda x: x[1], reverse=True)
+        if request.top_n > 0:
+            ranked = ranked[:request.top_n]
+
+        logging.info(f"[Rerank] {len(documents)} 个候选 -> 保留 {len(ranked)} 个")
+        return rag_service_pb2.RerankResponse(results=[
+            rag_service_pb2.RerankResult(index=idx, score=score) for idx, score in ranked
+        ])
\ No newline at end of file
@@ -140,6 +140,7 @@ type EmbedRequest struct {
 	//	*EmbedRequest_Text
 	//	*EmbedRequest_ImageUrl
 	Data          isEmbedRequest_Data `protobuf_oneof:"data"`
+	Model         string              `protobuf:"bytes,3,opt,name=model,proto3" json:"model,omitempty"` // 向量模型名 (与 Go 端 EMBEDDING_MODELS 一致)，为空时用默认模型
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -199,6 +200,13 @@ func (x *EmbedRequest) GetImageUrl() string {
 	return ""
 }
 
+func (x *EmbedRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
 type isEmbedRequest_Data interface {
 	isEmbedRequest_Data()
 }
@@ -261,11 +269,12 @@ func (x *EmbedResponse) GetVector() []float32 {
 
 // 🔥 新增请求：直接传文件内容的字节流
 type ParseRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	FileContent   []byte                 `protobuf:"bytes,1,opt,name=file_content,json=fileContent,proto3" json:"file_content,omitempty"`
-	FileName      string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	FileContent    []byte                 `protobuf:"bytes,1,opt,name=file_content,json=fileContent,proto3" json:"file_content,omitempty"`
+	FileName       string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	EmbeddingModel string                 `protobuf:"bytes,3,opt,name=embedding_model,json=embeddingModel,proto3" json:"embedding_model,omitempty"` // 为空时用默认模型
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *ParseRequest) Reset() {
@@ -312,6 +321,13 @@ func (x *ParseRequest) GetFileName() string {
 	return ""
 }
 
+func (x *ParseRequest) GetEmbeddingModel() string {
+	if x != nil {
+		return x.EmbeddingModel
+	}
+	return ""
+}
+
 // 🔥 新增响应：返回多个切片，每个切片都有文本和向量
 type ParseResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -362,6 +378,7 @@ type DocChunk struct {
 	Content       string                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`                          // 切分后的文本片段
 	Vector        []float32              `protobuf:"fixed32,2,rep,packed,name=vector,proto3" json:"vector,omitempty"`                   // 该片段对应的 384维 向量
 	PageNumber    int32                  `protobuf:"varint,3,opt,name=page_number,json=pageNumber,proto3" json:"page_number,omitempty"` // 页码 (用于前端跳转)
+	ChunkIndex    int32                  `protobuf:"varint,4,opt,name=chunk_index,json=chunkIndex,proto3" json:"chunk_index,omitempty"` // 切片序号 (流式接口返回)
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -417,6 +434,471 @@ func (x *DocChunk) GetPageNumber() int32 {
 	return 0
 }
 
+func (x *DocChunk) GetChunkIndex() int32 {
+	if x != nil {
+		return x.ChunkIndex
+	}
+	return 0
+}
+
+// 流式解析请求：第一条必须是 metadata，之后是若干条 data 分片
+type ParseStreamRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*ParseStreamRequest_Metadata
+	//	*ParseStreamRequest_Data
+	Payload       isParseStreamRequest_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParseStreamRequest) Reset() {
+	*x = ParseStreamRequest{}
+	mi := &file_rag_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParseStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParseStreamRequest) ProtoMessage() {}
+
+func (x *ParseStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rag_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParseStreamRequest.ProtoReflect.Descriptor instead.
+func (*ParseStreamRequest) Descriptor() ([]byte, []int) {
+	return file_rag_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ParseStreamRequest) GetPayload() isParseStreamRequest_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *ParseStreamRequest) GetMetadata() *ParseMetadata {
+	if x != nil {
+		if x, ok := x.Payload.(*ParseStreamRequest_Metadata); ok {
+			return x.Metadata
+		}
+	}
+	return nil
+}
+
+func (x *ParseStreamRequest) GetData() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*ParseStreamRequest_Data); ok {
+			return x.Data
+		}
+	}
+	return nil
+}
+
+type isParseStreamRequest_Payload interface {
+	isParseStreamRequest_Payload()
+}
+
+type ParseStreamRequest_Metadata struct {
+	Metadata *ParseMetadata `protobuf:"bytes,1,opt,name=metadata,proto3,oneof"`
+}
+
+type ParseStreamRequest_Data struct {
+	Data []byte `protobuf:"bytes,2,opt,name=data,proto3,oneof"`
+}
+
+func (*ParseStreamRequest_Metadata) isParseStreamRequest_Payload() {}
+
+func (*ParseStreamRequest_Data) isParseStreamRequest_Payload() {}
+
+type ParseMetadata struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	FileName       string                 `protobuf:"bytes,1,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	FileSize       int64                  `protobuf:"varint,2,opt,name=file_size,json=fileSize,proto3" json:"file_size,omitempty"`                                                        // 字节数，用于计算接收进度
+	Parser         string                 `protobuf:"bytes,3,opt,name=parser,proto3" json:"parser,omitempty"`                                                                             // 解析器，例如 docling
+	Options        map[string]string      `protobuf:"bytes,4,rep,name=options,proto3" json:"options,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // 解析参数
+	EmbeddingModel string                 `protobuf:"bytes,5,opt,name=embedding_model,json=embeddingModel,proto3" json:"embedding_model,omitempty"`                                       // 向量模型名，为空时用默认模型
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ParseMetadata) Reset() {
+	*x = ParseMetadata{}
+	mi := &file_rag_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParseMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParseMetadata) ProtoMessage() {}
+
+func (x *ParseMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_rag_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParseMetadata.ProtoReflect.Descriptor instead.
+func (*ParseMetadata) Descriptor() ([]byte, []int) {
+	return file_rag_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ParseMetadata) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *ParseMetadata) GetFileSize() int64 {
+	if x != nil {
+		return x.FileSize
+	}
+	return 0
+}
+
+func (x *ParseMetadata) GetParser() string {
+	if x != nil {
+		return x.Parser
+	}
+	return ""
+}
+
+func (x *ParseMetadata) GetOptions() map[string]string {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+func (x *ParseMetadata) GetEmbeddingModel() string {
+	if x != nil {
+		return x.EmbeddingModel
+	}
+	return ""
+}
+
+// 流式解析响应：切片与进度交替返回
+type ParseStreamResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*ParseStreamResponse_Chunk
+	//	*ParseStreamResponse_Progress
+	Payload       isParseStreamResponse_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParseStreamResponse) Reset() {
+	*x = ParseStreamResponse{}
+	mi := &file_rag_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParseStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParseStreamResponse) ProtoMessage() {}
+
+func (x *ParseStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rag_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParseStreamResponse.ProtoReflect.Descriptor instead.
+func (*ParseStreamResponse) Descriptor() ([]byte, []int) {
+	return file_rag_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ParseStreamResponse) GetPayload() isParseStreamResponse_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *ParseStreamResponse) GetChunk() *DocChunk {
+	if x != nil {
+		if x, ok := x.Payload.(*ParseStreamResponse_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+func (x *ParseStreamResponse) GetProgress() *ParseProgress {
+	if x != nil {
+		if x, ok := x.Payload.(*ParseStreamResponse_Progress); ok {
+			return x.Progress
+		}
+	}
+	return nil
+}
+
+type isParseStreamResponse_Payload interface {
+	isParseStreamResponse_Payload()
+}
+
+type ParseStreamResponse_Chunk struct {
+	Chunk *DocChunk `protobuf:"bytes,1,opt,name=chunk,proto3,oneof"`
+}
+
+type ParseStreamResponse_Progress struct {
+	Progress *ParseProgress `protobuf:"bytes,2,opt,name=progress,proto3,oneof"`
+}
+
+func (*ParseStreamResponse_Chunk) isParseStreamResponse_Payload() {}
+
+func (*ParseStreamResponse_Progress) isParseStreamResponse_Payload() {}
+
+type ParseProgress struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stage         string                 `protobuf:"bytes,1,opt,name=stage,proto3" json:"stage,omitempty"`                                 // receiving / parsing / embedding
+	Percent       int32                  `protobuf:"varint,2,opt,name=percent,proto3" json:"percent,omitempty"`                            // 当前阶段的完成百分比 0 ~ 100
+	TotalChunks   int32                  `protobuf:"varint,3,opt,name=total_chunks,json=totalChunks,proto3" json:"total_chunks,omitempty"` // 切片总数 (embedding 阶段才有)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParseProgress) Reset() {
+	*x = ParseProgress{}
+	mi := &file_rag_service_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParseProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParseProgress) ProtoMessage() {}
+
+func (x *ParseProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_rag_service_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParseProgress.ProtoReflect.Descriptor instead.
+func (*ParseProgress) Descriptor() ([]byte, []int) {
+	return file_rag_service_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ParseProgress) GetStage() string {
+	if x != nil {
+		return x.Stage
+	}
+	return ""
+}
+
+func (x *ParseProgress) GetPercent() int32 {
+	if x != nil {
+		return x.Percent
+	}
+	return 0
+}
+
+func (x *ParseProgress) GetTotalChunks() int32 {
+	if x != nil {
+		return x.TotalChunks
+	}
+	return 0
+}
+
+type RerankRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	Documents     []string               `protobuf:"bytes,2,rep,name=documents,proto3" json:"documents,omitempty"`    // 候选片段正文
+	TopN          int32                  `protobuf:"varint,3,opt,name=top_n,json=topN,proto3" json:"top_n,omitempty"` // 只返回得分最高的 N 个，0 表示全部返回
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RerankRequest) Reset() {
+	*x = RerankRequest{}
+	mi := &file_rag_service_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RerankRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RerankRequest) ProtoMessage() {}
+
+func (x *RerankRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rag_service_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RerankRequest.ProtoReflect.Descriptor instead.
+func (*RerankRequest) Descriptor() ([]byte, []int) {
+	return file_rag_service_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RerankRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *RerankRequest) GetDocuments() []string {
+	if x != nil {
+		return x.Documents
+	}
+	return nil
+}
+
+func (x *RerankRequest) GetTopN() int32 {
+	if x != nil {
+		return x.TopN
+	}
+	return 0
+}
+
+type RerankResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*RerankResult        `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"` // 按分数从高到低排列
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RerankResponse) Reset() {
+	*x = RerankResponse{}
+	mi := &file_rag_service_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RerankResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RerankResponse) ProtoMessage() {}
+
+func (x *RerankResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rag_service_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RerankResponse.ProtoReflect.Descriptor instead.
+func (*RerankResponse) Descriptor() ([]byte, []int) {
+	return file_rag_service_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RerankResponse) GetResults() []*RerankResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type RerankResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`  // 对应 RerankRequest.documents 的下标
+	Score         float32                `protobuf:"fixed32,2,opt,name=score,proto3" json:"score,omitempty"` // 相关性分数
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RerankResult) Reset() {
+	*x = RerankResult{}
+	mi := &file_rag_service_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RerankResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RerankResult) ProtoMessage() {}
+
+func (x *RerankResult) ProtoReflect() protoreflect.Message {
+	mi := &file_rag_service_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RerankResult.ProtoReflect.Descriptor instead.
+func (*RerankResult) Descriptor() ([]byte, []int) {
+	return file_rag_service_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RerankResult) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *RerankResult) GetScore() float32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
 var File_rag_service_proto protoreflect.FileDescriptor
 
 const file_rag_service_proto_rawDesc = "" +
@@ -430,28 +912,64 @@ const file_rag_service_proto_rawDesc = "" +
 	"\tuse_graph\x18\x03 \x01(\bR\buseGraph\"S\n" +
 	"\vAskResponse\x12!\n" +
 	"\fanswer_delta\x18\x01 \x01(\tR\vanswerDelta\x12!\n" +
-	"\fthinking_log\x18\x02 \x01(\tR\vthinkingLog\"K\n" +
+	"\fthinking_log\x18\x02 \x01(\tR\vthinkingLog\"a\n" +
 	"\fEmbedRequest\x12\x14\n" +
 	"\x04text\x18\x01 \x01(\tH\x00R\x04text\x12\x1d\n" +
-	"\timage_url\x18\x02 \x01(\tH\x00R\bimageUrlB\x06\n" +
+	"\timage_url\x18\x02 \x01(\tH\x00R\bimageUrl\x12\x14\n" +
+	"\x05model\x18\x03 \x01(\tR\x05modelB\x06\n" +
 	"\x04data\"'\n" +
 	"\rEmbedResponse\x12\x16\n" +
-	"\x06vector\x18\x01 \x03(\x02R\x06vector\"N\n" +
+	"\x06vector\x18\x01 \x03(\x02R\x06vector\"w\n" +
 	"\fParseRequest\x12!\n" +
 	"\ffile_content\x18\x01 \x01(\fR\vfileContent\x12\x1b\n" +
-	"\tfile_name\x18\x02 \x01(\tR\bfileName\"9\n" +
+	"\tfile_name\x18\x02 \x01(\tR\bfileName\x12'\n" +
+	"\x0fembedding_model\x18\x03 \x01(\tR\x0eembeddingModel\"9\n" +
 	"\rParseResponse\x12(\n" +
-	"\x06chunks\x18\x01 \x03(\v2\x10.rag.v1.DocChunkR\x06chunks\"]\n" +
+	"\x06chunks\x18\x01 \x03(\v2\x10.rag.v1.DocChunkR\x06chunks\"~\n" +
 	"\bDocChunk\x12\x18\n" +
 	"\acontent\x18\x01 \x01(\tR\acontent\x12\x16\n" +
 	"\x06vector\x18\x02 \x03(\x02R\x06vector\x12\x1f\n" +
 	"\vpage_number\x18\x03 \x01(\x05R\n" +
-	"pageNumber2\xbc\x01\n" +
+	"pageNumber\x12\x1f\n" +
+	"\vchunk_index\x18\x04 \x01(\x05R\n" +
+	"chunkIndex\"j\n" +
+	"\x12ParseStreamRequest\x123\n" +
+	"\bmetadata\x18\x01 \x01(\v2\x15.rag.v1.ParseMetadataH\x00R\bmetadata\x12\x14\n" +
+	"\x04data\x18\x02 \x01(\fH\x00R\x04dataB\t\n" +
+	"\apayload\"\x84\x02\n" +
+	"\rParseMetadata\x12\x1b\n" +
+	"\tfile_name\x18\x01 \x01(\tR\bfileName\x12\x1b\n" +
+	"\tfile_size\x18\x02 \x01(\x03R\bfileSize\x12\x16\n" +
+	"\x06parser\x18\x03 \x01(\tR\x06parser\x12<\n" +
+	"\aoptions\x18\x04 \x03(\v2\".rag.v1.ParseMetadata.OptionsEntryR\aoptions\x12'\n" +
+	"\x0fembedding_model\x18\x05 \x01(\tR\x0eembeddingModel\x1a:\n" +
+	"\fOptionsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x7f\n" +
+	"\x13ParseStreamResponse\x12(\n" +
+	"\x05chunk\x18\x01 \x01(\v2\x10.rag.v1.DocChunkH\x00R\x05chunk\x123\n" +
+	"\bprogress\x18\x02 \x01(\v2\x15.rag.v1.ParseProgressH\x00R\bprogressB\t\n" +
+	"\apayload\"b\n" +
+	"\rParseProgress\x12\x14\n" +
+	"\x05stage\x18\x01 \x01(\tR\x05stage\x12\x18\n" +
+	"\apercent\x18\x02 \x01(\x05R\apercent\x12!\n" +
+	"\ftotal_chunks\x18\x03 \x01(\x05R\vtotalChunks\"X\n" +
+	"\rRerankRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x1c\n" +
+	"\tdocuments\x18\x02 \x03(\tR\tdocuments\x12\x13\n" +
+	"\x05top_n\x18\x03 \x01(\x05R\x04topN\"@\n" +
+	"\x0eRerankResponse\x12.\n" +
+	"\aresults\x18\x01 \x03(\v2\x14.rag.v1.RerankResultR\aresults\":\n" +
+	"\fRerankResult\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x02R\x05score2\xc9\x02\n" +
 	"\n" +
 	"LLMService\x126\n" +
 	"\tAskStream\x12\x12.rag.v1.AskRequest\x1a\x13.rag.v1.AskResponse0\x01\x128\n" +
 	"\tEmbedData\x12\x14.rag.v1.EmbedRequest\x1a\x15.rag.v1.EmbedResponse\x12<\n" +
-	"\rParseAndEmbed\x12\x14.rag.v1.ParseRequest\x1a\x15.rag.v1.ParseResponseB\x1bZ\x19Chimera-RAG/api/rag/v1;v1b\x06proto3"
+	"\rParseAndEmbed\x12\x14.rag.v1.ParseRequest\x1a\x15.rag.v1.ParseResponse\x12R\n" +
+	"\x13ParseAndEmbedStream\x12\x1a.rag.v1.ParseStreamRequest\x1a\x1b.rag.v1.ParseStreamResponse(\x010\x01\x127\n" +
+	"\x06Rerank\x12\x15.rag.v1.RerankRequest\x1a\x16.rag.v1.RerankResponseB\x1bZ\x19Chimera-RAG/api/rag/v1;v1b\x06proto3"
 
 var (
 	file_rag_service_proto_rawDescOnce sync.Once
@@ -465,29 +983,46 @@ func file_rag_service_proto_rawDescGZIP() []byte {
 	return file_rag_service_proto_rawDescData
 }
 
-var file_rag_service_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_rag_service_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
 var file_rag_service_proto_goTypes = []any{
-	(*AskRequest)(nil),    // 0: rag.v1.AskRequest
-	(*AskResponse)(nil),   // 1: rag.v1.AskResponse
-	(*EmbedRequest)(nil),  // 2: rag.v1.EmbedRequest
-	(*EmbedResponse)(nil), // 3: rag.v1.EmbedResponse
-	(*ParseRequest)(nil),  // 4: rag.v1.ParseRequest
-	(*ParseResponse)(nil), // 5: rag.v1.ParseResponse
-	(*DocChunk)(nil),      // 6: rag.v1.DocChunk
+	(*AskRequest)(nil),          // 0: rag.v1.AskRequest
+	(*AskResponse)(nil),         // 1: rag.v1.AskResponse
+	(*EmbedRequest)(nil),        // 2: rag.v1.EmbedRequest
+	(*EmbedResponse)(nil),       // 3: rag.v1.EmbedResponse
+	(*ParseRequest)(nil),        // 4: rag.v1.ParseRequest
+	(*ParseResponse)(nil),       // 5: rag.v1.ParseResponse
+	(*DocChunk)(nil),            // 6: rag.v1.DocChunk
+	(*ParseStreamRequest)(nil),  // 7: rag.v1.ParseStreamRequest
+	(*ParseMetadata)(nil),       // 8: rag.v1.ParseMetadata
+	(*ParseStreamResponse)(nil), // 9: rag.v1.ParseStreamResponse
+	(*ParseProgress)(nil),       // 10: rag.v1.ParseProgress
+	(*RerankRequest)(nil),       // 11: rag.v1.RerankRequest
+	(*RerankResponse)(nil),      // 12: rag.v1.RerankResponse
+	(*RerankResult)(nil),        // 13: rag.v1.RerankResult
+	nil,                         // 14: rag.v1.ParseMetadata.OptionsEntry
 }
 var file_rag_service_proto_depIdxs = []int32{
-	6, // 0: rag.v1.ParseResponse.chunks:type_name -> rag.v1.DocChunk
-	0, // 1: rag.v1.LLMService.AskStream:input_type -> rag.v1.AskRequest
-	2, // 2: rag.v1.LLMService.EmbedData:input_type -> rag.v1.EmbedRequest
-	4, // 3: rag.v1.LLMService.ParseAndEmbed:input_type -> rag.v1.ParseRequest
-	1, // 4: rag.v1.LLMService.AskStream:output_type -> rag.v1.AskResponse
-	3, // 5: rag.v1.LLMService.EmbedData:output_type -> rag.v1.EmbedResponse
-	5, // 6: rag.v1.LLMService.ParseAndEmbed:output_type -> rag.v1.ParseResponse
-	4, // [4:7] is the sub-list for method output_type
-	1, // [1:4] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	6,  // 0: rag.v1.ParseResponse.chunks:type_name -> rag.v1.DocChunk
+	8,  // 1: rag.v1.ParseStreamRequest.metadata:type_name -> rag.v1.ParseMetadata
+	14, // 2: rag.v1.ParseMetadata.options:type_name -> rag.v1.ParseMetadata.OptionsEntry
+	6,  // 3: rag.v1.ParseStreamResponse.chunk:type_name -> rag.v1.DocChunk
+	10, // 4: rag.v1.ParseStreamResponse.progress:type_name -> rag.v1.ParseProgress
+	13, // 5: rag.v1.RerankResponse.results:type_name -> rag.v1.RerankResult
+	0,  // 6: rag.v1.LLMService.AskStream:input_type -> rag.v1.AskRequest
+	2,  // 7: rag.v1.LLMService.EmbedData:input_type -> rag.v1.EmbedRequest
+	4,  // 8: rag.v1.LLMService.ParseAndEmbed:input_type -> rag.v1.ParseRequest
+	7,  // 9: rag.v1.LLMService.ParseAndEmbedStream:input_type -> rag.v1.ParseStreamRequest
+	11, // 10: rag.v1.LLMService.Rerank:input_type -> rag.v1.RerankRequest
+	1,  // 11: rag.v1.LLMService.AskStream:output_type -> rag.v1.AskResponse
+	3,  // 12: rag.v1.LLMService.EmbedData:output_type -> rag.v1.EmbedResponse
+	5,  // 13: rag.v1.LLMService.ParseAndEmbed:output_type -> rag.v1.ParseResponse
+	9,  // 14: rag.v1.LLMService.ParseAndEmbedStream:output_type -> rag.v1.ParseStreamResponse
+	12, // 15: rag.v1.LLMService.Rerank:output_type -> rag.v1.RerankResponse
+	11, // [11:16] is the sub-list for method output_type
+	6,  // [6:11] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_rag_service_proto_init() }
@@ -499,13 +1034,21 @@ func file_rag_service_proto_init() {
 		(*EmbedRequest_Text)(nil),
 		(*EmbedRequest_ImageUrl)(nil),
 	}
+	file_rag_service_proto_msgTypes[7].OneofWrappers = []any{
+		(*ParseStreamRequest_Metadata)(nil),
+		(*ParseStreamRequest_Data)(nil),
+	}
+	file_rag_service_proto_msgTypes[9].OneofWrappers = []any{
+		(*ParseStreamResponse_Chunk)(nil),
+		(*ParseStreamResponse_Progress)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_rag_service_proto_rawDesc), len(file_rag_service_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   7,
+			NumMessages:   15,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
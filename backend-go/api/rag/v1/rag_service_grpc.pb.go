@@ -19,9 +19,11 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	LLMService_AskStream_FullMethodName     = "/rag.v1.LLMService/AskStream"
-	LLMService_EmbedData_FullMethodName     = "/rag.v1.LLMService/EmbedData"
-	LLMService_ParseAndEmbed_FullMethodName = "/rag.v1.LLMService/ParseAndEmbed"
+	LLMService_AskStream_FullMethodName           = "/rag.v1.LLMService/AskStream"
+	LLMService_EmbedData_FullMethodName           = "/rag.v1.LLMService/EmbedData"
+	LLMService_ParseAndEmbed_FullMethodName       = "/rag.v1.LLMService/ParseAndEmbed"
+	LLMService_ParseAndEmbedStream_FullMethodName = "/rag.v1.LLMService/ParseAndEmbedStream"
+	LLMService_Rerank_FullMethodName              = "/rag.v1.LLMService/Rerank"
 )
 
 // LLMServiceClient is the client API for LLMService service.
@@ -32,6 +34,10 @@ type LLMServiceClient interface {
 	EmbedData(ctx context.Context, in *EmbedRequest, opts ...grpc.CallOption) (*EmbedResponse, error)
 	// 🔥 新增：解析并向量化 PDF
 	ParseAndEmbed(ctx context.Context, in *ParseRequest, opts ...grpc.CallOption) (*ParseResponse, error)
+	// 流式解析：客户端分片上传文件，服务端边解析边返回切片和进度，不受单条消息大小限制
+	ParseAndEmbedStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ParseStreamRequest, ParseStreamResponse], error)
+	// 重排序：用 Cross-Encoder 对候选片段逐一打分
+	Rerank(ctx context.Context, in *RerankRequest, opts ...grpc.CallOption) (*RerankResponse, error)
 }
 
 type lLMServiceClient struct {
@@ -81,6 +87,29 @@ func (c *lLMServiceClient) ParseAndEmbed(ctx context.Context, in *ParseRequest,
 	return out, nil
 }
 
+func (c *lLMServiceClient) ParseAndEmbedStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ParseStreamRequest, ParseStreamResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LLMService_ServiceDesc.Streams[1], LLMService_ParseAndEmbedStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ParseStreamRequest, ParseStreamResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LLMService_ParseAndEmbedStreamClient = grpc.BidiStreamingClient[ParseStreamRequest, ParseStreamResponse]
+
+func (c *lLMServiceClient) Rerank(ctx context.Context, in *RerankRequest, opts ...grpc.CallOption) (*RerankResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RerankResponse)
+	err := c.cc.Invoke(ctx, LLMService_Rerank_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // LLMServiceServer is the server API for LLMService service.
 // All implementations must embed UnimplementedLLMServiceServer
 // for forward compatibility.
@@ -89,6 +118,10 @@ type LLMServiceServer interface {
 	EmbedData(context.Context, *EmbedRequest) (*EmbedResponse, error)
 	// 🔥 新增：解析并向量化 PDF
 	ParseAndEmbed(context.Context, *ParseRequest) (*ParseResponse, error)
+	// 流式解析：客户端分片上传文件，服务端边解析边返回切片和进度，不受单条消息大小限制
+	ParseAndEmbedStream(grpc.BidiStreamingServer[ParseStreamRequest, ParseStreamResponse]) error
+	// 重排序：用 Cross-Encoder 对候选片段逐一打分
+	Rerank(context.Context, *RerankRequest) (*RerankResponse, error)
 	mustEmbedUnimplementedLLMServiceServer()
 }
 
@@ -108,6 +141,12 @@ func (UnimplementedLLMServiceServer) EmbedData(context.Context, *EmbedRequest) (
 func (UnimplementedLLMServiceServer) ParseAndEmbed(context.Context, *ParseRequest) (*ParseResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ParseAndEmbed not implemented")
 }
+func (UnimplementedLLMServiceServer) ParseAndEmbedStream(grpc.BidiStreamingServer[ParseStreamRequest, ParseStreamResponse]) error {
+	return status.Error(codes.Unimplemented, "method ParseAndEmbedStream not implemented")
+}
+func (UnimplementedLLMServiceServer) Rerank(context.Context, *RerankRequest) (*RerankResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Rerank not implemented")
+}
 func (UnimplementedLLMServiceServer) mustEmbedUnimplementedLLMServiceServer() {}
 func (UnimplementedLLMServiceServer) testEmbeddedByValue()                    {}
 
@@ -176,6 +215,31 @@ func _LLMService_ParseAndEmbed_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LLMService_ParseAndEmbedStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LLMServiceServer).ParseAndEmbedStream(&grpc.GenericServerStream[ParseStreamRequest, ParseStreamResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LLMService_ParseAndEmbedStreamServer = grpc.BidiStreamingServer[ParseStreamRequest, ParseStreamResponse]
+
+func _LLMService_Rerank_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RerankRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LLMServiceServer).Rerank(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LLMService_Rerank_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LLMServiceServer).Rerank(ctx, req.(*RerankRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // LLMService_ServiceDesc is the grpc.ServiceDesc for LLMService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -191,6 +255,10 @@ var LLMService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ParseAndEmbed",
 			Handler:    _LLMService_ParseAndEmbed_Handler,
 		},
+		{
+			MethodName: "Rerank",
+			Handler:    _LLMService_Rerank_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -198,6 +266,12 @@ var LLMService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _LLMService_AskStream_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "ParseAndEmbedStream",
+			Handler:       _LLMService_ParseAndEmbedStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "rag_service.proto",
 }
@@ -23,7 +23,7 @@ func main() {
 	cfg := conf.LoadConfig()
 
 	// 2. 初始化 gRPC 连接 (Python AI Service)
-	// 设置 100MB 限制以支持大文件传输
+	// 设置 100MB 限制: 文档解析走流式接口不受此限制，这里只为兼容老版本的整文件 ParseAndEmbed
 	maxMsgSize := 100 * 1024 * 1024
 	conn, err := grpc.NewClient(
 		cfg.AI.GRPCHost,
@@ -48,16 +48,31 @@ func main() {
 
 	// 4. 初始化服务层与 Worker
 	grpcClient := pb.NewLLMServiceClient(conn)
-	ragService := service.NewRagService(grpcClient, d)
-	etlWorker := worker.NewETLWorker(d, grpcClient)
+	accessPolicy := service.NewAccessPolicy(d)
+	ragService := service.NewRagService(grpcClient, d, cfg, accessPolicy)
+	conversationService := service.NewConversationService(d)
+	documentService := service.NewDocumentService(d, accessPolicy)
+	chunkService := service.NewChunkService(grpcClient, d, accessPolicy)
+	knowledgeBaseService := service.NewKnowledgeBaseService(d, accessPolicy)
+	groupService := service.NewGroupService(d, accessPolicy)
+	organizationService := service.NewOrganizationService(d, accessPolicy)
+	etlWorker := worker.NewETLWorker(d, grpcClient, cfg.ETL)
 
 	// 启动后台 ETL Worker (处理文件解析任务)
-	go etlWorker.Start(context.Background(), 3)
-	log.Println("✅ 后台 ETL Worker 已启动 (并发数: 3)")
+	go etlWorker.Start(context.Background(), cfg.ETL.Workers)
+	log.Printf("✅ 后台 ETL Worker 已启动 (并发数: %d)", cfg.ETL.Workers)
 
 	// 5. 初始化 Handler (控制器)
-	authHandler := handler.NewAuthHandler(d.DB) // 🆕 注入 Postgres DB
+	authHandler := handler.NewAuthHandler(d) // 🆕 注入 Postgres DB
 	chatHandler := handler.NewChatHandler(ragService)
+	conversationHandler := handler.NewConversationHandler(conversationService)
+	documentHandler := handler.NewDocumentHandler(documentService)
+	chunkHandler := handler.NewChunkHandler(chunkService)
+	knowledgeBaseHandler := handler.NewKnowledgeBaseHandler(knowledgeBaseService)
+	groupHandler := handler.NewGroupHandler(groupService)
+	organizationHandler := handler.NewOrganizationHandler(organizationService)
+	adminHandler := handler.NewAdminHandler(etlWorker.Queue())
+	reindexHandler := handler.NewReindexHandler(service.NewReindexService(d))
 
 	// 6. 初始化 Gin Web Server
 	r := gin.Default()
@@ -65,12 +80,15 @@ func main() {
 	// 🔥 关键：配置 CORS 跨域
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"}, // 开发环境允许所有，生产环境建议指定前端域名
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "traceparent"},
+		ExposeHeaders:    []string{"Content-Length", "traceparent"},
 		AllowCredentials: true,
 	}))
 
+	// 链路追踪: 上传请求的 traceparent 会写入入库任务
+	r.Use(middleware.Trace())
+
 	// 7. 注册路由
 	api := r.Group("/api/v1")
 	{
@@ -89,8 +107,87 @@ func main() {
 			// 只有登录用户才能访问下面这些
 			protected.POST("/upload", chatHandler.HandleUpload)
 			protected.POST("/chat/stream", chatHandler.HandleChatSSE) // 聊天也建议保护起来
+			protected.GET("/file/*filename", chatHandler.HandleGetFile)
+
+			// 接受组织邀请
+			protected.POST("/invites/accept", organizationHandler.HandleAcceptInvite)
+
+			// 文档管理
+			protected.GET("/documents", documentHandler.HandleList)
+			protected.DELETE("/documents/:id", documentHandler.HandleDelete)
+			protected.GET("/documents/:id/status", documentHandler.HandleStatus)
+			protected.GET("/documents/:id/progress", documentHandler.HandleProgress)
+
+			// 切片浏览与人工修订
+			protected.GET("/documents/:id/chunks", chunkHandler.HandleList)
+			protected.GET("/documents/:id/chunks/audits", chunkHandler.HandleAudits)
+			protected.PATCH("/documents/:id/chunks/:chunk_id", chunkHandler.HandleEdit)
+			protected.POST("/documents/:id/chunks/exclusion", chunkHandler.HandleExclude)
+			protected.POST("/documents/:id/chunks/merge", chunkHandler.HandleMerge)
+			protected.POST("/documents/:id/chunks/sync", chunkHandler.HandleResync)
+			protected.POST("/documents/:id/chunks/:chunk_id/split", chunkHandler.HandleSplit)
+
+			// 知识库 (文件夹树)
+			protected.GET("/knowledge-bases", knowledgeBaseHandler.HandleTree)
+			protected.POST("/knowledge-bases", knowledgeBaseHandler.HandleCreate)
+			protected.PATCH("/knowledge-bases/:id", knowledgeBaseHandler.HandleUpdate)
+			protected.POST("/knowledge-bases/:id/move", knowledgeBaseHandler.HandleMove)
+			protected.DELETE("/knowledge-bases/:id", knowledgeBaseHandler.HandleDelete)
+
+			// 知识库授权 (manager)
+			protected.GET("/knowledge-bases/:id/grants", knowledgeBaseHandler.HandleListGrants)
+			protected.POST("/knowledge-bases/:id/grants", knowledgeBaseHandler.HandleGrant)
+			protected.DELETE("/knowledge-bases/:id/grants/:subject_type/:subject_id", knowledgeBaseHandler.HandleRevoke)
+
+			// 会话管理
+			protected.GET("/conversations", conversationHandler.HandleList)
+			protected.GET("/conversations/:id", conversationHandler.HandleGet)
+			protected.PATCH("/conversations/:id", conversationHandler.HandleRename)
+			protected.DELETE("/conversations/:id", conversationHandler.HandleDelete)
+			protected.GET("/conversations/:id/export", conversationHandler.HandleExport)
+		}
+
+		// 运维接口，仅管理员可访问
+		admin := api.Group("/admin")
+		admin.Use(middleware.JWTAuth(), middleware.RequireRole("admin"))
+		{
+			admin.GET("/queues", adminHandler.HandleQueueStats)
+
+			// 重建索引 (切换向量模型): 创建 -> 查看进度 -> 切换 / 回滚
+			admin.POST("/reindex", reindexHandler.HandleStart)
+			admin.GET("/reindex", reindexHandler.HandleList)
+			admin.GET("/reindex/:id", reindexHandler.HandleGet)
+			admin.POST("/reindex/:id/cutover", reindexHandler.HandleCutOver)
+			admin.POST("/reindex/:id/rollback", reindexHandler.HandleRollback)
+
+			// 组织 (租户)
+			admin.GET("/organizations", organizationHandler.HandleList)
+			admin.POST("/organizations", organizationHandler.HandleCreate)
+			admin.PUT("/users/:id/organization", organizationHandler.HandleAssignUser)
+		}
+
+		// 组织管理接口，组织管理员只能看到和管理本租户
+		org := api.Group("/org")
+		org.Use(middleware.JWTAuth(), middleware.RequireRole("admin", "org_admin"))
+		{
+			org.GET("", organizationHandler.HandleCurrent)
+			org.GET("/users", organizationHandler.HandleUsers)
+			org.PUT("/users/:id/role", organizationHandler.HandleSetUserRole)
+			org.DELETE("/users/:id", organizationHandler.HandleRemoveMember)
+
+			// 邀请成员
+			org.GET("/invites", organizationHandler.HandleInvites)
+			org.POST("/invites", organizationHandler.HandleInvite)
+			org.DELETE("/invites/:id", organizationHandler.HandleRevokeInvite)
+
+			// 用户组 (用于批量授权知识库)
+			org.GET("/groups", groupHandler.HandleList)
+			org.POST("/groups", groupHandler.HandleCreate)
+			org.GET("/groups/:id", groupHandler.HandleGet)
+			org.DELETE("/groups/:id", groupHandler.HandleDelete)
+			org.POST("/groups/:id/members", groupHandler.HandleAddMember)
+			org.DELETE("/groups/:id/members/:user_id", groupHandler.HandleRemoveMember)
 		}
-		protected.GET("/file/:filename", chatHandler.HandleGetFile)
 	}
 
 	log.Println("🚀 Chimera-RAG 后端已启动，监听端口 :8080")
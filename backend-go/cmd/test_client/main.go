@@ -7,7 +7,7 @@ import (
 	"log"
 	"time"
 
-	pb "Chimera-RAG/api/rag/v1"
+	pb "Chimera-RAG/backend-go/api/rag/v1"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -60,9 +60,6 @@ func main() {
 		if resp.AnswerDelta != "" {
 			fmt.Printf("%s", resp.AnswerDelta) // 不换行，模拟打字机
 		}
-		if len(resp.SourceDocs) > 0 {
-			fmt.Printf("\n\n[📚 引用]: %s (页码: %s)", resp.SourceDocs[0].DocName, resp.SourceDocs[0].PageNum)
-		}
 	}
 	fmt.Println("\n\n--- 对话结束 ---")
 }
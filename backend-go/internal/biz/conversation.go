@@ -0,0 +1,36 @@
+package biz
+
+import "time"
+
+// ConversationSummary 会话列表项
+type ConversationSummary struct {
+	SessionID string    `json:"session_id"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ConversationPage 会话分页结果
+type ConversationPage struct {
+	Items    []ConversationSummary `json:"items"`
+	Total    int64                 `json:"total"`
+	Page     int                   `json:"page"`
+	PageSize int                   `json:"page_size"`
+}
+
+// ConversationExport 会话导出 (JSON 格式)
+type ConversationExport struct {
+	SessionID  string            `json:"session_id"`
+	Title      string            `json:"title"`
+	CreatedAt  time.Time         `json:"created_at"`
+	ExportedAt time.Time         `json:"exported_at"`
+	Messages   []ExportedMessage `json:"messages"`
+}
+
+// ExportedMessage 导出的单条消息，正文中的 <<文件名|页码>> 已替换为 [n] 角标
+type ExportedMessage struct {
+	Role      string      `json:"role"`
+	Content   string      `json:"content"`
+	CreatedAt time.Time   `json:"created_at"`
+	Citations []SourceDoc `json:"citations,omitempty"` // 下标 n-1 对应正文中的 [n]
+}
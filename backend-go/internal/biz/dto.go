@@ -1,11 +1,37 @@
 package biz
 
+// 检索模式
+const (
+	SearchModeVector  = "vector"  // 仅向量检索
+	SearchModeKeyword = "keyword" // 仅关键词检索 (BM25)
+	SearchModeHybrid  = "hybrid"  // 向量 + 关键词，RRF 融合 (默认)
+)
+
 // ChatRequest 是前端发来的 JSON
 type ChatRequest struct {
 	Query     string `json:"query" binding:"required"`
 	SessionID string `json:"session_id"`
 	UseGraph  bool   `json:"use_graph"`
 	UseSearch bool   `json:"use_search"`
+
+	// SearchMode 可选 vector / keyword / hybrid，为空时走 hybrid
+	SearchMode string `json:"search_mode" binding:"omitempty,oneof=vector keyword hybrid"`
+
+	// Filter 限定检索范围 (某个文件夹 / 指定文档)，为空则检索全部
+	Filter *SearchFilter `json:"filter"`
+}
+
+// SearchFilter 检索范围过滤
+// 不同字段之间是 AND，同一字段的多个取值之间是 OR
+type SearchFilter struct {
+	KnowledgeBaseIDs []uint   `json:"knowledge_base_ids"` // 包含子文件夹
+	DocumentIDs      []uint   `json:"document_ids"`
+	OwnerIDs         []uint   `json:"owner_ids"`
+	OrganizationIDs  []uint   `json:"organization_ids"`
+	FileTypes        []string `json:"file_types"` // 例如 ".pdf"
+	FileNames        []string `json:"file_names"` // 上传时的文件名
+	PageFrom         int32    `json:"page_from"`
+	PageTo           int32    `json:"page_to"`
 }
 
 // 这里的结构体只用于绑定请求，响应我们直接写流，不需要定义结构体
@@ -0,0 +1,82 @@
+package biz
+
+// ---------------------------------------------------------
+// SSE 事件协议 (POST /api/v1/chat/stream)
+// ---------------------------------------------------------
+//
+// 每个事件的 SSE event 名称见下方常量，data 统一为 JSON:
+//   {"v": "v1", "data": {...}}
+// 协议有不兼容变更时递增 ChatEventVersion，前端据此做兼容。
+
+// ChatEventVersion SSE 事件协议版本
+const ChatEventVersion = "v1"
+
+// SSE 事件名称
+const (
+	EventThinking    = "thinking"     // 思考过程 ThinkingData
+	EventRetrieval   = "retrieval"    // 检索到的来源列表 RetrievalData
+	EventAnswerDelta = "answer_delta" // 回答增量 AnswerDeltaData
+	EventCitation    = "citation"     // 回答中引用了某个来源 CitationData
+	EventUsage       = "usage"        // 耗时与用量统计 UsageData
+	EventError       = "error"        // 出错，之后会紧跟 done ErrorData
+	EventDone        = "done"         // 结束 DoneData
+)
+
+// ChatEvent 一个 SSE 事件
+type ChatEvent struct {
+	Event   string `json:"-"`
+	Version string `json:"v"`
+	Data    any    `json:"data"`
+}
+
+func NewChatEvent(event string, data any) ChatEvent {
+	return ChatEvent{Event: event, Version: ChatEventVersion, Data: data}
+}
+
+// SourceDoc 一个检索来源
+type SourceDoc struct {
+	DocumentID uint    `json:"document_id"`
+	FileName   string  `json:"file_name"` // 存储对象名，可直接用于 GET /file/:filename
+	Title      string  `json:"title"`     // 原始文件名
+	Page       int32   `json:"page_number"`
+	Score      float32 `json:"score"`
+	Snippet    string  `json:"snippet"`
+}
+
+type ThinkingData struct {
+	Message string `json:"message"`
+}
+
+type RetrievalData struct {
+	Mode    string      `json:"mode"`
+	Sources []SourceDoc `json:"sources"`
+}
+
+type AnswerDeltaData struct {
+	Text string `json:"text"`
+}
+
+// CitationData 回答正文中出现的 <<文件名|页码>> 标记，已解析到对应来源
+type CitationData struct {
+	Marker      string    `json:"marker"`       // 原始标记文本
+	SourceIndex int       `json:"source_index"` // 对应 RetrievalData.Sources 的下标
+	Source      SourceDoc `json:"source"`
+}
+
+// UsageData 本轮问答的统计信息 (字符数而非 token 数，AI 服务暂未回传 token 用量)
+type UsageData struct {
+	PromptChars  int   `json:"prompt_chars"`
+	AnswerChars  int   `json:"answer_chars"`
+	SourceCount  int   `json:"source_count"`
+	RetrievalMs  int64 `json:"retrieval_ms"`
+	GenerationMs int64 `json:"generation_ms"`
+	TotalMs      int64 `json:"total_ms"`
+}
+
+type ErrorData struct {
+	Message string `json:"message"`
+}
+
+type DoneData struct {
+	SessionID string `json:"session_id"`
+}
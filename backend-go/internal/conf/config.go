@@ -3,12 +3,19 @@ package conf
 import (
 	"github.com/spf13/viper"
 	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
 	App  AppConfig
 	Data DataConfig
 	AI   AIConfig
+	ETL  ETLConfig
+
+	Embedding EmbeddingConfig
 }
 
 type AppConfig struct {
@@ -21,10 +28,134 @@ type DataConfig struct {
 	MinioAccessKey string
 	MinioSecretKey string
 	QdrantAddr     string
+
+	// 向量存储: qdrant (默认)、pgvector 或 memory (进程内，本地开发/测试用)
+	VectorStore string
+	// 集合名前缀，未显式指定集合的模型使用 <前缀>_<模型名>
+	QdrantCollection string
+	// pgvector 近似索引: hnsw (默认) 或 ivfflat
+	PgvectorIndex string
+}
+
+// 向量距离
+const (
+	DistanceCosine = "cosine"
+	DistanceDot    = "dot"
+	DistanceEuclid = "euclid"
+)
+
+// EmbeddingModel 一个向量模型，每个模型的向量存放在独立的集合里
+type EmbeddingModel struct {
+	Name       string
+	Dimension  uint64
+	Distance   string
+	Collection string // 为空时按模型名生成
+}
+
+// EmbeddingConfig 向量模型注册表
+// EMBEDDING_MODELS 格式: 名称:维度[:距离[:集合]]，多个模型用逗号分隔
+// 例如 "default:384:cosine:chimera_docs,bge-base-zh:768"
+// AI Service 用 EMBEDDING_MODEL_PATHS (名称=模型路径) 加载同名模型，两个变量的格式不同，不要混用
+type EmbeddingConfig struct {
+	Models []EmbeddingModel
+	// Active 当前用于入库和检索的模型
+	Active string
+}
+
+// Model 按名称查找模型
+func (c EmbeddingConfig) Model(name string) (EmbeddingModel, bool) {
+	for _, m := range c.Models {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return EmbeddingModel{}, false
+}
+
+// ActiveModel 当前模型 (LoadConfig 已校验存在)
+func (c EmbeddingConfig) ActiveModel() EmbeddingModel {
+	m, _ := c.Model(c.Active)
+	return m
+}
+
+var modelNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// parseEmbeddingModels 解析 EMBEDDING_MODELS，格式错误直接退出
+func parseEmbeddingModels(spec string) []EmbeddingModel {
+	var models []EmbeddingModel
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.Split(item, ":")
+		if len(parts) < 2 || len(parts) > 4 {
+			log.Fatalf("EMBEDDING_MODELS 格式错误: %q (应为 名称:维度[:距离[:集合]])", item)
+		}
+
+		m := EmbeddingModel{Name: parts[0], Distance: DistanceCosine}
+		if !modelNamePattern.MatchString(m.Name) || seen[m.Name] {
+			log.Fatalf("EMBEDDING_MODELS 模型名无效或重复: %q", m.Name)
+		}
+		seen[m.Name] = true
+
+		dim, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil || dim == 0 {
+			log.Fatalf("EMBEDDING_MODELS 模型 %s 的维度无效: %q", m.Name, parts[1])
+		}
+		m.Dimension = dim
+
+		if len(parts) > 2 && parts[2] != "" {
+			m.Distance = strings.ToLower(parts[2])
+		}
+		switch m.Distance {
+		case DistanceCosine, DistanceDot, DistanceEuclid:
+		default:
+			log.Fatalf("EMBEDDING_MODELS 模型 %s 的距离无效: %q (可选 cosine/dot/euclid)", m.Name, m.Distance)
+		}
+
+		if len(parts) > 3 {
+			m.Collection = parts[3]
+		}
+		models = append(models, m)
+	}
+	return models
 }
 
 type AIConfig struct {
 	GRPCHost string
+
+	// 召回数: 不做精排 (关闭或 AI 服务不支持) 时直接取这么多片段进 Prompt
+	RetrievalTopK int
+
+	// 重排序: 先召回 RerankCandidates 个候选，精排后保留 RerankTopN 个
+	RerankEnabled    bool
+	RerankCandidates int
+	RerankTopN       int
+
+	// 多轮对话: 带入最近 HistoryTurns 轮历史，并先把追问改写成独立问题再检索
+	HistoryTurns int
+	QueryRewrite bool
+}
+
+// ETLConfig 文档解析队列
+type ETLConfig struct {
+	Workers int
+
+	// 可见性超时: 任务被领取后超过该时间未确认，视为 Worker 已挂，由其他 Worker 接管
+	VisibilityTimeout time.Duration
+	// 重试: 最多执行 MaxAttempts 次，间隔从 RetryBackoff 开始指数增长，上限 RetryMaxBackoff
+	MaxAttempts     int
+	RetryBackoff    time.Duration
+	RetryMaxBackoff time.Duration
+
+	// 解析成功后自动投递摘要任务
+	AutoSummary bool
+
+	// 向量写入: 每批 UpsertBatchSize 个切片，单批失败最多重试 UpsertRetries 次
+	UpsertBatchSize int
+	UpsertRetries   int
 }
 
 func LoadConfig() *Config {
@@ -37,7 +168,27 @@ func LoadConfig() *Config {
 	v.SetDefault("DATA_MINIO_AK", "minioadmin") // 默认值
 	v.SetDefault("DATA_MINIO_SK", "minioadmin")
 	v.SetDefault("DATA_QDRANT_ADDR", "localhost:6334")
+	v.SetDefault("DATA_VECTOR_STORE", "qdrant")
+	v.SetDefault("DATA_QDRANT_COLLECTION", "chimera_docs")
+	// 默认模型沿用原来的集合，已有数据不需要迁移
+	v.SetDefault("EMBEDDING_MODELS", "default:384:cosine:chimera_docs")
+	v.SetDefault("EMBEDDING_MODEL", "default")
+	v.SetDefault("DATA_PGVECTOR_INDEX", "hnsw")
 	v.SetDefault("AI_GRPC_HOST", "localhost:50051")
+	v.SetDefault("AI_RETRIEVAL_TOP_K", 15)
+	v.SetDefault("AI_RERANK_ENABLED", true)
+	v.SetDefault("AI_RERANK_CANDIDATES", 40)
+	v.SetDefault("AI_RERANK_TOP_N", 8)
+	v.SetDefault("AI_HISTORY_TURNS", 5)
+	v.SetDefault("AI_QUERY_REWRITE", true)
+	v.SetDefault("ETL_WORKERS", 3)
+	v.SetDefault("ETL_VISIBILITY_TIMEOUT", "15m")
+	v.SetDefault("ETL_MAX_ATTEMPTS", 5)
+	v.SetDefault("ETL_RETRY_BACKOFF", "10s")
+	v.SetDefault("ETL_RETRY_MAX_BACKOFF", "10m")
+	v.SetDefault("ETL_AUTO_SUMMARY", false)
+	v.SetDefault("ETL_UPSERT_BATCH_SIZE", 100)
+	v.SetDefault("ETL_UPSERT_RETRIES", 3)
 
 	// 2. 允许读取环境变量 (自动将 . 转换为 _)
 	v.AutomaticEnv()
@@ -57,7 +208,29 @@ func LoadConfig() *Config {
 	c.Data.MinioAccessKey = v.GetString("DATA_MINIO_AK")
 	c.Data.MinioSecretKey = v.GetString("DATA_MINIO_SK")
 	c.Data.QdrantAddr = v.GetString("DATA_QDRANT_ADDR")
+	c.Data.VectorStore = v.GetString("DATA_VECTOR_STORE")
+	c.Data.QdrantCollection = v.GetString("DATA_QDRANT_COLLECTION")
+	c.Data.PgvectorIndex = v.GetString("DATA_PGVECTOR_INDEX")
 	c.AI.GRPCHost = v.GetString("AI_GRPC_HOST")
+	c.AI.RetrievalTopK = v.GetInt("AI_RETRIEVAL_TOP_K")
+	c.AI.RerankEnabled = v.GetBool("AI_RERANK_ENABLED")
+	c.AI.RerankCandidates = v.GetInt("AI_RERANK_CANDIDATES")
+	c.AI.RerankTopN = v.GetInt("AI_RERANK_TOP_N")
+	c.AI.HistoryTurns = v.GetInt("AI_HISTORY_TURNS")
+	c.AI.QueryRewrite = v.GetBool("AI_QUERY_REWRITE")
+	c.ETL.Workers = v.GetInt("ETL_WORKERS")
+	c.ETL.VisibilityTimeout = v.GetDuration("ETL_VISIBILITY_TIMEOUT")
+	c.ETL.MaxAttempts = v.GetInt("ETL_MAX_ATTEMPTS")
+	c.ETL.RetryBackoff = v.GetDuration("ETL_RETRY_BACKOFF")
+	c.ETL.RetryMaxBackoff = v.GetDuration("ETL_RETRY_MAX_BACKOFF")
+	c.ETL.AutoSummary = v.GetBool("ETL_AUTO_SUMMARY")
+	c.ETL.UpsertBatchSize = v.GetInt("ETL_UPSERT_BATCH_SIZE")
+	c.ETL.UpsertRetries = v.GetInt("ETL_UPSERT_RETRIES")
+	c.Embedding.Models = parseEmbeddingModels(v.GetString("EMBEDDING_MODELS"))
+	c.Embedding.Active = v.GetString("EMBEDDING_MODEL")
+	if _, ok := c.Embedding.Model(c.Embedding.Active); !ok {
+		log.Fatalf("EMBEDDING_MODEL=%s 不在 EMBEDDING_MODELS 中", c.Embedding.Active)
+	}
 
 	log.Println("✅ 配置加载完成")
 	return &c
@@ -0,0 +1,107 @@
+package data
+
+import (
+	"slices"
+
+	"github.com/qdrant/go-client/qdrant"
+	"gorm.io/gorm"
+)
+
+// ---------------------------------------------------------
+// 访问范围 (由 service.AccessPolicy 计算，data 层只负责翻译成查询条件)
+// ---------------------------------------------------------
+
+// AccessScope 某个用户能看到的文档范围
+// 租户条件必须满足；租户内的各条件之间是 OR 关系
+type AccessScope struct {
+	// AllTenants 为 true 时不限租户 (平台管理员)
+	AllTenants bool
+	// OrganizationID 调用者所在的租户，0 为默认租户
+	OrganizationID uint
+	// All 为 true 时租户内不做限制 (组织成员、组织管理员、平台管理员)
+	All bool
+
+	// 自己上传的文档
+	OwnerID uint
+	// 公开、自己创建或被授权 (含所在用户组) 的知识库，均含子文件夹
+	KnowledgeBaseIDs []uint
+}
+
+// qdrantConditions 转换为 Qdrant 的 must 条件: 租户 + 租户内的 should 条件组
+func (s *AccessScope) qdrantConditions() []*qdrant.Condition {
+	var must []*qdrant.Condition
+	if !s.AllTenants {
+		must = append(must, qdrant.NewMatchInt(PayloadOrganizationID, int64(s.OrganizationID)))
+	}
+	if !s.All {
+		should := []*qdrant.Condition{
+			qdrant.NewMatchInt(PayloadOwnerID, int64(s.OwnerID)),
+		}
+		if len(s.KnowledgeBaseIDs) > 0 {
+			should = append(should, qdrant.NewMatchInts(PayloadKnowledgeBaseID, uintsToInt64s(s.KnowledgeBaseIDs)...))
+		}
+		must = append(must, qdrant.NewFilterAsCondition(&qdrant.Filter{Should: should}))
+	}
+	return must
+}
+
+// allows 在内存中判断切片是否可见 (关键词索引使用)
+func (s *AccessScope) allows(c LexicalChunk) bool {
+	if s == nil {
+		return true
+	}
+	return s.allowsIn(c.OrganizationID, c.OwnerID, c.KnowledgeBaseID)
+}
+
+func (s *AccessScope) allowsIn(orgID, ownerID, kbID uint) bool {
+	if !s.AllTenants && orgID != s.OrganizationID {
+		return false
+	}
+	if s.All || ownerID == s.OwnerID {
+		return true
+	}
+	return slices.Contains(s.KnowledgeBaseIDs, kbID)
+}
+
+// AllowsDocument 判断单个文档是否可见
+func (s *AccessScope) AllowsDocument(doc *Document) bool {
+	return s.allowsIn(doc.OrganizationID, doc.OwnerID, doc.KnowledgeBaseID)
+}
+
+// ScopeDocuments 为 Document 列表查询追加可见性条件
+// 用法: db.Model(&Document{}).Scopes(scope.ScopeDocuments).Find(&docs)
+func (s *AccessScope) ScopeDocuments(db *gorm.DB) *gorm.DB {
+	if !s.AllTenants {
+		db = db.Where("documents.organization_id = ?", s.OrganizationID)
+	}
+	if s.All {
+		return db
+	}
+
+	cond := db.Session(&gorm.Session{NewDB: true}).Where("documents.owner_id = ?", s.OwnerID)
+	if len(s.KnowledgeBaseIDs) > 0 {
+		cond = cond.Or("documents.knowledge_base_id IN ?", s.KnowledgeBaseIDs)
+	}
+	return db.Where(cond)
+}
+
+// AllowsKnowledgeBase 判断单个知识库是否可见，规则与文档相同
+func (s *AccessScope) AllowsKnowledgeBase(kb *KnowledgeBase) bool {
+	return s.allowsIn(kb.OrganizationID, kb.OwnerID, kb.ID)
+}
+
+// ScopeKnowledgeBases 为 KnowledgeBase 列表查询追加可见性条件
+func (s *AccessScope) ScopeKnowledgeBases(db *gorm.DB) *gorm.DB {
+	if !s.AllTenants {
+		db = db.Where("knowledge_bases.organization_id = ?", s.OrganizationID)
+	}
+	if s.All {
+		return db
+	}
+
+	cond := db.Session(&gorm.Session{NewDB: true}).Where("knowledge_bases.owner_id = ?", s.OwnerID)
+	if len(s.KnowledgeBaseIDs) > 0 {
+		cond = cond.Or("knowledge_bases.id IN ?", s.KnowledgeBaseIDs)
+	}
+	return db.Where(cond)
+}
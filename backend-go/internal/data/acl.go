@@ -0,0 +1,221 @@
+package data
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ---------------------------------------------------------
+// 知识库授权 (ACL) 与用户组
+// data 层只负责存取，角色的计算与继承规则在 service.AccessPolicy
+// ---------------------------------------------------------
+
+// 知识库角色，权限依次递增
+const (
+	RoleViewer  = "viewer"  // 查看、检索、下载
+	RoleEditor  = "editor"  // + 上传、删除、修订文档，新建子文件夹
+	RoleManager = "manager" // + 修改 / 移动 / 删除知识库，授权
+)
+
+// 授权主体
+const (
+	SubjectUser  = "user"
+	SubjectGroup = "group"
+)
+
+var roleRanks = map[string]int{RoleViewer: 1, RoleEditor: 2, RoleManager: 3}
+
+// RoleRank 角色的等级，未知角色 (含空) 为 0
+func RoleRank(role string) int {
+	return roleRanks[role]
+}
+
+// ValidRole 是否为合法角色
+func ValidRole(role string) bool {
+	return RoleRank(role) > 0
+}
+
+var (
+	ErrGroupNotFound = errors.New("group not found")
+	ErrGrantNotFound = errors.New("grant not found")
+	// ErrInvalidGrant 角色或授权主体类型未知、被授权的用户不存在
+	ErrInvalidGrant = errors.New("invalid grant")
+)
+
+// UserGroupIDs 用户所在的全部用户组
+func (d *Data) UserGroupIDs(ctx context.Context, userID uint) ([]uint, error) {
+	var ids []uint
+	err := d.DB.WithContext(ctx).Model(&GroupMember{}).
+		Where("user_id = ?", userID).
+		Pluck("group_id", &ids).Error
+	return ids, err
+}
+
+// subjectCondition 授权给 userID 本人或其所在用户组的条件
+func subjectCondition(db *gorm.DB, userID uint, groupIDs []uint) *gorm.DB {
+	cond := db.Session(&gorm.Session{NewDB: true}).
+		Where("subject_type = ? AND subject_id = ?", SubjectUser, userID)
+	if len(groupIDs) > 0 {
+		cond = cond.Or("subject_type = ? AND subject_id IN ?", SubjectGroup, groupIDs)
+	}
+	return cond
+}
+
+// GrantedKnowledgeBaseIDs 直接授权给用户 (或其用户组) 的知识库，不含子文件夹
+func (d *Data) GrantedKnowledgeBaseIDs(ctx context.Context, userID uint, groupIDs []uint) ([]uint, error) {
+	db := d.DB.WithContext(ctx)
+	var ids []uint
+	err := db.Model(&KnowledgeBaseGrant{}).
+		Where(subjectCondition(db, userID, groupIDs)).
+		Distinct().
+		Pluck("knowledge_base_id", &ids).Error
+	return ids, err
+}
+
+// OwnedKnowledgeBaseIDs 用户创建的知识库，不含子文件夹
+func (d *Data) OwnedKnowledgeBaseIDs(ctx context.Context, userID uint) ([]uint, error) {
+	var ids []uint
+	err := d.DB.WithContext(ctx).Model(&KnowledgeBase{}).
+		Where("owner_id = ?", userID).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// KnowledgeBaseAncestors 返回从 id 自身开始、沿 ParentID 直到根的整条链
+func (d *Data) KnowledgeBaseAncestors(ctx context.Context, id uint) ([]KnowledgeBase, error) {
+	var chain []KnowledgeBase
+	seen := make(map[uint]bool)
+	for next := &id; next != nil && !seen[*next]; {
+		var kb KnowledgeBase
+		err := d.DB.WithContext(ctx).First(&kb, *next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if len(chain) == 0 {
+				return nil, ErrKnowledgeBaseNotFound
+			}
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		seen[kb.ID] = true
+		chain = append(chain, kb)
+		next = kb.ParentID
+	}
+	return chain, nil
+}
+
+// GrantsFor 用户 (或其用户组) 在这些知识库上的授权
+func (d *Data) GrantsFor(ctx context.Context, kbIDs []uint, userID uint, groupIDs []uint) ([]KnowledgeBaseGrant, error) {
+	if len(kbIDs) == 0 {
+		return nil, nil
+	}
+	db := d.DB.WithContext(ctx)
+	var grants []KnowledgeBaseGrant
+	err := db.Where("knowledge_base_id IN ?", kbIDs).
+		Where(subjectCondition(db, userID, groupIDs)).
+		Find(&grants).Error
+	return grants, err
+}
+
+// ListGrants 某个知识库上直接设置的授权 (不含从父节点继承的)
+func (d *Data) ListGrants(ctx context.Context, kbIDs []uint) ([]KnowledgeBaseGrant, error) {
+	var grants []KnowledgeBaseGrant
+	err := d.DB.WithContext(ctx).
+		Where("knowledge_base_id IN ?", kbIDs).
+		Order("knowledge_base_id, subject_type, subject_id").
+		Find(&grants).Error
+	return grants, err
+}
+
+// SaveGrant 授权，同一主体已有授权时改为新角色
+func (d *Data) SaveGrant(ctx context.Context, g *KnowledgeBaseGrant) error {
+	return d.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "knowledge_base_id"}, {Name: "subject_type"}, {Name: "subject_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "granted_by", "updated_at"}),
+	}).Create(g).Error
+}
+
+// RevokeGrant 撤销授权
+func (d *Data) RevokeGrant(ctx context.Context, kbID uint, subjectType string, subjectID uint) error {
+	res := d.DB.WithContext(ctx).
+		Where("knowledge_base_id = ? AND subject_type = ? AND subject_id = ?", kbID, subjectType, subjectID).
+		Delete(&KnowledgeBaseGrant{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrGrantNotFound
+	}
+	return nil
+}
+
+// GetGroup 根据 ID 查找用户组
+func (d *Data) GetGroup(ctx context.Context, id uint) (*Group, error) {
+	var g Group
+	err := d.DB.WithContext(ctx).First(&g, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrGroupNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// CreateGroup 创建用户组
+func (d *Data) CreateGroup(ctx context.Context, g *Group) error {
+	return d.DB.WithContext(ctx).Create(g).Error
+}
+
+// ListGroups 租户内的全部用户组
+func (d *Data) ListGroups(ctx context.Context, orgID uint) ([]Group, error) {
+	var groups []Group
+	err := d.DB.WithContext(ctx).Where("organization_id = ?", orgID).Order("name").Find(&groups).Error
+	return groups, err
+}
+
+// GroupMemberIDs 用户组成员
+func (d *Data) GroupMemberIDs(ctx context.Context, groupID uint) ([]uint, error) {
+	var ids []uint
+	err := d.DB.WithContext(ctx).Model(&GroupMember{}).
+		Where("group_id = ?", groupID).
+		Order("user_id").
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// AddGroupMember 加入用户组，重复加入不报错
+func (d *Data) AddGroupMember(ctx context.Context, groupID, userID uint) error {
+	return d.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&GroupMember{GroupID: groupID, UserID: userID}).Error
+}
+
+// RemoveGroupMember 移出用户组
+func (d *Data) RemoveGroupMember(ctx context.Context, groupID, userID uint) error {
+	return d.DB.WithContext(ctx).
+		Where("group_id = ? AND user_id = ?", groupID, userID).
+		Delete(&GroupMember{}).Error
+}
+
+// DeleteGroup 删除用户组及其成员关系、授权
+func (d *Data) DeleteGroup(ctx context.Context, id uint) error {
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", id).Delete(&GroupMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("subject_type = ? AND subject_id = ?", SubjectGroup, id).Delete(&KnowledgeBaseGrant{}).Error; err != nil {
+			return err
+		}
+		res := tx.Delete(&Group{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrGroupNotFound
+		}
+		return nil
+	})
+}
@@ -0,0 +1,123 @@
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"gorm.io/gorm/clause"
+)
+
+// ---------------------------------------------------------
+// 切片表 (chunks)
+// 切片文本以 Postgres 为准: 入库时与向量同批写入，关键词索引、重建索引、
+// 切片浏览都从这里读，只有需要新向量时才调用 AI Service
+// ---------------------------------------------------------
+
+// chunkScrollLimit 翻页读取切片时每页的行数
+const chunkScrollLimit = 256
+
+// chunkFrom 读取切片用的 FROM 子句，列名与 pgvector 表一致，可以共用 pgChunkColumns / pgConditions
+const chunkFrom = `chunks c
+	JOIN documents d ON d.id = c.document_id`
+
+// NewChunkRecord 由写入向量存储的切片生成 chunks 表的记录
+func NewChunkRecord(c LexicalChunk, documentVersion int, model string) Chunk {
+	sum := sha256.Sum256([]byte(c.Content))
+	return Chunk{
+		ID:              c.ID,
+		DocumentID:      c.DocumentID,
+		ChunkIndex:      c.ChunkIndex,
+		PageNumber:      c.Page,
+		FileName:        c.FileName,
+		Content:         c.Content,
+		TokenCount:      len(tokenize(c.Content)),
+		ContentHash:     hex.EncodeToString(sum[:]),
+		DocumentVersion: documentVersion,
+		EmbeddingModel:  model,
+		IngestRun:       c.IngestRun,
+		Excluded:        c.Excluded,
+	}
+}
+
+// SaveChunks 写入 (或覆盖) 切片记录，ID 与向量 Point ID 相同，重试时只是覆盖
+func (d *Data) SaveChunks(ctx context.Context, records []Chunk) error {
+	if len(records) == 0 {
+		return nil
+	}
+	return d.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "id"}}, UpdateAll: true}).
+		Create(&records).Error
+}
+
+// ScrollChunks 按主键翻页读取符合 filter 的切片，每页调用一次 fn
+func (d *Data) ScrollChunks(ctx context.Context, filter *SearchFilter, fn func(chunks []LexicalChunk) error) error {
+	where, args := pgConditions(filter)
+	after := ""
+
+	for {
+		query := `SELECT ` + pgChunkColumns + ` FROM ` + chunkFrom + `
+			WHERE ` + strings.Join(where, " AND ") + ` AND c.id > ?
+			ORDER BY c.id
+			LIMIT ?`
+		var rows []pgChunkRow
+		if err := d.DB.WithContext(ctx).Raw(query, append(args, after, chunkScrollLimit)...).Scan(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		chunks := make([]LexicalChunk, 0, len(rows))
+		for _, r := range rows {
+			chunks = append(chunks, r.chunk())
+		}
+		if err := fn(chunks); err != nil {
+			return err
+		}
+
+		if len(rows) < chunkScrollLimit {
+			return nil
+		}
+		after = rows[len(rows)-1].ID
+	}
+}
+
+// DocumentChunks 按切片顺序读取某个文档的前 limit 个切片，limit 为 0 时不限
+func (d *Data) DocumentChunks(ctx context.Context, documentID uint, limit uint32) ([]LexicalChunk, error) {
+	where, args := pgConditions(documentChunksFilter(documentID))
+	query := `SELECT ` + pgChunkColumns + ` FROM ` + chunkFrom + `
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY c.chunk_index`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	var rows []pgChunkRow
+	if err := d.DB.WithContext(ctx).Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	chunks := make([]LexicalChunk, 0, len(rows))
+	for _, r := range rows {
+		chunks = append(chunks, r.chunk())
+	}
+	return chunks, nil
+}
+
+// deleteChunkRecords 删除某个文档的切片记录，run 不为空时保留该批次
+func (d *Data) deleteChunkRecords(ctx context.Context, documentID uint, keepRun string) error {
+	q := d.DB.WithContext(ctx).Where("document_id = ?", documentID)
+	if keepRun != "" {
+		q = q.Where("ingest_run <> ?", keepRun)
+	}
+	return q.Delete(&Chunk{}).Error
+}
+
+// countChunkRecords 切片表中的记录数
+func (d *Data) countChunkRecords(ctx context.Context) (int64, error) {
+	var n int64
+	err := d.DB.WithContext(ctx).Model(&Chunk{}).Count(&n).Error
+	return n, err
+}
@@ -0,0 +1,178 @@
+package data
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ---------------------------------------------------------
+// 会话 (Conversation / Message) 相关操作
+// ---------------------------------------------------------
+
+// MessageSource 一条回答的检索来源
+type MessageSource struct {
+	DocumentID uint    `json:"document_id"`
+	FileName   string  `json:"file_name"`
+	Title      string  `json:"title"`
+	Page       int32   `json:"page_number"`
+	Score      float32 `json:"score"`
+	Snippet    string  `json:"snippet"`
+	Cited      bool    `json:"cited"` // 回答正文中是否引用了该来源
+}
+
+// MessageSources 以 JSON 文本存入数据库
+type MessageSources []MessageSource
+
+func (m MessageSources) Value() (driver.Value, error) {
+	if m == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(m)
+	return string(b), err
+}
+
+func (m *MessageSources) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	case []byte:
+		return json.Unmarshal(v, m)
+	default:
+		return fmt.Errorf("unsupported type for MessageSources: %T", value)
+	}
+}
+
+// ErrConversationNotFound 会话不存在或不属于当前用户
+var ErrConversationNotFound = errors.New("conversation not found")
+
+// GetOrCreateConversation 按 SessionID 查找会话，不存在则新建
+// 并发的首条消息用 ON CONFLICT DO NOTHING 兜底，插入落空时重新读出已有的会话；
+// 会话属于其他用户时返回 ErrConversationNotFound
+func (d *Data) GetOrCreateConversation(ctx context.Context, sessionID string, userID uint, title string) (*Conversation, error) {
+	conv := Conversation{SessionID: sessionID, UserID: userID, Title: title}
+	res := d.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "session_id"}}, DoNothing: true}).
+		Create(&conv)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return &conv, nil
+	}
+
+	conv = Conversation{}
+	// Unscoped: 已删除的会话 SessionID 仍占用唯一索引，不能再复用
+	if err := d.DB.WithContext(ctx).Unscoped().Where("session_id = ?", sessionID).First(&conv).Error; err != nil {
+		return nil, err
+	}
+	if conv.UserID != userID || conv.DeletedAt.Valid {
+		return nil, ErrConversationNotFound
+	}
+	return &conv, nil
+}
+
+// RecentMessages 返回会话最近的 limit 条消息，按时间正序
+func (d *Data) RecentMessages(ctx context.Context, conversationID uint, limit int) ([]Message, error) {
+	var msgs []Message
+	if err := d.DB.WithContext(ctx).
+		Where("conversation_id = ?", conversationID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&msgs).Error; err != nil {
+		return nil, err
+	}
+	// 倒序查出最近的，再翻转回正序
+	for i, j := 0, len(msgs)-1; i < j; i, j = i+1, j-1 {
+		msgs[i], msgs[j] = msgs[j], msgs[i]
+	}
+	return msgs, nil
+}
+
+// SaveTurn 在一个事务里写入一轮问答，并刷新会话的更新时间
+func (d *Data) SaveTurn(ctx context.Context, conversationID uint, question, answer *Message) error {
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		question.ConversationID = conversationID
+		answer.ConversationID = conversationID
+		if err := tx.Create(question).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(answer).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Conversation{}).Where("id = ?", conversationID).
+			Update("updated_at", gorm.Expr("NOW()")).Error
+	})
+}
+
+// ListConversations 分页列出用户的会话，按最近更新排序
+func (d *Data) ListConversations(ctx context.Context, userID uint, offset, limit int) ([]Conversation, int64, error) {
+	var (
+		convs []Conversation
+		total int64
+	)
+	q := d.DB.WithContext(ctx).Model(&Conversation{}).Where("user_id = ?", userID)
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := q.Order("updated_at DESC").Offset(offset).Limit(limit).Find(&convs).Error; err != nil {
+		return nil, 0, err
+	}
+	return convs, total, nil
+}
+
+// GetConversation 查询用户的会话及完整消息记录
+func (d *Data) GetConversation(ctx context.Context, sessionID string, userID uint) (*Conversation, error) {
+	var conv Conversation
+	err := d.DB.WithContext(ctx).
+		Where("session_id = ? AND user_id = ?", sessionID, userID).
+		Preload("Messages", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		First(&conv).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &conv, nil
+}
+
+// RenameConversation 修改会话标题
+func (d *Data) RenameConversation(ctx context.Context, sessionID string, userID uint, title string) error {
+	res := d.DB.WithContext(ctx).Model(&Conversation{}).
+		Where("session_id = ? AND user_id = ?", sessionID, userID).
+		Update("title", title)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrConversationNotFound
+	}
+	return nil
+}
+
+// DeleteConversation 软删除会话及其消息
+func (d *Data) DeleteConversation(ctx context.Context, sessionID string, userID uint) error {
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var conv Conversation
+		err := tx.Where("session_id = ? AND user_id = ?", sessionID, userID).First(&conv).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrConversationNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("conversation_id = ?", conv.ID).Delete(&Message{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&conv).Error
+	})
+}
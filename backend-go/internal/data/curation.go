@@ -0,0 +1,371 @@
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ---------------------------------------------------------
+// 切片人工修订 (改文本、排除、合并、拆分)
+// 切片表在事务里修改并写审计记录，之后由调用方重新向量化 (见 SyncChunkVectors)。
+// 文档重新解析时会整体替换切片，人工修改随之失效
+// ---------------------------------------------------------
+
+// 审计动作
+const (
+	ChunkActionEdit    = "edit"
+	ChunkActionExclude = "exclude"
+	ChunkActionInclude = "include"
+	ChunkActionMerge   = "merge"
+	ChunkActionSplit   = "split"
+)
+
+var (
+	ErrChunkNotFound = errors.New("chunk not found")
+	// ErrChunkConflict 请求与切片当前状态冲突 (不相邻、拆分位置无效等)
+	ErrChunkConflict = errors.New("chunk conflict")
+)
+
+// ChunkExclusion 排除 (或恢复) 的范围: 指定切片，或页码闭区间，二者取并集
+type ChunkExclusion struct {
+	ChunkIDs []string
+	PageFrom int32
+	PageTo   int32
+	Excluded bool
+}
+
+// ListChunkRecords 按切片顺序列出某个文档的全部切片
+func (d *Data) ListChunkRecords(ctx context.Context, documentID uint) ([]Chunk, error) {
+	var chunks []Chunk
+	err := d.DB.WithContext(ctx).
+		Where("document_id = ?", documentID).
+		Order("chunk_index").
+		Find(&chunks).Error
+	return chunks, err
+}
+
+// ListChunkAudits 某个文档的修订记录，最新的在前
+func (d *Data) ListChunkAudits(ctx context.Context, documentID uint, limit int) ([]ChunkAudit, error) {
+	var audits []ChunkAudit
+	err := d.DB.WithContext(ctx).
+		Where("document_id = ?", documentID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&audits).Error
+	return audits, err
+}
+
+// lockChunk 在事务内锁住一个切片
+func lockChunk(tx *gorm.DB, documentID uint, chunkID string) (*Chunk, error) {
+	var c Chunk
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND document_id = ?", chunkID, documentID).
+		First(&c).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrChunkNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// setContent 修改切片文本，同步更新哈希与 token 数
+func (c *Chunk) setContent(content string) {
+	sum := sha256.Sum256([]byte(content))
+	c.Content = content
+	c.ContentHash = hex.EncodeToString(sum[:])
+	c.TokenCount = len(tokenize(content))
+}
+
+// UpdateChunkContent 修改切片文本
+func (d *Data) UpdateChunkContent(ctx context.Context, userID, documentID uint, chunkID, content string) (*Chunk, error) {
+	var out *Chunk
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		c, err := lockChunk(tx, documentID, chunkID)
+		if err != nil {
+			return err
+		}
+		before := c.Content
+		c.setContent(content)
+		if err := tx.Save(c).Error; err != nil {
+			return err
+		}
+		out = c
+		return tx.Create(&ChunkAudit{
+			DocumentID: documentID, UserID: userID, Action: ChunkActionEdit,
+			ChunkIDs: chunkID, Before: before, After: content,
+		}).Error
+	})
+	return out, err
+}
+
+// SetChunksExcluded 排除或恢复切片，返回实际命中的切片 ID
+func (d *Data) SetChunksExcluded(ctx context.Context, userID, documentID uint, ex ChunkExclusion) ([]string, error) {
+	var ids []string
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		q := tx.Model(&Chunk{}).Where("document_id = ?", documentID)
+		scope := tx.Session(&gorm.Session{NewDB: true})
+		switch {
+		case len(ex.ChunkIDs) > 0 && (ex.PageFrom > 0 || ex.PageTo > 0):
+			q = q.Where(scope.Where("id IN ?", ex.ChunkIDs).Or(pageRange(scope, ex.PageFrom, ex.PageTo)))
+		case len(ex.ChunkIDs) > 0:
+			q = q.Where("id IN ?", ex.ChunkIDs)
+		default:
+			q = q.Where(pageRange(scope, ex.PageFrom, ex.PageTo))
+		}
+		if err := q.Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return ErrChunkNotFound
+		}
+		if err := tx.Model(&Chunk{}).Where("id IN ?", ids).Update("excluded", ex.Excluded).Error; err != nil {
+			return err
+		}
+
+		action, detail := ChunkActionInclude, ""
+		if ex.Excluded {
+			action = ChunkActionExclude
+		}
+		if ex.PageFrom > 0 || ex.PageTo > 0 {
+			detail = fmt.Sprintf("pages %d-%d", ex.PageFrom, ex.PageTo)
+		}
+		return tx.Create(&ChunkAudit{
+			DocumentID: documentID, UserID: userID, Action: action,
+			ChunkIDs: strings.Join(ids, ","), Detail: detail,
+		}).Error
+	})
+	return ids, err
+}
+
+// pageRange 页码闭区间条件，0 表示不限
+func pageRange(db *gorm.DB, from, to int32) *gorm.DB {
+	q := db
+	if from > 0 {
+		q = q.Where("page_number >= ?", from)
+	}
+	if to > 0 {
+		q = q.Where("page_number <= ?", to)
+	}
+	return q
+}
+
+// MergeChunks 将相邻的两个切片合并为一个: 保留 first 的 ID 与页码，second 被删除，后续切片序号前移
+func (d *Data) MergeChunks(ctx context.Context, userID, documentID uint, firstID, secondID string) (*Chunk, error) {
+	var out *Chunk
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		first, err := lockChunk(tx, documentID, firstID)
+		if err != nil {
+			return err
+		}
+		second, err := lockChunk(tx, documentID, secondID)
+		if err != nil {
+			return err
+		}
+		// 允许调换顺序传入
+		if second.ChunkIndex < first.ChunkIndex {
+			first, second = second, first
+		}
+		if second.ChunkIndex != first.ChunkIndex+1 {
+			return fmt.Errorf("%w: 切片 %d 与 %d 不相邻", ErrChunkConflict, first.ChunkIndex, second.ChunkIndex)
+		}
+
+		before := first.Content + "\n---\n" + second.Content
+		first.setContent(first.Content + "\n" + second.Content)
+		// 两者都被排除时合并结果才保持排除
+		first.Excluded = first.Excluded && second.Excluded
+		if err := tx.Save(first).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(second).Error; err != nil {
+			return err
+		}
+		if err := shiftChunkIndexes(tx, documentID, second.ChunkIndex, -1); err != nil {
+			return err
+		}
+		if err := adjustChunkCount(tx, documentID, -1); err != nil {
+			return err
+		}
+
+		out = first
+		return tx.Create(&ChunkAudit{
+			DocumentID: documentID, UserID: userID, Action: ChunkActionMerge,
+			ChunkIDs: first.ID + "," + second.ID, Before: before, After: first.Content,
+		}).Error
+	})
+	return out, err
+}
+
+// SplitChunk 在 offset (按字符计) 处把切片一分为二: 前半段保留原 ID，后半段作为新切片插在其后
+func (d *Data) SplitChunk(ctx context.Context, userID, documentID uint, chunkID string, offset int) ([]Chunk, error) {
+	var out []Chunk
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		c, err := lockChunk(tx, documentID, chunkID)
+		if err != nil {
+			return err
+		}
+		runes := []rune(c.Content)
+		var head, tail string
+		if offset > 0 && offset < len(runes) {
+			head, tail = strings.TrimSpace(string(runes[:offset])), strings.TrimSpace(string(runes[offset:]))
+		}
+		if head == "" || tail == "" {
+			return fmt.Errorf("%w: 拆分位置 %d 无效 (切片共 %d 个字符)", ErrChunkConflict, offset, len(runes))
+		}
+
+		before := c.Content
+		if err := shiftChunkIndexes(tx, documentID, c.ChunkIndex+1, 1); err != nil {
+			return err
+		}
+		c.setContent(head)
+		if err := tx.Save(c).Error; err != nil {
+			return err
+		}
+		// 人工拆出的切片没有确定的来源序号，用随机 ID
+		next := *c
+		next.ID = uuid.NewString()
+		next.ChunkIndex = c.ChunkIndex + 1
+		next.CreatedAt, next.UpdatedAt = c.UpdatedAt, c.UpdatedAt
+		next.setContent(tail)
+		if err := tx.Create(&next).Error; err != nil {
+			return err
+		}
+		if err := adjustChunkCount(tx, documentID, 1); err != nil {
+			return err
+		}
+
+		out = []Chunk{*c, next}
+		return tx.Create(&ChunkAudit{
+			DocumentID: documentID, UserID: userID, Action: ChunkActionSplit,
+			ChunkIDs: c.ID + "," + next.ID, Before: before, After: head + "\n---\n" + tail,
+			Detail: fmt.Sprintf("offset %d", offset),
+		}).Error
+	})
+	return out, err
+}
+
+// shiftChunkIndexes 序号 >= from 的切片整体移动 delta
+// 顺序以切片表为准，向量 Payload 中的 chunk_index 不跟着改
+func shiftChunkIndexes(tx *gorm.DB, documentID uint, from int32, delta int) error {
+	return tx.Model(&Chunk{}).
+		Where("document_id = ? AND chunk_index >= ?", documentID, from).
+		Update("chunk_index", gorm.Expr("chunk_index + ?", delta)).Error
+}
+
+func adjustChunkCount(tx *gorm.DB, documentID uint, delta int) error {
+	return tx.Model(&Document{}).Where("id = ?", documentID).
+		Update("chunk_count", gorm.Expr("chunk_count + ?", delta)).Error
+}
+
+// ChunkEmbedder 用指定模型向量化一段文本
+type ChunkEmbedder func(ctx context.Context, model, text string) ([]float32, error)
+
+// SyncChunkVectors 人工修订后同步向量存储与关键词索引:
+// changed 中的切片按切片表最新内容在每个模型的集合里重新向量化，removed 中的切片从所有集合删除
+func (d *Data) SyncChunkVectors(ctx context.Context, changed, removed []string, embed ChunkEmbedder) error {
+	if len(removed) > 0 {
+		filter := &SearchFilter{ChunkIDs: removed, IncludeSuperseded: true, IncludeExcluded: true}
+		err := d.eachVectorStore(func(store VectorStore) error {
+			return store.Delete(ctx, filter)
+		})
+		if err != nil {
+			return err
+		}
+		d.Lexical.Remove(removed...)
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	var chunks []LexicalChunk
+	err := d.ScrollChunks(ctx, &SearchFilter{ChunkIDs: changed, IncludeSuperseded: true, IncludeExcluded: true}, func(batch []LexicalChunk) error {
+		chunks = append(chunks, batch...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 重建中 / 可回滚的集合也要更新，否则切换后会看到旧文本
+	models := make([]string, 0, len(d.vectorStores))
+	for model := range d.vectorStores {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	for _, model := range models {
+		points := make([]VectorPoint, 0, len(chunks))
+		for _, c := range chunks {
+			vector, err := embed(ctx, model, c.Content)
+			if err != nil {
+				return fmt.Errorf("模型 %s: %w", model, err)
+			}
+			points = append(points, VectorPoint{Vector: vector, Chunk: c})
+		}
+		if err := d.vectorStores[model].Upsert(ctx, points); err != nil {
+			return fmt.Errorf("模型 %s: %w", model, err)
+		}
+	}
+	d.IndexChunks(chunks...)
+	return nil
+}
+
+// ResyncDocumentChunks 按切片表重新同步某个文档在所有集合中的向量与关键词索引，返回同步和删除的切片数
+// 修订在切片表提交后、向量同步前失败时使用: 表里的切片全部重新向量化，
+// 集合里有而表里没有的切片 (合并时删掉的) 从所有集合删除，重复执行的结果相同
+func (d *Data) ResyncDocumentChunks(ctx context.Context, documentID uint, embed ChunkEmbedder) (int, int, error) {
+	records, err := d.ListChunkRecords(ctx, documentID)
+	if err != nil {
+		return 0, 0, err
+	}
+	ids := make([]string, 0, len(records))
+	known := make(map[string]bool, len(records))
+	for _, r := range records {
+		ids = append(ids, r.ID)
+		known[r.ID] = true
+	}
+
+	var removed []string
+	filter := &SearchFilter{DocumentIDs: []uint{documentID}, IncludeSuperseded: true, IncludeExcluded: true}
+	err = d.eachVectorStore(func(store VectorStore) error {
+		return store.Scroll(ctx, filter, func(chunks []LexicalChunk) error {
+			for _, c := range chunks {
+				if !known[c.ID] {
+					known[c.ID] = true
+					removed = append(removed, c.ID)
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := d.SyncChunkVectors(ctx, ids, removed, embed); err != nil {
+		return 0, 0, err
+	}
+	return len(ids), len(removed), nil
+}
+
+// SyncChunkExclusion 排除标记同步到所有集合与关键词索引
+func (d *Data) SyncChunkExclusion(ctx context.Context, ids []string, excluded bool) error {
+	filter := &SearchFilter{ChunkIDs: ids, IncludeSuperseded: true, IncludeExcluded: true}
+	err := d.eachVectorStore(func(store VectorStore) error {
+		return store.SetPayload(ctx, filter, PayloadPatch{Excluded: &excluded})
+	})
+	if err != nil {
+		return err
+	}
+	d.Lexical.SetExcluded(ids, excluded)
+	return nil
+}
@@ -11,23 +11,32 @@ import (
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 	"github.com/redis/go-redis/v9"
-
-	// Qdrant 官方 Go SDK
-	"github.com/qdrant/go-client/qdrant"
 )
 
 // Data 结构体持有所有数据库句柄
 type Data struct {
-	Minio  *minio.Client
-	Redis  *redis.Client
-	Qdrant *qdrant.Client
-	DB     *gorm.DB
+	Minio *minio.Client
+	Redis *redis.Client
+	DB    *gorm.DB
+
+	// 向量存储 (见 vector_store.go)，每个模型一个集合
+	// 当前用于入库和检索的模型由检索别名决定，见 LiveVectors
+	vectorStores map[string]VectorStore // 模型名 -> 集合
+	vectorAlias  string                 // 后端支持别名时 (Qdrant) 的别名
+	live         liveModelCache
+
+	// 关键词索引 (BM25)，与向量检索一起做混合检索
+	Lexical *LexicalIndex
 }
 
 type SearchResult struct {
-	Content  string
-	FileName string
-	Page     int32
+	ID         string // 向量 Point ID，用于多路结果融合去重
+	DocumentID uint
+	Content    string
+	FileName   string
+	Title      string
+	Page       int32
+	Score      float32
 }
 
 func NewData(cfg *conf.Config) (*Data, func(), error) {
@@ -62,31 +71,41 @@ func NewData(cfg *conf.Config) (*Data, func(), error) {
 		log.Printf("🎉 MinIO Bucket '%s' 创建成功", bucketName)
 	}
 
-	// 3. 初始化 Qdrant
-	qdrantClient, err := qdrant.NewClient(&qdrant.Config{
-		Host: "localhost",
-		Port: 6334,
-	})
-	if err != nil {
-		log.Fatalf("无法初始化 Qdrant 客户端: %v", err)
-	}
-
-	// ⚠️ 移除了 Health() 调用，直接通过创建 Collection 来验证连接
-	// 这样兼容性最好，不会因为 SDK 版本变动报错
-	createCollection(qdrantClient)
-
+	// 3. 初始化 Postgres (含表结构迁移)
 	pgDB, err := NewPostgresDB(cfg)
 	if err != nil {
 		log.Fatalf("无法初始化 Postgres 客户端: %v", err)
 	}
 
+	// 4. 初始化向量存储 (默认 Qdrant；pgvector 建表依赖 documents 表，放在迁移之后)
+	// 注册表里的每个模型各一个集合，维度与已有集合不一致时直接退出
+	vectorStores := make(map[string]VectorStore, len(cfg.Embedding.Models))
+	for _, model := range cfg.Embedding.Models {
+		store, err := NewVectorStore(context.Background(), cfg.Data, model, pgDB)
+		if err != nil {
+			log.Fatalf("无法初始化模型 %s 的向量存储: %v", model.Name, err)
+		}
+		vectorStores[model.Name] = store
+	}
+
 	d := &Data{
-		Minio:  minioClient,
-		Redis:  rdb,
-		Qdrant: qdrantClient,
-		DB:     pgDB,
+		Minio: minioClient,
+		Redis: rdb,
+		DB:    pgDB,
+
+		vectorStores: vectorStores,
+		vectorAlias:  cfg.Data.QdrantCollection + "_live",
+
+		Lexical: NewLexicalIndex(),
 	}
 
+	if err := d.initVectorAlias(context.Background(), cfg.Embedding.Active); err != nil {
+		log.Fatalf("初始化检索别名失败: %v", err)
+	}
+
+	// 从切片表重建关键词索引
+	d.loadLexicalIndex(context.Background())
+
 	// 构造清理函数
 	cleanup := func() {
 		log.Println("正在关闭数据层资源...")
@@ -96,90 +115,96 @@ func NewData(cfg *conf.Config) (*Data, func(), error) {
 			sqlDB.Close()
 		}
 
-		// 如果有 Redis 或 Qdrant 的 Close 方法，也在这里调用
+		// 如果有 Redis 或向量存储的 Close 方法，也在这里调用
 		d.Redis.Close()
-		d.Qdrant.Close()
+		for _, store := range d.vectorStores {
+			store.Close()
+		}
 	}
 
 	return d, cleanup, nil
 }
 
-// 辅助函数：确保 Collection 存在
-func createCollection(client *qdrant.Client) {
-	ctx := context.Background()
+// VectorsFor 返回某个模型的向量集合，模型未注册时返回 false
+func (d *Data) VectorsFor(model string) (VectorStore, bool) {
+	store, ok := d.vectorStores[model]
+	return store, ok
+}
 
-	// 尝试列出集合，这本身就是一种连接测试
-	collections, err := client.ListCollections(ctx)
-	if err != nil {
-		// 如果这里报错，说明 Qdrant 没连上
-		log.Printf("⚠️ 无法连接 Qdrant (ListCollections 失败): %v", err)
-		return
+// SearchSimilar 核心检索功能，在 model 的集合中检索 (vector 必须由同一模型生成)
+// filter 为 nil 时检索全部切片
+func (d *Data) SearchSimilar(ctx context.Context, model string, vector []float32, topK uint64, filter *SearchFilter) ([]SearchResult, error) {
+	store, ok := d.VectorsFor(model)
+	if !ok {
+		return nil, fmt.Errorf("模型 %s 未注册", model)
 	}
+	return store.Search(ctx, vector, topK, filter)
+}
+
+// documentChunksFilter 某个文档的全部切片 (含已被取代的旧版本、被人工排除的切片)
+func documentChunksFilter(documentID uint) *SearchFilter {
+	return &SearchFilter{DocumentIDs: []uint{documentID}, IncludeSuperseded: true, IncludeExcluded: true}
+}
 
-	exists := false
-	for _, c := range collections {
-		if c == "chimera_docs" {
-			exists = true
-			break
+// eachVectorStore 对所有模型的集合执行同一操作
+// 删除、版本标记要同时作用于重建中/可回滚的集合，否则切换后会看到已删除的文档
+func (d *Data) eachVectorStore(fn func(store VectorStore) error) error {
+	for model, store := range d.vectorStores {
+		if err := fn(store); err != nil {
+			return fmt.Errorf("模型 %s: %w", model, err)
 		}
 	}
+	return nil
+}
 
-	if !exists {
-		// 创建向量集合
-		err := client.CreateCollection(ctx, &qdrant.CreateCollection{
-			CollectionName: "chimera_docs",
-			VectorsConfig: qdrant.NewVectorsConfig(&qdrant.VectorParams{
-				Size:     384, // ⚠️ 配合 Mock 数据，未来需改为 768
-				Distance: qdrant.Distance_Cosine,
-			}),
-		})
-
-		if err != nil {
-			log.Printf("创建 Collection 失败: %v", err)
-		} else {
-			log.Println("🎉 Qdrant Collection 'chimera_docs' 创建成功")
-		}
-	} else {
-		log.Println("🎉 Qdrant 连接成功 (Collection 'chimera_docs' 已存在)")
+// DeleteDocumentChunks 删除某个文档的全部切片 (向量 + 切片表 + 关键词索引)
+// 按 document_id 过滤删除，重复执行是幂等的
+func (d *Data) DeleteDocumentChunks(ctx context.Context, documentID uint) error {
+	err := d.eachVectorStore(func(store VectorStore) error {
+		return store.Delete(ctx, documentChunksFilter(documentID))
+	})
+	if err != nil {
+		return err
+	}
+	if err := d.deleteChunkRecords(ctx, documentID, ""); err != nil {
+		return err
 	}
+	d.Lexical.RemoveDocument(documentID)
+	return nil
 }
 
-// SearchSimilar 核心检索功能 (使用最新的 Query API)
-func (d *Data) SearchSimilar(ctx context.Context, vector []float32, topK uint64) ([]SearchResult, error) {
-	// 将 vector 转为 SDK 需要的格式
-	queryVal := make([]float32, len(vector))
-	copy(queryVal, vector)
-
-	// 使用 Query 接口 (这是 Qdrant 的新标准)
-	points, err := d.Qdrant.Query(ctx, &qdrant.QueryPoints{
-		CollectionName: "chimera_docs",
-		Query:          qdrant.NewQuery(queryVal...), // 使用 NewQuery 包装向量
-		Limit:          &topK,
-		WithPayload: &qdrant.WithPayloadSelector{
-			SelectorOptions: &qdrant.WithPayloadSelector_Enable{
-				Enable: true,
-			},
-		},
+// DeleteStaleChunks 删除某个文档中不属于 run 批次的切片 (向量 + 切片表 + 关键词索引)
+func (d *Data) DeleteStaleChunks(ctx context.Context, documentID uint, run string) error {
+	filter := documentChunksFilter(documentID)
+	filter.ExceptIngestRun = run
+	err := d.eachVectorStore(func(store VectorStore) error {
+		return store.Delete(ctx, filter)
 	})
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if err := d.deleteChunkRecords(ctx, documentID, run); err != nil {
+		return err
 	}
+	d.Lexical.RemoveDocumentExcept(documentID, run)
+	return nil
+}
 
-	var results []SearchResult
-	for _, point := range points {
-		res := SearchResult{}
-		if val, ok := point.Payload["content"]; ok {
-			res.Content = val.GetStringValue()
-		}
-		if val, ok := point.Payload["filename"]; ok {
-			res.FileName = val.GetStringValue()
-		}
-		if val, ok := point.Payload["page_number"]; ok {
-			res.Page = int32(val.GetIntegerValue())
-		}
-		results = append(results, res)
+// UpsertChunks 将切片记录写入 Postgres、向量写入 store，成功后同步写入关键词索引
+// records 与 points 一一对应；先写切片表，向量写入失败重试时记录只是被覆盖
+func (d *Data) UpsertChunks(ctx context.Context, store VectorStore, records []Chunk, points []VectorPoint) error {
+	if err := d.SaveChunks(ctx, records); err != nil {
+		return err
+	}
+	if err := store.Upsert(ctx, points); err != nil {
+		return err
+	}
+	chunks := make([]LexicalChunk, 0, len(points))
+	for _, p := range points {
+		chunks = append(chunks, p.Chunk)
 	}
-	return results, nil
+	d.IndexChunks(chunks...)
+	return nil
 }
 
 // NewPostgresDB 初始化 PG 连接
@@ -201,15 +226,36 @@ func NewPostgresDB(cfg *conf.Config) (*gorm.DB, error) {
 		return nil, err
 	}
 
+	// 租户列是后加的，迁移前记下是否需要回填
+	needTenantBackfill := db.Migrator().HasTable(&Document{}) && !db.Migrator().HasColumn(&Document{}, "OrganizationID")
+
 	// 🔥 核心：自动迁移模式，自动创建表结构
 	if err := db.AutoMigrate(
 		&User{},
 		&Organization{},
+		&OrganizationInvite{},
 		&KnowledgeBase{},
+		&KnowledgeBaseGrant{},
+		&Group{},
+		&GroupMember{},
 		&Document{},
+		&Conversation{},
+		&Message{},
+		&Chunk{},
+		&ChunkAudit{},
+		&VectorAlias{},
+		&ReindexTask{},
 	); err != nil {
 		return nil, fmt.Errorf("database migration failed: %v", err)
 	}
+	if err := ensureDocumentIndexes(db); err != nil {
+		return nil, fmt.Errorf("database migration failed: %v", err)
+	}
+	if needTenantBackfill {
+		if err := backfillTenants(db); err != nil {
+			return nil, fmt.Errorf("database migration failed: %v", err)
+		}
+	}
 
 	log.Println("✅ PostgreSQL connected & Schema migrated!")
 	return db, nil
@@ -0,0 +1,218 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ---------------------------------------------------------
+// 文档状态机 (Document Status)
+// ---------------------------------------------------------
+
+// 文档状态
+const (
+	DocStatusPending   = "pending"   // 已上传，排队中 (或等待重试)
+	DocStatusParsing   = "parsing"   // 解析 + 切片 + 向量化
+	DocStatusEmbedding = "embedding" // 写入向量库
+	DocStatusSuccess   = "success"
+	DocStatusFailed    = "failed"
+)
+
+// docTransitions 允许的状态迁移
+// 任务重试、Worker 崩溃后被接管时，会从中间状态重新进入 parsing
+var docTransitions = map[string][]string{
+	DocStatusPending:   {DocStatusParsing, DocStatusFailed},
+	DocStatusParsing:   {DocStatusParsing, DocStatusEmbedding, DocStatusPending, DocStatusFailed},
+	DocStatusEmbedding: {DocStatusParsing, DocStatusEmbedding, DocStatusSuccess, DocStatusPending, DocStatusFailed},
+	DocStatusSuccess:   {DocStatusParsing},
+	DocStatusFailed:    {DocStatusParsing, DocStatusPending},
+}
+
+var (
+	// ErrDocumentNotFound 文档不存在 (或已删除)
+	ErrDocumentNotFound = errors.New("document not found")
+	// ErrInvalidTransition 非法的状态迁移
+	ErrInvalidTransition = errors.New("invalid document status transition")
+)
+
+// DocumentProgress 文档处理进度，通过 Redis Pub/Sub 推送给订阅者
+type DocumentProgress struct {
+	DocumentID uint      `json:"document_id"`
+	Status     string    `json:"status"`
+	Progress   int       `json:"progress"`
+	ChunkCount int       `json:"chunk_count"`
+	ErrorMsg   string    `json:"error_msg,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Done 是否已处于终态
+func (p *DocumentProgress) Done() bool {
+	return p.Status == DocStatusSuccess || p.Status == DocStatusFailed
+}
+
+// ProgressOf 从文档记录得到进度快照
+func ProgressOf(doc *Document) *DocumentProgress {
+	return &DocumentProgress{
+		DocumentID: doc.ID,
+		Status:     doc.Status,
+		Progress:   doc.Progress,
+		ChunkCount: doc.ChunkCount,
+		ErrorMsg:   doc.ErrorMsg,
+		UpdatedAt:  doc.UpdatedAt,
+	}
+}
+
+// DocumentUpdate 状态迁移时一并写入的字段，零值表示不修改 (ErrorMsg 除外，每次迁移都会覆盖)
+type DocumentUpdate struct {
+	Progress   int
+	ChunkCount int
+	ErrorMsg   string
+}
+
+// GetDocument 根据 ID 查找文档
+func (d *Data) GetDocument(ctx context.Context, id uint) (*Document, error) {
+	var doc Document
+	err := d.DB.WithContext(ctx).First(&doc, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDocumentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// GetDocumentUnscoped 查找文档，包括已软删除、尚未清理的
+func (d *Data) GetDocumentUnscoped(ctx context.Context, id uint) (*Document, error) {
+	var doc Document
+	err := d.DB.WithContext(ctx).Unscoped().First(&doc, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDocumentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// SoftDeleteDocument 软删除文档: 之后检索、列表、下载都看不到它，真正的清理由后台任务完成
+func (d *Data) SoftDeleteDocument(ctx context.Context, id uint) error {
+	return d.DB.WithContext(ctx).Delete(&Document{}, id).Error
+}
+
+// PurgeDocument 物理删除已软删除的文档记录；未软删除的记录不会被误删
+func (d *Data) PurgeDocument(ctx context.Context, id uint) error {
+	return d.DB.WithContext(ctx).Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Delete(&Document{}).Error
+}
+
+// TransitionDocument 在事务中把文档迁移到新状态 (行锁防止并发 Worker 互相覆盖)
+// 提交成功后广播进度
+func (d *Data) TransitionDocument(ctx context.Context, id uint, to string, upd DocumentUpdate) (*Document, error) {
+	var doc Document
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&doc, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrDocumentNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(docTransitions[doc.Status], to) {
+			return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, doc.Status, to)
+		}
+
+		updates := map[string]any{"status": to, "error_msg": upd.ErrorMsg, "progress": upd.Progress}
+		if upd.ChunkCount > 0 {
+			updates["chunk_count"] = upd.ChunkCount
+		}
+		return tx.Model(&doc).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.publishProgress(ctx, &doc)
+	return &doc, nil
+}
+
+// UpdateDocumentProgress 同一状态内更新百分比 (例如分批写入向量时)
+func (d *Data) UpdateDocumentProgress(ctx context.Context, id uint, progress int) error {
+	var doc Document
+	res := d.DB.WithContext(ctx).Model(&doc).Clauses(clause.Returning{}).
+		Where("id = ?", id).Update("progress", progress)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrDocumentNotFound
+	}
+
+	d.publishProgress(ctx, &doc)
+	return nil
+}
+
+// UpdateDocumentSummary 写入文档摘要
+func (d *Data) UpdateDocumentSummary(ctx context.Context, id uint, summary string) error {
+	return d.DB.WithContext(ctx).Model(&Document{}).Where("id = ?", id).Update("summary", summary).Error
+}
+
+// progressChannel 文档进度的 Pub/Sub 频道
+func progressChannel(id uint) string {
+	return fmt.Sprintf("doc:progress:%d", id)
+}
+
+// publishProgress 广播进度，失败只影响实时推送，不影响处理流程
+func (d *Data) publishProgress(ctx context.Context, doc *Document) {
+	payload, err := json.Marshal(ProgressOf(doc))
+	if err != nil {
+		return
+	}
+	_ = d.Redis.Publish(ctx, progressChannel(doc.ID), payload).Err()
+}
+
+// SubscribeDocumentProgress 订阅文档进度，ctx 结束后自动退订
+// 返回前订阅已经建立，调用者随后再查询当前状态即可不丢事件
+func (d *Data) SubscribeDocumentProgress(ctx context.Context, id uint) (<-chan *DocumentProgress, error) {
+	sub := d.Redis.Subscribe(ctx, progressChannel(id))
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, err
+	}
+
+	out := make(chan *DocumentProgress, 8)
+	go func() {
+		defer close(out)
+		defer sub.Close()
+
+		msgs := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				var p DocumentProgress
+				if err := json.Unmarshal([]byte(msg.Payload), &p); err != nil {
+					continue
+				}
+				select {
+				case out <- &p:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
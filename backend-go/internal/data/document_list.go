@@ -0,0 +1,206 @@
+package data
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ---------------------------------------------------------
+// 文档列表 (过滤、排序、游标分页)
+// 游标记录上一页最后一行的 (排序列, id)，翻页时用行比较取下一页，
+// 翻页期间有新上传也不会重复或漏行
+// ---------------------------------------------------------
+
+// 列表排序字段
+const (
+	DocumentSortCreatedAt = "created_at"
+	DocumentSortUpdatedAt = "updated_at"
+	DocumentSortTitle     = "title"
+	DocumentSortFileSize  = "file_size"
+)
+
+// ErrInvalidDocumentQuery 排序字段未知、游标无法解析或与本次请求的排序方式不一致
+var ErrInvalidDocumentQuery = errors.New("invalid document query")
+
+// DocumentQuery 文档列表的查询条件，零值表示不限制
+type DocumentQuery struct {
+	// Access 调用者的可见范围，必填
+	Access *AccessScope
+
+	KnowledgeBaseIDs []uint
+	Statuses         []string
+	FileTypes        []string
+	OwnerID          uint
+	CreatedFrom      *time.Time
+	CreatedTo        *time.Time
+	// Title 标题包含该关键词 (不区分大小写)
+	Title string
+	// LatestOnly 只列出每个文档的最新版本
+	LatestOnly bool
+
+	Sort   string // 见 DocumentSort*，默认 created_at
+	Desc   bool
+	Cursor string
+	Limit  int
+}
+
+// documentCursor 游标内容: 排序方式 + 上一页最后一行的排序值与 ID
+type documentCursor struct {
+	Sort string    `json:"s"`
+	Desc bool      `json:"d"`
+	Time time.Time `json:"t,omitempty"`
+	Str  string    `json:"v,omitempty"`
+	Int  int64     `json:"n,omitempty"`
+	ID   uint      `json:"id"`
+}
+
+func encodeDocumentCursor(sort string, desc bool, doc *Document) string {
+	c := documentCursor{Sort: sort, Desc: desc, ID: doc.ID}
+	switch sort {
+	case DocumentSortUpdatedAt:
+		c.Time = doc.UpdatedAt
+	case DocumentSortTitle:
+		c.Str = doc.Title
+	case DocumentSortFileSize:
+		c.Int = doc.FileSize
+	default:
+		c.Time = doc.CreatedAt
+	}
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeDocumentCursor(s string) (*documentCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 游标无效", ErrInvalidDocumentQuery)
+	}
+	var c documentCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == 0 {
+		return nil, fmt.Errorf("%w: 游标无效", ErrInvalidDocumentQuery)
+	}
+	return &c, nil
+}
+
+// value 游标中与排序列对应的值
+func (c *documentCursor) value() any {
+	switch c.Sort {
+	case DocumentSortTitle:
+		return c.Str
+	case DocumentSortFileSize:
+		return c.Int
+	default:
+		return c.Time
+	}
+}
+
+// escapeLike 转义 LIKE 通配符，关键词按字面匹配
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// ListDocuments 按条件列出文档，返回本页结果和下一页的游标 (没有下一页时为空)
+func (d *Data) ListDocuments(ctx context.Context, q DocumentQuery) ([]Document, string, error) {
+	sort := q.Sort
+	switch sort {
+	case "":
+		sort = DocumentSortCreatedAt
+	case DocumentSortCreatedAt, DocumentSortUpdatedAt, DocumentSortTitle, DocumentSortFileSize:
+	default:
+		return nil, "", fmt.Errorf("%w: 未知的排序字段 %q", ErrInvalidDocumentQuery, sort)
+	}
+	column := "documents." + sort
+
+	db := d.DB.WithContext(ctx).Model(&Document{}).Scopes(q.Access.ScopeDocuments)
+	if len(q.KnowledgeBaseIDs) > 0 {
+		db = db.Where("documents.knowledge_base_id IN ?", q.KnowledgeBaseIDs)
+	}
+	if len(q.Statuses) > 0 {
+		db = db.Where("documents.status IN ?", q.Statuses)
+	}
+	if len(q.FileTypes) > 0 {
+		db = db.Where("documents.file_type IN ?", q.FileTypes)
+	}
+	if q.OwnerID != 0 {
+		db = db.Where("documents.owner_id = ?", q.OwnerID)
+	}
+	if q.CreatedFrom != nil {
+		db = db.Where("documents.created_at >= ?", *q.CreatedFrom)
+	}
+	if q.CreatedTo != nil {
+		db = db.Where("documents.created_at < ?", *q.CreatedTo)
+	}
+	if q.Title != "" {
+		db = db.Where("documents.title ILIKE ?", "%"+escapeLike(q.Title)+"%")
+	}
+	if q.LatestOnly {
+		db = db.Where("documents.is_latest")
+	}
+
+	if q.Cursor != "" {
+		c, err := decodeDocumentCursor(q.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		if c.Sort != sort || c.Desc != q.Desc {
+			return nil, "", fmt.Errorf("%w: 游标与排序方式不一致", ErrInvalidDocumentQuery)
+		}
+		op := ">"
+		if q.Desc {
+			op = "<"
+		}
+		db = db.Where("("+column+", documents.id) "+op+" (?, ?)", c.value(), c.ID)
+	}
+
+	dir := " ASC"
+	if q.Desc {
+		dir = " DESC"
+	}
+	// 多取一行判断是否还有下一页
+	var docs []Document
+	err := db.Order(column + dir).Order("documents.id" + dir).Limit(q.Limit + 1).Find(&docs).Error
+	if err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(docs) > q.Limit {
+		docs = docs[:q.Limit]
+		next = encodeDocumentCursor(sort, q.Desc, &docs[len(docs)-1])
+	}
+	return docs, next, nil
+}
+
+// documentIndexes 列表查询用到的组合索引 (gorm 标签无法给 gorm.Model 里的 created_at 建组合索引)
+var documentIndexes = []string{
+	`CREATE INDEX IF NOT EXISTS idx_documents_kb_created ON documents (knowledge_base_id, created_at DESC, id DESC)`,
+	`CREATE INDEX IF NOT EXISTS idx_documents_owner_created ON documents (owner_id, created_at DESC, id DESC)`,
+	`CREATE INDEX IF NOT EXISTS idx_documents_created ON documents (created_at DESC, id DESC)`,
+}
+
+// ensureDocumentIndexes 建立文档列表的索引，都是幂等的
+// 标题模糊搜索用 pg_trgm 的 GIN 索引；没有权限建扩展时只打警告，ILIKE 仍然可用，只是要扫表
+func ensureDocumentIndexes(db *gorm.DB) error {
+	for _, stmt := range documentIndexes {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+
+	err := db.Exec(`CREATE EXTENSION IF NOT EXISTS pg_trgm`).Error
+	if err == nil {
+		err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_documents_title_trgm ON documents USING gin (title gin_trgm_ops)`).Error
+	}
+	if err != nil {
+		log.Printf("⚠️ 标题模糊搜索索引创建失败 (需要 pg_trgm 扩展): %v", err)
+	}
+	return nil
+}
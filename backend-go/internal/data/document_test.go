@@ -0,0 +1,56 @@
+package data
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDocTransitions(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		// 正常流程
+		{DocStatusPending, DocStatusParsing, true},
+		{DocStatusParsing, DocStatusEmbedding, true},
+		{DocStatusEmbedding, DocStatusSuccess, true},
+
+		// 重试与接管: 从中间状态重新解析，或回到 pending 等待重试
+		{DocStatusParsing, DocStatusParsing, true},
+		{DocStatusEmbedding, DocStatusParsing, true},
+		{DocStatusEmbedding, DocStatusEmbedding, true},
+		{DocStatusParsing, DocStatusPending, true},
+		{DocStatusEmbedding, DocStatusPending, true},
+		{DocStatusFailed, DocStatusPending, true},
+		{DocStatusFailed, DocStatusParsing, true},
+
+		// 失败
+		{DocStatusPending, DocStatusFailed, true},
+		{DocStatusParsing, DocStatusFailed, true},
+		{DocStatusEmbedding, DocStatusFailed, true},
+
+		// 重新解析已完成的文档
+		{DocStatusSuccess, DocStatusParsing, true},
+
+		// 不能跳过中间状态
+		{DocStatusPending, DocStatusEmbedding, false},
+		{DocStatusPending, DocStatusSuccess, false},
+		{DocStatusParsing, DocStatusSuccess, false},
+		{DocStatusFailed, DocStatusSuccess, false},
+
+		// 终态不能直接失败或回到排队
+		{DocStatusSuccess, DocStatusFailed, false},
+		{DocStatusSuccess, DocStatusPending, false},
+		{DocStatusSuccess, DocStatusSuccess, false},
+		{DocStatusFailed, DocStatusFailed, false},
+
+		{"unknown", DocStatusParsing, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			if got := slices.Contains(docTransitions[tt.from], tt.to); got != tt.want {
+				t.Errorf("%s -> %s allowed = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package data
+
+import (
+	"slices"
+
+	"github.com/qdrant/go-client/qdrant"
+)
+
+// ---------------------------------------------------------
+// 检索过滤 (Qdrant Payload Filter)
+// ---------------------------------------------------------
+
+// Qdrant Payload 字段名，ETL Worker 写入、检索时过滤都以此为准
+const (
+	PayloadDocumentID      = "document_id"
+	PayloadKnowledgeBaseID = "knowledge_base_id"
+	PayloadOwnerID         = "owner_id"
+	PayloadOrganizationID  = "organization_id"
+	PayloadFileType        = "file_type"
+	PayloadFileName        = "filename"
+	PayloadTitle           = "title"
+	PayloadPageNumber      = "page_number"
+	PayloadChunkIndex      = "chunk_index"
+	PayloadContent         = "content"
+	PayloadIsLatest        = "is_latest"
+	PayloadIngestRun       = "ingest_run" // 入库批次，用于清理旧切片
+	PayloadExcluded        = "excluded"   // 人工排除，不参与检索
+)
+
+// SearchFilter 检索过滤条件
+// 不同字段之间是 AND，同一字段的多个取值之间是 OR；零值表示不限制
+type SearchFilter struct {
+	KnowledgeBaseIDs []uint
+	DocumentIDs      []uint
+	ChunkIDs         []string // 切片 (Point) ID
+	OwnerIDs         []uint
+	OrganizationIDs  []uint
+	FileTypes        []string
+	FileNames        []string // 上传时的原始文件名 (title)，不是 MinIO 对象名
+
+	// 页码范围 (闭区间)
+	PageFrom int32
+	PageTo   int32
+
+	// Access 调用者的可见范围，为 nil 时不做权限限制 (仅限内部任务使用)
+	Access *AccessScope
+
+	// IncludeSuperseded 是否包含已被新版本取代的旧版本切片，默认不包含
+	IncludeSuperseded bool
+
+	// IncludeExcluded 是否包含被人工排除的切片，默认不包含
+	IncludeExcluded bool
+
+	// ExceptIngestRun 排除该入库批次写入的切片 (清理旧切片用)
+	ExceptIngestRun string
+}
+
+// toQdrant 转换为 Qdrant Filter，没有任何条件时返回 nil
+func (f *SearchFilter) toQdrant() *qdrant.Filter {
+	if f == nil {
+		return nil
+	}
+
+	var must []*qdrant.Condition
+	if len(f.KnowledgeBaseIDs) > 0 {
+		must = append(must, qdrant.NewMatchInts(PayloadKnowledgeBaseID, uintsToInt64s(f.KnowledgeBaseIDs)...))
+	}
+	if len(f.DocumentIDs) > 0 {
+		must = append(must, qdrant.NewMatchInts(PayloadDocumentID, uintsToInt64s(f.DocumentIDs)...))
+	}
+	if len(f.ChunkIDs) > 0 {
+		ids := make([]*qdrant.PointId, 0, len(f.ChunkIDs))
+		for _, id := range f.ChunkIDs {
+			ids = append(ids, qdrant.NewIDUUID(id))
+		}
+		must = append(must, qdrant.NewHasID(ids...))
+	}
+	if len(f.OwnerIDs) > 0 {
+		must = append(must, qdrant.NewMatchInts(PayloadOwnerID, uintsToInt64s(f.OwnerIDs)...))
+	}
+	if len(f.OrganizationIDs) > 0 {
+		must = append(must, qdrant.NewMatchInts(PayloadOrganizationID, uintsToInt64s(f.OrganizationIDs)...))
+	}
+	if len(f.FileTypes) > 0 {
+		must = append(must, qdrant.NewMatchKeywords(PayloadFileType, f.FileTypes...))
+	}
+	if len(f.FileNames) > 0 {
+		must = append(must, qdrant.NewMatchKeywords(PayloadTitle, f.FileNames...))
+	}
+	if f.PageFrom > 0 || f.PageTo > 0 {
+		r := &qdrant.Range{}
+		if f.PageFrom > 0 {
+			r.Gte = qdrant.PtrOf(float64(f.PageFrom))
+		}
+		if f.PageTo > 0 {
+			r.Lte = qdrant.PtrOf(float64(f.PageTo))
+		}
+		must = append(must, qdrant.NewRange(PayloadPageNumber, r))
+	}
+	if f.Access != nil {
+		must = append(must, f.Access.qdrantConditions()...)
+	}
+
+	// 旧版本切片 is_latest=false；用 must_not 而不是 must，老数据没有这个字段也能被检索到
+	var mustNot []*qdrant.Condition
+	if !f.IncludeSuperseded {
+		mustNot = append(mustNot, qdrant.NewMatchBool(PayloadIsLatest, false))
+	}
+	if !f.IncludeExcluded {
+		mustNot = append(mustNot, qdrant.NewMatchBool(PayloadExcluded, true))
+	}
+	if f.ExceptIngestRun != "" {
+		mustNot = append(mustNot, qdrant.NewMatchKeyword(PayloadIngestRun, f.ExceptIngestRun))
+	}
+
+	if len(must) == 0 && len(mustNot) == 0 {
+		return nil
+	}
+	return &qdrant.Filter{Must: must, MustNot: mustNot}
+}
+
+// matches 在内存中判断切片是否满足过滤条件 (关键词索引、内存向量存储使用)
+func (f *SearchFilter) matches(c LexicalChunk) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.KnowledgeBaseIDs) > 0 && !slices.Contains(f.KnowledgeBaseIDs, c.KnowledgeBaseID) {
+		return false
+	}
+	if len(f.DocumentIDs) > 0 && !slices.Contains(f.DocumentIDs, c.DocumentID) {
+		return false
+	}
+	if len(f.ChunkIDs) > 0 && !slices.Contains(f.ChunkIDs, c.ID) {
+		return false
+	}
+	if len(f.OwnerIDs) > 0 && !slices.Contains(f.OwnerIDs, c.OwnerID) {
+		return false
+	}
+	if len(f.OrganizationIDs) > 0 && !slices.Contains(f.OrganizationIDs, c.OrganizationID) {
+		return false
+	}
+	if len(f.FileTypes) > 0 && !slices.Contains(f.FileTypes, c.FileType) {
+		return false
+	}
+	if len(f.FileNames) > 0 && !slices.Contains(f.FileNames, c.Title) {
+		return false
+	}
+	if f.PageFrom > 0 && c.Page < f.PageFrom {
+		return false
+	}
+	if f.PageTo > 0 && c.Page > f.PageTo {
+		return false
+	}
+	if c.Superseded && !f.IncludeSuperseded {
+		return false
+	}
+	if c.Excluded && !f.IncludeExcluded {
+		return false
+	}
+	if f.ExceptIngestRun != "" && c.IngestRun == f.ExceptIngestRun {
+		return false
+	}
+	return f.Access.allows(c)
+}
+
+// payloadIndexes 需要建立 Payload 索引的字段，过滤时才不会全表扫描
+var payloadIndexes = map[string]qdrant.FieldType{
+	PayloadDocumentID:      qdrant.FieldType_FieldTypeInteger,
+	PayloadKnowledgeBaseID: qdrant.FieldType_FieldTypeInteger,
+	PayloadOwnerID:         qdrant.FieldType_FieldTypeInteger,
+	PayloadOrganizationID:  qdrant.FieldType_FieldTypeInteger,
+	PayloadPageNumber:      qdrant.FieldType_FieldTypeInteger,
+	PayloadChunkIndex:      qdrant.FieldType_FieldTypeInteger, // Scroll 按切片顺序排序需要
+	PayloadFileType:        qdrant.FieldType_FieldTypeKeyword,
+	PayloadTitle:           qdrant.FieldType_FieldTypeKeyword, // 按原始文件名过滤
+	PayloadIsLatest:        qdrant.FieldType_FieldTypeBool,
+	PayloadIngestRun:       qdrant.FieldType_FieldTypeKeyword,
+	PayloadExcluded:        qdrant.FieldType_FieldTypeBool,
+}
+
+func uintsToInt64s(ids []uint) []int64 {
+	out := make([]int64, len(ids))
+	for i, id := range ids {
+		out[i] = int64(id)
+	}
+	return out
+}
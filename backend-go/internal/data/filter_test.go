@@ -0,0 +1,70 @@
+package data
+
+import "testing"
+
+func TestSearchFilterMatches(t *testing.T) {
+	chunk := LexicalChunk{
+		ID:              "c1",
+		FileName:        "org-1/2f1c.pdf",
+		Title:           "安全规范.pdf",
+		Page:            5,
+		DocumentID:      10,
+		KnowledgeBaseID: 3,
+		OwnerID:         7,
+		OrganizationID:  1,
+		FileType:        ".pdf",
+	}
+	superseded := chunk
+	superseded.Superseded = true
+	excluded := chunk
+	excluded.Excluded = true
+
+	tests := []struct {
+		name   string
+		filter *SearchFilter
+		chunk  LexicalChunk
+		want   bool
+	}{
+		{"nil 不过滤", nil, chunk, true},
+		{"空条件不过滤", &SearchFilter{}, chunk, true},
+
+		{"知识库命中", &SearchFilter{KnowledgeBaseIDs: []uint{2, 3}}, chunk, true},
+		{"知识库未命中", &SearchFilter{KnowledgeBaseIDs: []uint{2}}, chunk, false},
+		{"文档未命中", &SearchFilter{DocumentIDs: []uint{11}}, chunk, false},
+		{"文件类型未命中", &SearchFilter{FileTypes: []string{".docx"}}, chunk, false},
+		{"文件名按上传时的标题匹配", &SearchFilter{FileNames: []string{"安全规范.pdf"}}, chunk, true},
+		{"文件名不匹配对象名", &SearchFilter{FileNames: []string{"org-1/2f1c.pdf"}}, chunk, false},
+		{"不同字段之间是 AND", &SearchFilter{KnowledgeBaseIDs: []uint{3}, FileTypes: []string{".docx"}}, chunk, false},
+
+		{"页码在范围内", &SearchFilter{PageFrom: 3, PageTo: 8}, chunk, true},
+		{"页码范围含下界", &SearchFilter{PageFrom: 5}, chunk, true},
+		{"页码范围含上界", &SearchFilter{PageTo: 5}, chunk, true},
+		{"页码小于下界", &SearchFilter{PageFrom: 6}, chunk, false},
+		{"页码大于上界", &SearchFilter{PageFrom: 1, PageTo: 4}, chunk, false},
+
+		{"默认不含旧版本", &SearchFilter{}, superseded, false},
+		{"显式包含旧版本", &SearchFilter{IncludeSuperseded: true}, superseded, true},
+		{"默认不含人工排除的切片", &SearchFilter{}, excluded, false},
+		{"显式包含人工排除的切片", &SearchFilter{IncludeExcluded: true}, excluded, true},
+		{"按切片 ID 过滤", &SearchFilter{ChunkIDs: []string{"c2"}}, chunk, false},
+		{"排除当前批次之外的切片", &SearchFilter{ExceptIngestRun: "run-1"}, LexicalChunk{IngestRun: "run-1"}, false},
+
+		{"平台管理员跨租户可见", &SearchFilter{Access: &AccessScope{AllTenants: true, All: true, OrganizationID: 2}}, chunk, true},
+		{"组织成员可见本组织全部", &SearchFilter{Access: &AccessScope{All: true, OrganizationID: 1, OwnerID: 8}}, chunk, true},
+		{"其他租户不可见", &SearchFilter{Access: &AccessScope{All: true, OrganizationID: 2, OwnerID: 8}}, chunk, false},
+		{"上传者可见", &SearchFilter{Access: &AccessScope{OrganizationID: 1, OwnerID: 7}}, chunk, true},
+		{"被授权的知识库可见", &SearchFilter{Access: &AccessScope{OrganizationID: 1, OwnerID: 8, KnowledgeBaseIDs: []uint{3}}}, chunk, true},
+		{"未授权不可见", &SearchFilter{Access: &AccessScope{OrganizationID: 1, OwnerID: 8, KnowledgeBaseIDs: []uint{4}}}, chunk, false},
+		{"租户条件必须满足", &SearchFilter{Access: &AccessScope{OrganizationID: 2, OwnerID: 7, KnowledgeBaseIDs: []uint{3}}}, chunk, false},
+		{"默认租户的上传者可见", &SearchFilter{Access: &AccessScope{OwnerID: 7}}, LexicalChunk{OwnerID: 7}, true},
+		{"默认租户里其他用户不可见", &SearchFilter{Access: &AccessScope{OwnerID: 8}}, LexicalChunk{OwnerID: 7}, false},
+		{"权限与其他条件同时生效", &SearchFilter{Access: &AccessScope{OrganizationID: 1, OwnerID: 7}, PageTo: 4}, chunk, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.matches(tt.chunk); got != tt.want {
+				t.Errorf("matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package data
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ---------------------------------------------------------
+// 组织邀请
+// 组织管理员创建邀请 -> 被邀请人登录后接受，或注册时带上邀请 Token 直接加入
+// ---------------------------------------------------------
+
+var (
+	ErrInviteNotFound = errors.New("invite not found")
+	// ErrInviteInvalid 邀请已过期、已使用、已撤销，或不是发给该用户的
+	ErrInviteInvalid = errors.New("invite invalid")
+)
+
+// NewInviteToken 生成邀请 Token，返回明文和要保存的哈希
+func NewInviteToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(b)
+	return token, hashInviteToken(token), nil
+}
+
+func hashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateInvite 保存邀请
+func (d *Data) CreateInvite(ctx context.Context, inv *OrganizationInvite) error {
+	return d.DB.WithContext(ctx).Create(inv).Error
+}
+
+// ListInvites 组织内尚未使用、未撤销的邀请 (含已过期的，便于管理员查看)
+func (d *Data) ListInvites(ctx context.Context, orgID uint) ([]OrganizationInvite, error) {
+	var invites []OrganizationInvite
+	err := d.DB.WithContext(ctx).
+		Where("organization_id = ? AND accepted_at IS NULL AND revoked_at IS NULL", orgID).
+		Order("created_at DESC").
+		Find(&invites).Error
+	return invites, err
+}
+
+// RevokeInvite 撤销组织内尚未使用的邀请
+func (d *Data) RevokeInvite(ctx context.Context, orgID, id uint) error {
+	res := d.DB.WithContext(ctx).Model(&OrganizationInvite{}).
+		Where("id = ? AND organization_id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id, orgID).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrInviteNotFound
+	}
+	return nil
+}
+
+// AcceptInvite 已有用户接受邀请: 加入邀请的组织并获得邀请的角色
+func (d *Data) AcceptInvite(ctx context.Context, token string, userID uint) (*OrganizationInvite, error) {
+	var inv *OrganizationInvite
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user User
+		err := tx.First(&user, userID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if user.Role == UserRoleAdmin {
+			return fmt.Errorf("%w: 平台管理员不能加入组织", ErrInviteInvalid)
+		}
+
+		inv, err = redeemInvite(tx, token, &user)
+		if err != nil {
+			return err
+		}
+		return setMembership(tx, &user, inv.OrganizationID, inv.Role)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// RegisterWithInvite 注册新用户并通过邀请加入组织，用户的组织与角色以邀请为准
+func (d *Data) RegisterWithInvite(ctx context.Context, user *User, token string) (*OrganizationInvite, error) {
+	var inv *OrganizationInvite
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if inv, err = redeemInvite(tx, token, user); err != nil {
+			return err
+		}
+		user.OrganizationID, user.Role = inv.OrganizationID, inv.Role
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		inv.AcceptedBy = &user.ID
+		return tx.Model(inv).Update("accepted_by", user.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// redeemInvite 在事务内锁住并核对邀请，标记为已使用
+// 邀请指定了用户名或邮箱时必须与 user 一致 (邮箱不区分大小写)
+func redeemInvite(tx *gorm.DB, token string, user *User) (*OrganizationInvite, error) {
+	var inv OrganizationInvite
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", hashInviteToken(token)).
+		First(&inv).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInviteNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case inv.AcceptedAt != nil:
+		return nil, fmt.Errorf("%w: 邀请已被使用", ErrInviteInvalid)
+	case inv.RevokedAt != nil:
+		return nil, fmt.Errorf("%w: 邀请已撤销", ErrInviteInvalid)
+	case time.Now().After(inv.ExpiresAt):
+		return nil, fmt.Errorf("%w: 邀请已过期", ErrInviteInvalid)
+	case inv.Username != "" && inv.Username != user.Username:
+		return nil, fmt.Errorf("%w: 邀请不是发给该用户的", ErrInviteInvalid)
+	case inv.Email != "" && !strings.EqualFold(inv.Email, user.Email):
+		return nil, fmt.Errorf("%w: 邀请不是发给该邮箱的", ErrInviteInvalid)
+	}
+
+	now := time.Now()
+	updates := map[string]any{"accepted_at": now}
+	if user.ID != 0 {
+		updates["accepted_by"] = user.ID
+	}
+	if err := tx.Model(&inv).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	inv.AcceptedAt = &now
+	if user.ID != 0 {
+		inv.AcceptedBy = &user.ID
+	}
+	return &inv, nil
+}
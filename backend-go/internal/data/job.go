@@ -0,0 +1,173 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ---------------------------------------------------------
+// 入库任务 (Ingestion Job)
+// ---------------------------------------------------------
+// 生产者 (RagService) 与 ETLWorker 共用的任务信封，以 JSON 写入队列。
+// 结构有不兼容变化时递增 JobVersion，Worker 拒绝处理自己不认识的新版本。
+
+// JobVersion 当前任务信封版本
+const JobVersion = 1
+
+// 任务类型
+const (
+	JobParse     = "parse"     // 首次解析入库
+	JobReindex   = "reindex"   // 重新解析，替换已有切片
+	JobDelete    = "delete"    // 清理向量与索引
+	JobSummarize = "summarize" // 生成文档摘要
+	JobReembed   = "reembed"   // 用新模型重建整个向量集合 (见 reindex.go)，不针对单个文档
+)
+
+// ErrUnsupportedJob 任务版本或类型无法处理，重试也没有意义
+var ErrUnsupportedJob = errors.New("unsupported job")
+
+// Job 任务信封
+type Job struct {
+	Version int       `json:"v"`
+	ID      string    `json:"job_id"`
+	Type    string    `json:"type"`
+	Created time.Time `json:"created_at"`
+
+	// 目标文档及其归属
+	DocumentID      uint `json:"document_id"`
+	OwnerID         uint `json:"owner_id"`
+	OrganizationID  uint `json:"organization_id"` // 租户
+	KnowledgeBaseID uint `json:"knowledge_base_id"`
+
+	Storage StorageLocation `json:"storage"`
+	Parser  ParserOptions   `json:"parser"`
+
+	// ReindexTaskID reembed 任务对应的 ReindexTask
+	ReindexTaskID uint `json:"reindex_task_id,omitempty"`
+
+	// Attempt 第几次执行，由 Worker 领取任务时根据队列记录填写
+	Attempt int `json:"attempt"`
+
+	// Trace 链路追踪上下文 (W3C traceparent)，用于把上传请求和后台处理串起来
+	Trace map[string]string `json:"trace,omitempty"`
+}
+
+// StorageLocation 原始文件在对象存储中的位置
+type StorageLocation struct {
+	Bucket string `json:"bucket"`
+	Object string `json:"object"`
+}
+
+// ParserOptions 解析参数，透传给 AI Service
+type ParserOptions struct {
+	Parser  string            `json:"parser"` // docling 等
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// NewJob 根据文档记录构造任务
+func NewJob(ctx context.Context, jobType string, doc *Document) *Job {
+	job := &Job{
+		Version:         JobVersion,
+		ID:              uuid.New().String(),
+		Type:            jobType,
+		Created:         time.Now(),
+		DocumentID:      doc.ID,
+		OwnerID:         doc.OwnerID,
+		OrganizationID:  doc.OrganizationID,
+		KnowledgeBaseID: doc.KnowledgeBaseID,
+		Storage:         StorageLocation{Bucket: "chimera-docs", Object: doc.StoragePath},
+		Parser:          ParserOptions{Parser: doc.ParserType},
+	}
+	job.withTrace(ctx)
+	return job
+}
+
+// NewReembedJob 重建索引任务，任务 ID 与 ReindexTask.JobID 一致
+func NewReembedJob(ctx context.Context, task *ReindexTask) *Job {
+	job := &Job{
+		Version:       JobVersion,
+		ID:            task.JobID,
+		Type:          JobReembed,
+		Created:       time.Now(),
+		ReindexTaskID: task.ID,
+	}
+	job.withTrace(ctx)
+	return job
+}
+
+func (j *Job) withTrace(ctx context.Context) {
+	if tp := TraceParent(ctx); tp != "" {
+		j.Trace = map[string]string{"traceparent": tp}
+	}
+}
+
+// TraceID 取出 traceparent 中的 trace-id，便于在日志里关联
+func (j *Job) TraceID() string {
+	parts := strings.Split(j.Trace["traceparent"], "-")
+	if len(parts) != 4 {
+		return ""
+	}
+	return parts[1]
+}
+
+// EncodeJob 序列化任务
+func EncodeJob(job *Job) (string, error) {
+	b, err := json.Marshal(job)
+	return string(b), err
+}
+
+// DecodeJob 反序列化任务
+// 兼容旧版本直接投递的 文档 ID / MinIO 对象名，视为解析任务 (Version 0)
+func DecodeJob(payload string) (*Job, error) {
+	if !strings.HasPrefix(strings.TrimSpace(payload), "{") {
+		job := &Job{Type: JobParse, Storage: StorageLocation{Bucket: "chimera-docs"}}
+		if id, err := strconv.ParseUint(payload, 10, 64); err == nil {
+			job.DocumentID = uint(id)
+		} else {
+			job.Storage.Object = payload
+		}
+		return job, nil
+	}
+
+	var job Job
+	if err := json.Unmarshal([]byte(payload), &job); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedJob, err)
+	}
+	if job.Version > JobVersion {
+		return nil, fmt.Errorf("%w: version %d", ErrUnsupportedJob, job.Version)
+	}
+	return &job, nil
+}
+
+// EnqueueJob 将任务写入入库队列
+func (d *Data) EnqueueJob(ctx context.Context, job *Job) error {
+	payload, err := EncodeJob(job)
+	if err != nil {
+		return err
+	}
+	return d.PushTask(ctx, QueueIngest, payload)
+}
+
+// ---------------------------------------------------------
+// 链路追踪上下文
+// ---------------------------------------------------------
+
+type traceParentKey struct{}
+
+// WithTraceParent 把 traceparent 放入 ctx，入队时会写进任务信封
+func WithTraceParent(ctx context.Context, traceParent string) context.Context {
+	return context.WithValue(ctx, traceParentKey{}, traceParent)
+}
+
+// TraceParent 从 ctx 取出 traceparent
+func TraceParent(ctx context.Context) string {
+	tp, _ := ctx.Value(traceParentKey{}).(string)
+	return tp
+}
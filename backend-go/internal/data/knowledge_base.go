@@ -0,0 +1,239 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ---------------------------------------------------------
+// 知识库 (文件夹树) 相关操作
+// ---------------------------------------------------------
+
+// DescendantKnowledgeBaseIDs 返回 ids 及其所有子孙节点的 ID
+// 按层展开 ParentID，用于把 "某个文件夹" 扩展成整棵子树
+func (d *Data) DescendantKnowledgeBaseIDs(ctx context.Context, ids []uint) ([]uint, error) {
+	seen := make(map[uint]bool, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+
+	frontier := result
+	for len(frontier) > 0 {
+		var children []uint
+		if err := d.DB.WithContext(ctx).Model(&KnowledgeBase{}).
+			Where("parent_id IN ?", frontier).
+			Pluck("id", &children).Error; err != nil {
+			return nil, err
+		}
+
+		var next []uint
+		for _, id := range children {
+			if !seen[id] {
+				seen[id] = true
+				result = append(result, id)
+				next = append(next, id)
+			}
+		}
+		frontier = next
+	}
+	return result, nil
+}
+
+// PublicKnowledgeBaseIDs 返回租户内所有公开知识库及其子文件夹的 ID
+func (d *Data) PublicKnowledgeBaseIDs(ctx context.Context, orgID uint) ([]uint, error) {
+	var roots []uint
+	if err := d.DB.WithContext(ctx).Model(&KnowledgeBase{}).
+		Where("is_public = ? AND organization_id = ?", true, orgID).
+		Pluck("id", &roots).Error; err != nil {
+		return nil, err
+	}
+	if len(roots) == 0 {
+		return nil, nil
+	}
+	return d.DescendantKnowledgeBaseIDs(ctx, roots)
+}
+
+// 知识库类型
+const (
+	KnowledgeBaseFolder = "folder"
+	KnowledgeBaseRepo   = "repo"
+)
+
+var (
+	ErrKnowledgeBaseNotFound = errors.New("knowledge base not found")
+	// ErrKnowledgeBaseConflict 移动会形成环、父节点无效等
+	ErrKnowledgeBaseConflict = errors.New("knowledge base conflict")
+)
+
+// KnowledgeBaseStats 某个知识库 (不含子文件夹) 下文档的统计
+type KnowledgeBaseStats struct {
+	DocumentCount int64            `json:"document_count"`
+	StatusCounts  map[string]int64 `json:"status_counts"`
+}
+
+// GetKnowledgeBase 根据 ID 查找知识库
+func (d *Data) GetKnowledgeBase(ctx context.Context, id uint) (*KnowledgeBase, error) {
+	var kb KnowledgeBase
+	err := d.DB.WithContext(ctx).First(&kb, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrKnowledgeBaseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &kb, nil
+}
+
+// ListKnowledgeBases 列出 scope 可见的全部知识库 (平铺，由调用方组装成树)
+func (d *Data) ListKnowledgeBases(ctx context.Context, scope *AccessScope) ([]KnowledgeBase, error) {
+	var kbs []KnowledgeBase
+	err := d.DB.WithContext(ctx).Model(&KnowledgeBase{}).
+		Scopes(scope.ScopeKnowledgeBases).
+		Order("name, id").
+		Find(&kbs).Error
+	return kbs, err
+}
+
+// CreateKnowledgeBase 创建知识库，ParentID 不为空时父节点必须存在
+func (d *Data) CreateKnowledgeBase(ctx context.Context, kb *KnowledgeBase) error {
+	if kb.ParentID != nil {
+		parent, err := d.GetKnowledgeBase(ctx, *kb.ParentID)
+		if err != nil {
+			if errors.Is(err, ErrKnowledgeBaseNotFound) {
+				return fmt.Errorf("%w: 父节点 %d 不存在", ErrKnowledgeBaseConflict, *kb.ParentID)
+			}
+			return err
+		}
+		// 子文件夹跟随父节点的租户
+		kb.OrganizationID = parent.OrganizationID
+	}
+	return d.DB.WithContext(ctx).Create(kb).Error
+}
+
+// UpdateKnowledgeBase 修改名称、描述、是否公开等字段
+func (d *Data) UpdateKnowledgeBase(ctx context.Context, id uint, fields map[string]any) error {
+	res := d.DB.WithContext(ctx).Model(&KnowledgeBase{}).Where("id = ?", id).Updates(fields)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrKnowledgeBaseNotFound
+	}
+	return nil
+}
+
+// MoveKnowledgeBase 把知识库移到 parentID 下 (nil 表示移到根)
+// 新父节点不能是自己或自己的子孙，否则树会成环
+func (d *Data) MoveKnowledgeBase(ctx context.Context, id uint, parentID *uint) error {
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 锁住被移动的节点，两个并发的移动不会各自通过检查后拼出一个环
+		var kb KnowledgeBase
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&kb, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrKnowledgeBaseNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if parentID != nil {
+			var parent KnowledgeBase
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&parent, *parentID).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: 父节点 %d 不存在", ErrKnowledgeBaseConflict, *parentID)
+			}
+			if err != nil {
+				return err
+			}
+			if parent.OrganizationID != kb.OrganizationID {
+				return fmt.Errorf("%w: 不能移动到其他组织的知识库下", ErrKnowledgeBaseConflict)
+			}
+			// 从新父节点往上走，遇到自己就说明成环
+			for cur := &parent; ; {
+				if cur.ID == id {
+					return fmt.Errorf("%w: 不能移动到自己或子文件夹下", ErrKnowledgeBaseConflict)
+				}
+				if cur.ParentID == nil {
+					break
+				}
+				var next KnowledgeBase
+				if err := tx.First(&next, *cur.ParentID).Error; err != nil {
+					return err
+				}
+				cur = &next
+			}
+		}
+		return tx.Model(&KnowledgeBase{}).Where("id = ?", id).Update("parent_id", parentID).Error
+	})
+}
+
+// DeleteKnowledgeBaseTree 软删除知识库及其整棵子树，连同其中的文档
+// 返回被删除的文档，调用方负责投递清理任务 (向量、原文件、数据库记录)
+func (d *Data) DeleteKnowledgeBaseTree(ctx context.Context, id uint) ([]Document, error) {
+	if _, err := d.GetKnowledgeBase(ctx, id); err != nil {
+		return nil, err
+	}
+	ids, err := d.DescendantKnowledgeBaseIDs(ctx, []uint{id})
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []Document
+	err = d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("knowledge_base_id IN ?", ids).Find(&docs).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("knowledge_base_id IN ?", ids).Delete(&Document{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("knowledge_base_id IN ?", ids).Delete(&KnowledgeBaseGrant{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", ids).Delete(&KnowledgeBase{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// KnowledgeBaseStats 统计每个知识库 (不含子文件夹) 下的文档数与各状态的文档数
+func (d *Data) KnowledgeBaseStats(ctx context.Context, ids []uint) (map[uint]*KnowledgeBaseStats, error) {
+	stats := make(map[uint]*KnowledgeBaseStats, len(ids))
+	if len(ids) == 0 {
+		return stats, nil
+	}
+
+	var rows []struct {
+		KnowledgeBaseID uint
+		Status          string
+		Count           int64
+	}
+	err := d.DB.WithContext(ctx).Model(&Document{}).
+		Select("knowledge_base_id, status, COUNT(*) AS count").
+		Where("knowledge_base_id IN ?", ids).
+		Group("knowledge_base_id, status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range rows {
+		s := stats[r.KnowledgeBaseID]
+		if s == nil {
+			s = &KnowledgeBaseStats{StatusCounts: make(map[string]int64)}
+			stats[r.KnowledgeBaseID] = s
+		}
+		s.DocumentCount += r.Count
+		s.StatusCounts[r.Status] += r.Count
+	}
+	return stats, nil
+}
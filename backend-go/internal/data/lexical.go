@@ -0,0 +1,446 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ---------------------------------------------------------
+// 关键词检索 (进程内 BM25 倒排索引)
+// ---------------------------------------------------------
+
+// BM25 经验参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// LexicalChunk 是写入关键词索引的一个切片
+// ID 与向量存储中的 Point ID 保持一致，方便与向量结果做融合
+type LexicalChunk struct {
+	ID       string
+	Content  string
+	FileName string
+	Title    string
+	Page     int32
+	// ChunkIndex 切片在文档中的序号
+	ChunkIndex int32
+
+	// 元数据，用于检索过滤
+	DocumentID      uint
+	KnowledgeBaseID uint
+	OwnerID         uint
+	OrganizationID  uint
+	FileType        string
+
+	// Superseded 已被新版本取代 (对应 Payload is_latest=false)
+	Superseded bool
+	// IngestRun 写入该切片的入库批次
+	IngestRun string
+	// Excluded 被人工排除，不参与检索 (对应 Payload excluded=true)
+	Excluded bool
+}
+
+// toResult 转换为检索结果
+func (c LexicalChunk) toResult(score float32) SearchResult {
+	return SearchResult{
+		ID:         c.ID,
+		DocumentID: c.DocumentID,
+		Content:    c.Content,
+		FileName:   c.FileName,
+		Title:      c.Title,
+		Page:       c.Page,
+		Score:      score,
+	}
+}
+
+type lexicalDoc struct {
+	chunk  LexicalChunk
+	length int
+}
+
+// LexicalIndex 基于 BM25 的内存倒排索引
+// 适合精确词命中: 标准号 (GB 30871-2022)、化学品名、CAS 号等
+type LexicalIndex struct {
+	mu       sync.RWMutex
+	docs     map[string]*lexicalDoc
+	postings map[string]map[string]int // term -> chunkID -> tf
+	totalLen int
+}
+
+func NewLexicalIndex() *LexicalIndex {
+	return &LexicalIndex{
+		docs:     make(map[string]*lexicalDoc),
+		postings: make(map[string]map[string]int),
+	}
+}
+
+// Add 写入 (或覆盖) 切片
+func (idx *LexicalIndex) Add(chunks ...LexicalChunk) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, c := range chunks {
+		idx.removeLocked(c.ID)
+
+		terms := tokenize(c.Content)
+		for _, t := range terms {
+			if idx.postings[t] == nil {
+				idx.postings[t] = make(map[string]int)
+			}
+			idx.postings[t][c.ID]++
+		}
+		idx.docs[c.ID] = &lexicalDoc{chunk: c, length: len(terms)}
+		idx.totalLen += len(terms)
+	}
+}
+
+func (idx *LexicalIndex) removeLocked(id string) {
+	old, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	for _, t := range tokenize(old.chunk.Content) {
+		if p := idx.postings[t]; p != nil {
+			delete(p, id)
+			if len(p) == 0 {
+				delete(idx.postings, t)
+			}
+		}
+	}
+	idx.totalLen -= old.length
+	delete(idx.docs, id)
+}
+
+// RemoveDocument 删除某个文档的全部切片
+func (idx *LexicalIndex) RemoveDocument(documentID uint) {
+	idx.RemoveDocumentExcept(documentID, "")
+}
+
+// RemoveDocumentExcept 删除某个文档中不属于 keepRun 批次的切片，keepRun 为空时全部删除
+func (idx *LexicalIndex) RemoveDocumentExcept(documentID uint, keepRun string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for id, doc := range idx.docs {
+		if doc.chunk.DocumentID == documentID && (keepRun == "" || doc.chunk.IngestRun != keepRun) {
+			idx.removeLocked(id)
+		}
+	}
+}
+
+// SetSuperseded 标记某个文档的切片是否已被新版本取代
+func (idx *LexicalIndex) SetSuperseded(documentID uint, superseded bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, doc := range idx.docs {
+		if doc.chunk.DocumentID == documentID {
+			doc.chunk.Superseded = superseded
+		}
+	}
+}
+
+// Remove 删除指定切片
+func (idx *LexicalIndex) Remove(ids ...string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, id := range ids {
+		idx.removeLocked(id)
+	}
+}
+
+// SetExcluded 标记切片是否被人工排除
+func (idx *LexicalIndex) SetExcluded(ids []string, excluded bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, id := range ids {
+		if doc, ok := idx.docs[id]; ok {
+			doc.chunk.Excluded = excluded
+		}
+	}
+}
+
+// Len 返回索引中的切片数
+func (idx *LexicalIndex) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// Search 按 BM25 分数返回 TopK，filter 为 nil 时不过滤
+func (idx *LexicalIndex) Search(query string, topK int, filter *SearchFilter) []SearchResult {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	n := len(idx.docs)
+	if n == 0 || topK <= 0 {
+		return nil
+	}
+	avgLen := float64(idx.totalLen) / float64(n)
+
+	scores := make(map[string]float64)
+	seen := make(map[string]bool)
+	for _, t := range tokenize(query) {
+		// 查询里重复出现的词只算一次
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+
+		posting := idx.postings[t]
+		if len(posting) == 0 {
+			continue
+		}
+		df := float64(len(posting))
+		idf := math.Log(1 + (float64(n)-df+0.5)/(df+0.5))
+		for id, tf := range posting {
+			if !filter.matches(idx.docs[id].chunk) {
+				continue
+			}
+			dl := float64(idx.docs[id].length)
+			f := float64(tf)
+			scores[id] += idf * f * (bm25K1 + 1) / (f + bm25K1*(1-bm25B+bm25B*dl/avgLen))
+		}
+	}
+
+	results := make([]SearchResult, 0, len(scores))
+	for id, score := range scores {
+		results = append(results, idx.docs[id].chunk.toResult(float32(score)))
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	if len(results) > topK {
+		results = results[:topK]
+	}
+	return results
+}
+
+// tokenize 分词规则:
+//   - 字母/数字串整体作为一个词 (小写)，连字符、点号连接的编号额外保留整体
+//     例如 "GB 30871-2022" -> gb, 30871, 2022, 30871-2022
+//   - 中文按单字 + 双字 (bigram) 切分，不依赖外部词典
+func tokenize(text string) []string {
+	var tokens []string
+	runes := []rune(strings.ToLower(text))
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case isHan(r):
+			j := i
+			for j < len(runes) && isHan(runes[j]) {
+				j++
+			}
+			for k := i; k < j; k++ {
+				tokens = append(tokens, string(runes[k]))
+				if k+1 < j {
+					tokens = append(tokens, string(runes[k:k+2]))
+				}
+			}
+			i = j
+		case isWordRune(r):
+			j := i
+			for j < len(runes) && (isWordRune(runes[j]) || (isJoiner(runes[j]) && j+1 < len(runes) && isWordRune(runes[j+1]))) {
+				j++
+			}
+			compound := string(runes[i:j])
+			parts := strings.FieldsFunc(compound, isJoiner)
+			tokens = append(tokens, parts...)
+			if len(parts) > 1 {
+				tokens = append(tokens, compound)
+			}
+			i = j
+		default:
+			i++
+		}
+	}
+	return tokens
+}
+
+func isHan(r rune) bool {
+	return unicode.Is(unicode.Han, r)
+}
+
+func isWordRune(r rune) bool {
+	return !isHan(r) && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+func isJoiner(r rune) bool {
+	return r == '-' || r == '.' || r == '/' || r == '_'
+}
+
+// KeywordSearch 关键词检索 (BM25)
+func (d *Data) KeywordSearch(ctx context.Context, query string, topK uint64, filter *SearchFilter) ([]SearchResult, error) {
+	return d.Lexical.Search(query, int(topK), filter), nil
+}
+
+// IndexChunks 将新入库的切片写入关键词索引
+func (d *Data) IndexChunks(chunks ...LexicalChunk) {
+	d.Lexical.Add(chunks...)
+}
+
+// loadLexicalIndex 启动时从切片表重建关键词索引
+// 索引只在内存中，进程重启后需要重新灌入
+func (d *Data) loadLexicalIndex(ctx context.Context) {
+	n, err := d.countChunkRecords(ctx)
+	if err != nil {
+		log.Printf("⚠️ 关键词索引重建失败: %v", err)
+		return
+	}
+	if n == 0 {
+		// 切片表为空: 升级前的切片只存在向量 Payload 里，迁移一次
+		d.migrateChunkRecords(ctx)
+		return
+	}
+
+	total := 0
+	err = d.ScrollChunks(ctx, &SearchFilter{IncludeSuperseded: true, IncludeExcluded: true}, func(chunks []LexicalChunk) error {
+		d.Lexical.Add(chunks...)
+		total += len(chunks)
+		return nil
+	})
+	if err != nil {
+		log.Printf("⚠️ 关键词索引重建失败: %v", err)
+		return
+	}
+
+	log.Printf("✅ 关键词索引已加载 (%d 个切片)", total)
+}
+
+// migrateChunkRecords 从当前检索集合的 Payload 回填切片表，同时灌入关键词索引
+// 早期的 Payload 没有 document_id 等元数据，只有 filename (即 documents.storage_path)，
+// 按它找回文档；文档级字段 (知识库、归属、版本等) 一律以 documents 表为准，找不到文档的切片跳过
+func (d *Data) migrateChunkRecords(ctx context.Context) {
+	model, store, err := d.LiveVectors(ctx)
+	if err != nil {
+		log.Printf("⚠️ 切片表迁移失败: %v", err)
+		return
+	}
+
+	resolver := newChunkDocResolver(d)
+	total, skipped := 0, 0
+	err = store.Scroll(ctx, &SearchFilter{IncludeSuperseded: true, IncludeExcluded: true}, func(chunks []LexicalChunk) error {
+		records := make([]Chunk, 0, len(chunks))
+		resolved := make([]LexicalChunk, 0, len(chunks))
+		for _, c := range chunks {
+			doc, orgID, err := resolver.resolve(ctx, c)
+			if err != nil {
+				return err
+			}
+			if doc == nil {
+				skipped++
+				continue
+			}
+			c.DocumentID = doc.ID
+			c.KnowledgeBaseID = doc.KnowledgeBaseID
+			c.OwnerID = doc.OwnerID
+			c.OrganizationID = orgID
+			c.FileType = doc.FileType
+			c.Title = doc.Title
+			c.Superseded = !doc.IsLatest
+			records = append(records, NewChunkRecord(c, doc.Version, model))
+			resolved = append(resolved, c)
+		}
+		if err := d.SaveChunks(ctx, records); err != nil {
+			return err
+		}
+		d.Lexical.Add(resolved...)
+		total += len(resolved)
+		return nil
+	})
+	if err != nil {
+		log.Printf("⚠️ 切片表迁移失败: %v", err)
+		return
+	}
+	if skipped > 0 {
+		log.Printf("⚠️ 切片表迁移: %d 个切片找不到对应的文档 (可能已删除)，已跳过", skipped)
+	}
+	if total > 0 {
+		log.Printf("✅ 已从向量存储迁移 %d 个切片到切片表", total)
+	}
+	log.Printf("✅ 关键词索引已加载 (%d 个切片)", total)
+}
+
+// chunkDocResolver 迁移时查找切片所属的文档及上传者所在的组织，结果按文档缓存
+type chunkDocResolver struct {
+	d      *Data
+	byID   map[uint]*Document
+	byPath map[string]*Document
+	orgs   map[uint]uint // owner_id -> organization_id
+}
+
+func newChunkDocResolver(d *Data) *chunkDocResolver {
+	return &chunkDocResolver{
+		d:      d,
+		byID:   make(map[uint]*Document),
+		byPath: make(map[string]*Document),
+		orgs:   make(map[uint]uint),
+	}
+}
+
+// resolve 有 document_id 时按 ID 查，否则按 filename 查 storage_path；找不到时返回 nil
+func (r *chunkDocResolver) resolve(ctx context.Context, c LexicalChunk) (*Document, uint, error) {
+	var doc *Document
+	if c.DocumentID != 0 {
+		cached, ok := r.byID[c.DocumentID]
+		if !ok {
+			found, err := r.d.GetDocument(ctx, c.DocumentID)
+			if err != nil && !errors.Is(err, ErrDocumentNotFound) {
+				return nil, 0, err
+			}
+			cached = found
+			r.byID[c.DocumentID] = cached
+		}
+		doc = cached
+	} else if c.FileName != "" {
+		cached, ok := r.byPath[c.FileName]
+		if !ok {
+			found, err := r.d.GetDocumentByStoragePath(ctx, c.FileName)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, 0, err
+			}
+			cached = found
+			r.byPath[c.FileName] = cached
+		}
+		doc = cached
+	}
+	if doc == nil {
+		return nil, 0, nil
+	}
+
+	orgID, ok := r.orgs[doc.OwnerID]
+	if !ok {
+		if owner, err := r.d.GetUser(ctx, doc.OwnerID); err == nil {
+			orgID = owner.OrganizationID
+		}
+		r.orgs[doc.OwnerID] = orgID
+	}
+	return doc, orgID, nil
+}
+
+// chunkPointNamespace 切片 Point ID 的 UUIDv5 命名空间 (固定值，改了会导致全部 ID 变化)
+var chunkPointNamespace = uuid.MustParse("6f1c2a8e-3b7d-4e59-9a0c-5d2e8f4b7c31")
+
+// ChunkPointID 由 (文档 ID, 版本, 切片序号) 确定性地生成 Point ID
+// 同一切片重复写入会覆盖而不是新增，重试任务不会产生重复向量
+func ChunkPointID(documentID uint, version int, chunkIndex int32) string {
+	name := fmt.Sprintf("doc:%d:v%d:chunk:%d", documentID, version, chunkIndex)
+	return uuid.NewSHA1(chunkPointNamespace, []byte(name)).String()
+}
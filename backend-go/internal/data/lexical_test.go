@@ -0,0 +1,85 @@
+package data
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"GB 30871-2022", []string{"gb", "30871", "2022", "30871-2022"}},
+		{"GB/T 3836.1-2021", []string{"gb", "t", "gb/t", "3836", "1", "2021", "3836.1-2021"}},
+		{"CAS 64-17-5", []string{"cas", "64", "17", "5", "64-17-5"}},
+		{"CAS号：7732-18-5。", []string{"cas", "号", "7732", "18", "5", "7732-18-5"}},
+		{"动火作业", []string{"动", "动火", "火", "火作", "作", "作业", "业"}},
+		{"结尾的连字符-", []string{"结", "结尾", "尾", "尾的", "的", "的连", "连", "连字", "字", "字符", "符"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := tokenize(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("tokenize(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLexicalIndexSearch(t *testing.T) {
+	idx := NewLexicalIndex()
+	idx.Add(
+		LexicalChunk{ID: "gb30871", Content: "GB 30871-2022 危险化学品企业特殊作业安全规范，动火作业分级"},
+		LexicalChunk{ID: "gb30000", Content: "GB 30000.7-2013 化学品分类和标签规范 第7部分：易燃液体"},
+		LexicalChunk{ID: "ethanol", Content: "乙醇 CAS 64-17-5，易燃液体，闪点 13℃"},
+		LexicalChunk{ID: "methanol", Content: "甲醇 CAS 67-56-1，易燃液体，有毒"},
+		LexicalChunk{ID: "water", Content: "水 CAS 7732-18-5"},
+	)
+
+	tests := []struct {
+		name  string
+		query string
+		topK  int
+		want  []string
+	}{
+		{"标准号整体命中排第一", "GB 30871-2022", 10, []string{"gb30871", "gb30000"}},
+		{"标准号中的年份也能命中", "2013", 10, []string{"gb30000"}},
+		{"CAS 号整体命中排第一", "64-17-5", 10, []string{"ethanol", "water"}},
+		{"CAS 号整体优先于拆开的数字", "CAS 7732-18-5", 1, []string{"water"}},
+		{"中文双字命中", "动火", 10, []string{"gb30871"}},
+		{"没有命中", "氯气", 10, []string{}},
+		{"TopK 为 0", "易燃液体", 0, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := idx.Search(tt.query, tt.topK, nil)
+			got := make([]string, len(results))
+			for i, r := range results {
+				got[i] = r.ID
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLexicalIndexRemove(t *testing.T) {
+	idx := NewLexicalIndex()
+	idx.Add(
+		LexicalChunk{ID: "a", DocumentID: 1, IngestRun: "old", Content: "GB 30871-2022"},
+		LexicalChunk{ID: "b", DocumentID: 1, IngestRun: "new", Content: "GB 30871-2022"},
+		LexicalChunk{ID: "c", DocumentID: 2, IngestRun: "old", Content: "GB 30871-2022"},
+	)
+
+	idx.RemoveDocumentExcept(1, "new")
+	if got := idx.Len(); got != 2 {
+		t.Fatalf("Len = %d, want 2", got)
+	}
+	// 同一 ID 再次写入是覆盖，不会重复计数
+	idx.Add(LexicalChunk{ID: "b", DocumentID: 1, Content: "动火作业"})
+	if got := idx.Search("30871", 10, nil); len(got) != 1 || got[0].ID != "c" {
+		t.Errorf("Search(30871) = %v, want [c]", got)
+	}
+}
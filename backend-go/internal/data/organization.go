@@ -0,0 +1,171 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+
+	"gorm.io/gorm"
+)
+
+// ---------------------------------------------------------
+// 组织 (租户)
+// 文档、知识库、用户组、向量 Payload 都带 organization_id，
+// 检索与列表的租户条件是必须满足的 (AND)，见 AccessScope
+// ---------------------------------------------------------
+
+// 用户角色
+const (
+	UserRoleAdmin    = "admin"     // 平台管理员，可以跨租户
+	UserRoleOrgAdmin = "org_admin" // 组织管理员，管理本租户的用户、用户组和全部知识库
+	UserRoleUser     = "user"
+)
+
+var (
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrOrganizationConflict = errors.New("organization conflict")
+	ErrUserNotFound         = errors.New("user not found")
+	// ErrInvalidMembership 组织 Key 不合法、角色未知等
+	ErrInvalidMembership = errors.New("invalid organization membership")
+)
+
+// TenantPrefix 租户在 MinIO 中的对象前缀
+// 默认租户 (nil) 没有前缀，沿用原来的对象布局；未设置 Key 的组织用 org-<ID>
+func TenantPrefix(org *Organization) string {
+	if org == nil {
+		return ""
+	}
+	if org.Key != "" {
+		return org.Key
+	}
+	return fmt.Sprintf("org-%d", org.ID)
+}
+
+// generatedPrefixPattern 未设置 Key 时生成的前缀形式，自定义 Key 不能占用，否则会和其他组织共用前缀
+var generatedPrefixPattern = regexp.MustCompile(`^org-\d+$`)
+
+type tenantKey struct{}
+
+// WithTenant 把 JWT 中的组织放入 ctx，AccessPolicy 以此为准
+func WithTenant(ctx context.Context, orgID uint) context.Context {
+	return context.WithValue(ctx, tenantKey{}, orgID)
+}
+
+// TenantFromContext 从 ctx 取出组织；后台任务等没有经过鉴权的调用返回 false
+func TenantFromContext(ctx context.Context) (uint, bool) {
+	orgID, ok := ctx.Value(tenantKey{}).(uint)
+	return orgID, ok
+}
+
+// GetOrganization 根据 ID 查找组织
+func (d *Data) GetOrganization(ctx context.Context, id uint) (*Organization, error) {
+	var org Organization
+	err := d.DB.WithContext(ctx).First(&org, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// ListOrganizations 全部组织
+func (d *Data) ListOrganizations(ctx context.Context) ([]Organization, error) {
+	var orgs []Organization
+	err := d.DB.WithContext(ctx).Order("id").Find(&orgs).Error
+	return orgs, err
+}
+
+// CreateOrganization 创建组织，名称或 Key 重复时返回 ErrOrganizationConflict
+// Key 不能是 org-<数字> 的形式，这是留给未设置 Key 的组织的前缀
+func (d *Data) CreateOrganization(ctx context.Context, org *Organization) error {
+	if generatedPrefixPattern.MatchString(org.Key) {
+		return fmt.Errorf("%w: Key 不能使用 org-<数字> 的形式", ErrInvalidMembership)
+	}
+	var n int64
+	err := d.DB.WithContext(ctx).Model(&Organization{}).
+		Where("name = ? OR (key = ? AND key <> '')", org.Name, org.Key).
+		Count(&n).Error
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return fmt.Errorf("%w: 组织名称或 Key 已存在", ErrOrganizationConflict)
+	}
+	return d.DB.WithContext(ctx).Create(org).Error
+}
+
+// OrganizationUsers 组织内的全部用户
+func (d *Data) OrganizationUsers(ctx context.Context, orgID uint) ([]User, error) {
+	var users []User
+	err := d.DB.WithContext(ctx).
+		Where("organization_id = ?", orgID).
+		Order("id").
+		Find(&users).Error
+	return users, err
+}
+
+// UpdateUserMembership 修改用户的组织与角色
+func (d *Data) UpdateUserMembership(ctx context.Context, userID, orgID uint, role string) error {
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user User
+		err := tx.First(&user, userID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return setMembership(tx, &user, orgID, role)
+	})
+}
+
+// setMembership 在事务内修改用户的组织与角色
+// 用户已上传的文档、创建的知识库仍留在原租户；换组织时移出原租户的用户组，
+// 并撤销原租户知识库上直接授予他的权限，离开后立即失去访问
+func setMembership(tx *gorm.DB, user *User, orgID uint, role string) error {
+	if user.OrganizationID != orgID {
+		newDB := tx.Session(&gorm.Session{NewDB: true})
+		err := tx.Where("user_id = ? AND group_id IN (?)", user.ID,
+			newDB.Model(&Group{}).Select("id").Where("organization_id = ?", user.OrganizationID)).
+			Delete(&GroupMember{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("subject_type = ? AND subject_id = ? AND knowledge_base_id IN (?)", SubjectUser, user.ID,
+			newDB.Model(&KnowledgeBase{}).Select("id").Where("organization_id = ?", user.OrganizationID)).
+			Delete(&KnowledgeBaseGrant{}).Error
+		if err != nil {
+			return err
+		}
+	}
+	if err := tx.Model(user).Updates(map[string]any{"organization_id": orgID, "role": role}).Error; err != nil {
+		return err
+	}
+	user.OrganizationID, user.Role = orgID, role
+	return nil
+}
+
+// backfillTenants 给升级前的文档、知识库补上租户 (取创建者当时所在的组织)
+// 只在 organization_id 列刚加上时执行一次，之后换组织的用户不会把旧数据带走
+func backfillTenants(db *gorm.DB) error {
+	stmts := []string{
+		`UPDATE documents SET organization_id = u.organization_id
+			FROM users u WHERE u.id = documents.owner_id AND u.organization_id <> 0`,
+		`UPDATE knowledge_bases SET organization_id = u.organization_id
+			FROM users u WHERE u.id = knowledge_bases.owner_id AND u.organization_id <> 0`,
+	}
+	for _, stmt := range stmts {
+		res := db.Exec(stmt)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected > 0 {
+			log.Printf("🏢 已为 %d 条旧记录补上租户", res.RowsAffected)
+		}
+	}
+	return nil
+}
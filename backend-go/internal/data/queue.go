@@ -2,19 +2,354 @@ package data
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
 )
 
 // ---------------------------------------------------------
 // Redis 相关操作 (Queue)
 // ---------------------------------------------------------
+// 基于 Redis Streams 消费组的可靠队列:
+//   - <queue>          主队列 (Stream)，XREADGROUP 读取、处理成功后 XACK
+//   - <queue>:delayed  等待重试的任务 (ZSET，score 为可重试的时间戳)
+//   - <queue>:dead     超过最大重试次数的死信 (Stream)
+// Worker 崩溃时消息停留在 PEL 中，超过可见性超时后由其他 Worker 通过 XAUTOCLAIM 接管
+
+// QueueIngest 入库队列 (Stream)，解析 / 重建 / 删除 / 摘要等任务共用 (见 job.go)
+// legacyParsePDFQueue 是 List 类型的旧队列，Worker 启动时由 MigrateLegacy 把其中的任务搬到 QueueIngest
+const (
+	QueueIngest         = "queue:parse_pdf"
+	legacyParsePDFQueue = "task:parse_pdf"
+)
+
+// 队列消息字段
+const (
+	fieldPayload  = "payload"
+	fieldAttempts = "attempts"
+	fieldError    = "error"
+	fieldFailedAt = "failed_at"
+)
+
+// queueGroup 消费组名，所有 Worker 共用一个组
+const queueGroup = "etl-workers"
+
+// errVisibilityTimeout 消息被接管时记录的失败原因
+var errVisibilityTimeout = errors.New("处理超时 (可见性超时后被重新接管)")
+
+// QueueOptions 重试策略
+type QueueOptions struct {
+	VisibilityTimeout time.Duration // 消息被领取后多久未 ACK 视为 Worker 已挂
+	MaxAttempts       int           // 最多执行次数，超过后进入死信队列
+	BaseBackoff       time.Duration // 第一次重试的等待时间，之后指数增长
+	MaxBackoff        time.Duration
+}
+
+// Task 从队列中领取的一条任务
+type Task struct {
+	ID       string // Stream 消息 ID
+	Payload  string
+	Attempts int // 之前已经失败的次数
+}
+
+// QueueStats 队列运行状态
+type QueueStats struct {
+	Queue     string `json:"queue"`
+	Depth     int64  `json:"depth"`     // Stream 中尚未 ACK 的消息总数
+	Lag       int64  `json:"lag"`       // 还没有被任何 Worker 领取的消息数
+	Pending   int64  `json:"pending"`   // 已领取、处理中 (未 ACK) 的消息数
+	Delayed   int64  `json:"delayed"`   // 等待退避重试的消息数
+	Dead      int64  `json:"dead"`      // 死信数
+	Consumers int64  `json:"consumers"` // 消费者数
+}
+
+// delayedTask 重试等待区中的任务
+type delayedTask struct {
+	Payload  string `json:"payload"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error"`
+}
+
+// TaskQueue 单个队列的读写入口
+type TaskQueue struct {
+	rdb  *redis.Client
+	name string
+	opts QueueOptions
+
+	// onFailure 任务失败 (含超时被接管) 后的回调，dead 表示已进入死信队列
+	onFailure func(ctx context.Context, task *Task, cause error, dead bool)
+}
+
+// Queue 获取指定名称的队列
+func (d *Data) Queue(name string, opts QueueOptions) *TaskQueue {
+	return &TaskQueue{rdb: d.Redis, name: name, opts: opts}
+}
 
 // PushTask 将任务推送到 Redis 队列
 func (d *Data) PushTask(ctx context.Context, queueName string, payload string) error {
-	// 使用 RPush (右进) 配合 Worker 的 BLPop (左出) 实现 FIFO 队列
-	err := d.Redis.RPush(ctx, queueName, payload).Err()
+	err := d.Redis.XAdd(ctx, &redis.XAddArgs{
+		Stream: queueName,
+		Values: map[string]interface{}{fieldPayload: payload, fieldAttempts: 0},
+	}).Err()
 	if err != nil {
 		return fmt.Errorf("redis push error: %w", err)
 	}
 	return nil
 }
+
+// OnFailure 注册失败回调，Worker 用它同步业务侧状态
+// 超时接管发生在 Read 内部，Worker 无法在处理循环里感知，只能通过回调
+func (q *TaskQueue) OnFailure(fn func(ctx context.Context, task *Task, cause error, dead bool)) {
+	q.onFailure = fn
+}
+
+// Name 队列名
+func (q *TaskQueue) Name() string {
+	return q.name
+}
+
+func (q *TaskQueue) delayedKey() string { return q.name + ":delayed" }
+func (q *TaskQueue) deadKey() string    { return q.name + ":dead" }
+
+// EnsureGroup 创建消费组 (已存在则忽略)
+// 从 0 开始消费，升级前已经在 Stream 里的消息不会被跳过
+func (q *TaskQueue) EnsureGroup(ctx context.Context) error {
+	err := q.rdb.XGroupCreateMkStream(ctx, q.name, queueGroup, "0").Err()
+	if err != nil && !strings.Contains(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("create consumer group error: %w", err)
+	}
+	return nil
+}
+
+// MigrateLegacy 把旧版 List 队列中残留的任务搬到 Stream，只对解析队列有效
+func (q *TaskQueue) MigrateLegacy(ctx context.Context) (int, error) {
+	if q.name != QueueIngest {
+		return 0, nil
+	}
+	moved := 0
+	for {
+		payload, err := q.rdb.LPop(ctx, legacyParsePDFQueue).Result()
+		if errors.Is(err, redis.Nil) {
+			return moved, nil
+		}
+		if err != nil {
+			return moved, err
+		}
+		err = q.rdb.XAdd(ctx, &redis.XAddArgs{
+			Stream: q.name,
+			Values: map[string]interface{}{fieldPayload: payload, fieldAttempts: 0},
+		}).Err()
+		if err != nil {
+			return moved, err
+		}
+		moved++
+	}
+}
+
+// Read 领取一条任务，block 时间内没有任务时返回 (nil, nil)
+// 顺序: 到期的重试任务回流 -> 接管超时消息 -> 读取新消息
+func (q *TaskQueue) Read(ctx context.Context, consumer string, block time.Duration) (*Task, error) {
+	if err := q.promoteDelayed(ctx); err != nil {
+		return nil, err
+	}
+	if err := q.reclaimStuck(ctx, consumer); err != nil {
+		return nil, err
+	}
+
+	streams, err := q.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    queueGroup,
+		Consumer: consumer,
+		Streams:  []string{q.name, ">"},
+		Count:    1,
+		Block:    block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range streams {
+		for _, msg := range s.Messages {
+			return taskFromMessage(msg), nil
+		}
+	}
+	return nil, nil
+}
+
+// Ack 确认任务处理完成，并从 Stream 中删除
+func (q *TaskQueue) Ack(ctx context.Context, task *Task) error {
+	pipe := q.rdb.TxPipeline()
+	pipe.XAck(ctx, q.name, queueGroup, task.ID)
+	pipe.XDel(ctx, q.name, task.ID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Touch 续期: 重置消息的空闲时间，防止处理时间较长的任务被其他 Worker 接管
+func (q *TaskQueue) Touch(ctx context.Context, consumer string, task *Task) error {
+	return q.rdb.XClaimJustID(ctx, &redis.XClaimArgs{
+		Stream:   q.name,
+		Group:    queueGroup,
+		Consumer: consumer,
+		MinIdle:  0,
+		Messages: []string{task.ID},
+	}).Err()
+}
+
+// HeartbeatInterval Worker 续期的间隔
+func (q *TaskQueue) HeartbeatInterval() time.Duration {
+	if q.opts.VisibilityTimeout <= 0 {
+		return time.Minute
+	}
+	return q.opts.VisibilityTimeout / 3
+}
+
+// Bury 不可恢复的失败，跳过重试直接进入死信队列
+func (q *TaskQueue) Bury(ctx context.Context, task *Task, cause error) error {
+	return q.fail(ctx, task, cause, true)
+}
+
+// Retry 任务处理失败: 未超过最大次数时按指数退避放入重试区，否则转入死信队列
+// 返回 true 表示已进入死信队列
+func (q *TaskQueue) Retry(ctx context.Context, task *Task, cause error) (bool, error) {
+	dead := task.Attempts+1 >= q.opts.MaxAttempts
+	return dead, q.fail(ctx, task, cause, dead)
+}
+
+func (q *TaskQueue) fail(ctx context.Context, task *Task, cause error, dead bool) error {
+	attempts := task.Attempts + 1
+
+	pipe := q.rdb.TxPipeline()
+	if dead {
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: q.deadKey(),
+			Values: map[string]interface{}{
+				fieldPayload:  task.Payload,
+				fieldAttempts: attempts,
+				fieldError:    cause.Error(),
+				fieldFailedAt: time.Now().Format(time.RFC3339),
+			},
+		})
+	} else {
+		member, err := json.Marshal(delayedTask{Payload: task.Payload, Attempts: attempts, Error: cause.Error()})
+		if err != nil {
+			return err
+		}
+		retryAt := time.Now().Add(q.backoff(attempts))
+		pipe.ZAdd(ctx, q.delayedKey(), redis.Z{Score: float64(retryAt.UnixMilli()), Member: member})
+	}
+	pipe.XAck(ctx, q.name, queueGroup, task.ID)
+	pipe.XDel(ctx, q.name, task.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	if q.onFailure != nil {
+		q.onFailure(ctx, task, cause, dead)
+	}
+	return nil
+}
+
+// backoff 第 n 次失败后的等待时间: Base * 2^(n-1)，不超过 MaxBackoff
+func (q *TaskQueue) backoff(attempts int) time.Duration {
+	d := q.opts.BaseBackoff
+	for i := 1; i < attempts && d < q.opts.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, q.opts.MaxBackoff)
+}
+
+// promoteDelayedScript 把到期的重试任务从 ZSET 移到 Stream
+// KEYS: delayed ZSET, Stream; ARGV: 当前时间戳 (毫秒), 单次最多移动的条数, 三个消息字段名
+// 成员是 delayedTask 的 JSON，无法解析的直接丢弃
+var promoteDelayedScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, member in ipairs(due) do
+	local ok, t = pcall(cjson.decode, member)
+	if ok and type(t) == 'table' then
+		redis.call('XADD', KEYS[2], '*', ARGV[3], t.payload or '', ARGV[4], t.attempts or 0, ARGV[5], t.error or '')
+	end
+	redis.call('ZREM', KEYS[1], member)
+end
+return #due
+`)
+
+// promoteDelayed 把到期的重试任务放回主队列
+// 取出、写入 Stream、从 ZSET 删除在同一个 Lua 脚本里原子完成: 进程崩溃或 Redis 出错时任务要么还在 ZSET，
+// 要么已经进入 Stream，多个 Worker 并发执行也不会重复投递
+func (q *TaskQueue) promoteDelayed(ctx context.Context) error {
+	return promoteDelayedScript.Run(ctx, q.rdb,
+		[]string{q.delayedKey(), q.name},
+		time.Now().UnixMilli(), 100, fieldPayload, fieldAttempts, fieldError,
+	).Err()
+}
+
+// reclaimStuck 接管超过可见性超时仍未 ACK 的消息 (原 Worker 崩溃或卡死)
+// 接管本身计为一次失败，反复拖垮 Worker 的消息最终会进入死信队列
+func (q *TaskQueue) reclaimStuck(ctx context.Context, consumer string) error {
+	msgs, _, err := q.rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   q.name,
+		Group:    queueGroup,
+		Consumer: consumer,
+		MinIdle:  q.opts.VisibilityTimeout,
+		Start:    "0-0",
+		Count:    10,
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, msg := range msgs {
+		if _, err := q.Retry(ctx, taskFromMessage(msg), errVisibilityTimeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stats 队列深度、处理中、重试中、死信数量
+func (q *TaskQueue) Stats(ctx context.Context) (*QueueStats, error) {
+	stats := &QueueStats{Queue: q.name}
+
+	var err error
+	if stats.Depth, err = q.rdb.XLen(ctx, q.name).Result(); err != nil {
+		return nil, err
+	}
+	if stats.Delayed, err = q.rdb.ZCard(ctx, q.delayedKey()).Result(); err != nil {
+		return nil, err
+	}
+	if stats.Dead, err = q.rdb.XLen(ctx, q.deadKey()).Result(); err != nil {
+		return nil, err
+	}
+
+	groups, err := q.rdb.XInfoGroups(ctx, q.name).Result()
+	if err != nil && !errors.Is(err, redis.Nil) && !strings.Contains(err.Error(), "no such key") {
+		return nil, err
+	}
+	for _, g := range groups {
+		if g.Name != queueGroup {
+			continue
+		}
+		stats.Pending = g.Pending
+		stats.Lag = g.Lag
+		stats.Consumers = g.Consumers
+	}
+	return stats, nil
+}
+
+func taskFromMessage(msg redis.XMessage) *Task {
+	t := &Task{ID: msg.ID}
+	if v, ok := msg.Values[fieldPayload].(string); ok {
+		t.Payload = v
+	}
+	if v, ok := msg.Values[fieldAttempts].(string); ok {
+		t.Attempts, _ = strconv.Atoi(v)
+	}
+	return t
+}
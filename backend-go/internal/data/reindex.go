@@ -0,0 +1,372 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ---------------------------------------------------------
+// 蓝绿重建索引 (切换向量模型)
+// ---------------------------------------------------------
+// 流程: 新模型的集合在后台逐个文档重新向量化 (running) -> 校验切片数 (ready / verify_failed)
+// -> 管理员切换 (cut_over) -> 如有问题回滚 (rolled_back)。
+// 旧集合在切换后保留，回滚只是把检索别名指回去。
+// 任务完成后入库只写当前集合；切换前如果有文档在完成之后变动过，任务回到 running 补做，补完再切换。
+
+// 重建任务状态
+const (
+	ReindexPending      = "pending"
+	ReindexRunning      = "running"
+	ReindexReady        = "ready"         // 完成且切片数一致，可以切换
+	ReindexVerifyFailed = "verify_failed" // 完成但切片数不一致，需要确认后强制切换
+	ReindexFailed       = "failed"
+	ReindexCutOver      = "cut_over"
+	ReindexRolledBack   = "rolled_back"
+)
+
+// SearchAlias 检索别名，指向当前用于入库和检索的模型
+const SearchAlias = "search"
+
+var (
+	ErrReindexNotFound = errors.New("reindex task not found")
+	// ErrReindexConflict 已有进行中的任务，或当前状态不允许该操作
+	ErrReindexConflict = errors.New("reindex task conflict")
+	// ErrReindexStale 任务完成后有文档重新入库，新集合里是旧切片，需要补做后才能切换
+	ErrReindexStale = errors.New("reindex task stale")
+)
+
+// VectorAlias 别名当前指向的模型，所有进程以此为准
+type VectorAlias struct {
+	Name      string `gorm:"primaryKey;size:50" json:"name"`
+	Model     string `gorm:"size:100;not null" json:"model"`
+	UpdatedAt time.Time
+}
+
+// ReindexTask 一次重建索引
+type ReindexTask struct {
+	gorm.Model
+	SourceModel string `gorm:"size:100;not null" json:"source_model"`
+	TargetModel string `gorm:"size:100;not null" json:"target_model"`
+	Status      string `gorm:"size:20;index;not null" json:"status"`
+	JobID       string `gorm:"size:64" json:"job_id"`
+	CreatedBy   uint   `json:"created_by"`
+
+	// 进度: 按文档 ID 递增处理，Cursor 为最后处理完的文档，任务重试时从这里继续
+	Cursor             uint `json:"cursor"`
+	TotalDocuments     int  `json:"total_documents"`
+	ProcessedDocuments int  `json:"processed_documents"`
+	FailedDocuments    int  `json:"failed_documents"`
+
+	// 校验: 两个集合的切片数
+	SourcePoints uint64 `json:"source_points"`
+	TargetPoints uint64 `json:"target_points"`
+
+	ErrorMsg     string     `json:"error_msg"`
+	StartedAt    *time.Time `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+	CutOverAt    *time.Time `json:"cut_over_at"`
+	RolledBackAt *time.Time `json:"rolled_back_at"`
+
+	// CatchUpFrom 补做时为上次完成的时间，只需补这之后变动的文档；首次运行为空，按 StartedAt 补
+	CatchUpFrom *time.Time `json:"catch_up_from,omitempty"`
+}
+
+// Progress 完成百分比
+func (t *ReindexTask) Progress() int {
+	if t.TotalDocuments == 0 {
+		if t.FinishedAt != nil {
+			return 100
+		}
+		return 0
+	}
+	return min(100, t.ProcessedDocuments*100/t.TotalDocuments)
+}
+
+// aliasSwitcher 支持别名的向量存储 (Qdrant)，切换时一并原子地改指向
+type aliasSwitcher interface {
+	SwitchAlias(ctx context.Context, alias string) error
+}
+
+// liveModelTTL 进程内缓存别名的时间，切换后最多这么久所有进程都会生效
+const liveModelTTL = 5 * time.Second
+
+// liveModelCache 别名缓存
+type liveModelCache struct {
+	mu        sync.Mutex
+	model     string
+	expiresAt time.Time
+}
+
+// initVectorAlias 启动时初始化别名: 首次启动以 EMBEDDING_MODEL 为准，之后以数据库为准
+func (d *Data) initVectorAlias(ctx context.Context, defaultModel string) error {
+	alias := VectorAlias{Name: SearchAlias, Model: defaultModel}
+	if err := d.DB.WithContext(ctx).Where(VectorAlias{Name: SearchAlias}).FirstOrCreate(&alias).Error; err != nil {
+		return err
+	}
+	if _, ok := d.vectorStores[alias.Model]; !ok {
+		return fmt.Errorf("检索别名指向的模型 %s 不在 EMBEDDING_MODELS 中", alias.Model)
+	}
+	if alias.Model != defaultModel {
+		log.Printf("⚠️ 检索已切换到模型 %s (EMBEDDING_MODEL=%s 仅在首次启动时生效)", alias.Model, defaultModel)
+	}
+	return d.switchStoreAlias(ctx, alias.Model)
+}
+
+// LiveModel 当前用于入库和检索的模型
+func (d *Data) LiveModel(ctx context.Context) (string, error) {
+	d.live.mu.Lock()
+	defer d.live.mu.Unlock()
+	if d.live.model != "" && time.Now().Before(d.live.expiresAt) {
+		return d.live.model, nil
+	}
+
+	var alias VectorAlias
+	if err := d.DB.WithContext(ctx).First(&alias, "name = ?", SearchAlias).Error; err != nil {
+		return "", err
+	}
+	d.live.model = alias.Model
+	d.live.expiresAt = time.Now().Add(liveModelTTL)
+	return alias.Model, nil
+}
+
+// LiveVectors 当前模型及其向量集合
+// 同一次入库/检索应只解析一次，保证向量化用的模型与写入/检索的集合一致
+func (d *Data) LiveVectors(ctx context.Context) (string, VectorStore, error) {
+	model, err := d.LiveModel(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	store, ok := d.vectorStores[model]
+	if !ok {
+		return "", nil, fmt.Errorf("模型 %s 未注册", model)
+	}
+	return model, store, nil
+}
+
+// applyLiveModel 数据库里的别名已经提交后调用: 刷新本进程的缓存，并切换向量存储的别名
+// 存储别名只供外部工具使用，切换失败只记录日志，下次启动时 initVectorAlias 会按数据库重新对齐
+func (d *Data) applyLiveModel(ctx context.Context, model string) {
+	d.live.mu.Lock()
+	d.live.model = model
+	d.live.expiresAt = time.Now().Add(liveModelTTL)
+	d.live.mu.Unlock()
+
+	if err := d.switchStoreAlias(ctx, model); err != nil {
+		log.Printf("⚠️ 向量存储别名切换到 %s 失败 (检索已按数据库切换，重启后会重新对齐): %v", model, err)
+	}
+}
+
+// switchStoreAlias 后端支持别名时，让别名指向 model 的集合，供外部工具按固定名字访问
+func (d *Data) switchStoreAlias(ctx context.Context, model string) error {
+	if s, ok := d.vectorStores[model].(aliasSwitcher); ok {
+		return s.SwitchAlias(ctx, d.vectorAlias)
+	}
+	return nil
+}
+
+// CreateReindexTask 创建重建任务，同一时间只能有一个进行中的任务
+func (d *Data) CreateReindexTask(ctx context.Context, target string, userID uint) (*ReindexTask, error) {
+	if _, ok := d.vectorStores[target]; !ok {
+		return nil, fmt.Errorf("%w: 模型 %s 未注册", ErrReindexConflict, target)
+	}
+
+	var task *ReindexTask
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var alias VectorAlias
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&alias, "name = ?", SearchAlias).Error; err != nil {
+			return err
+		}
+		if alias.Model == target {
+			return fmt.Errorf("%w: 检索已在使用模型 %s", ErrReindexConflict, target)
+		}
+
+		var active int64
+		err := tx.Model(&ReindexTask{}).Where("status IN ?", []string{ReindexPending, ReindexRunning}).Count(&active).Error
+		if err != nil {
+			return err
+		}
+		if active > 0 {
+			return fmt.Errorf("%w: 已有进行中的重建任务", ErrReindexConflict)
+		}
+
+		task = &ReindexTask{
+			SourceModel: alias.Model,
+			TargetModel: target,
+			Status:      ReindexPending,
+			JobID:       uuid.New().String(),
+			CreatedBy:   userID,
+		}
+		return tx.Create(task).Error
+	})
+	return task, err
+}
+
+// GetReindexTask 查询重建任务
+func (d *Data) GetReindexTask(ctx context.Context, id uint) (*ReindexTask, error) {
+	var task ReindexTask
+	err := d.DB.WithContext(ctx).First(&task, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReindexNotFound
+	}
+	return &task, err
+}
+
+// ListReindexTasks 最近的重建任务
+func (d *Data) ListReindexTasks(ctx context.Context, limit int) ([]ReindexTask, error) {
+	var tasks []ReindexTask
+	err := d.DB.WithContext(ctx).Order("id DESC").Limit(limit).Find(&tasks).Error
+	return tasks, err
+}
+
+// UpdateReindexTask 更新任务进度等字段
+func (d *Data) UpdateReindexTask(ctx context.Context, id uint, fields map[string]any) error {
+	return d.DB.WithContext(ctx).Model(&ReindexTask{}).Where("id = ?", id).Updates(fields).Error
+}
+
+// CutOverReindex 将检索切换到重建好的新集合
+// verify_failed 的任务需要 force 才能切换；任务完成后有文档变动时返回 ErrReindexStale，需要先补做
+func (d *Data) CutOverReindex(ctx context.Context, id uint, force bool) (*ReindexTask, error) {
+	return d.moveReindex(ctx, id, func(tx *gorm.DB, task *ReindexTask, live string) (string, map[string]any, error) {
+		ok := task.Status == ReindexReady || (force && task.Status == ReindexVerifyFailed)
+		if !ok {
+			return "", nil, fmt.Errorf("%w: 状态为 %s 的任务不能切换", ErrReindexConflict, task.Status)
+		}
+		if live != task.SourceModel {
+			return "", nil, fmt.Errorf("%w: 检索已切换到模型 %s，该任务已过期", ErrReindexConflict, live)
+		}
+		if task.FinishedAt != nil {
+			var changed int64
+			err := tx.Model(&Document{}).Scopes(changedSince(*task.FinishedAt)).Count(&changed).Error
+			if err != nil {
+				return "", nil, err
+			}
+			if changed > 0 {
+				return "", nil, fmt.Errorf("%w: %d 个文档在重建完成后有变动", ErrReindexStale, changed)
+			}
+		}
+		return task.TargetModel, map[string]any{"status": ReindexCutOver, "cut_over_at": time.Now()}, nil
+	})
+}
+
+// ResumeReindex 已完成 (ready / verify_failed) 的任务回到 running，由 reembed 任务补做完成后变动的文档
+// 游标保持不变: 之后新建的文档从游标处继续，已处理过的文档只补上次完成 (catch_up_from) 之后的变动
+func (d *Data) ResumeReindex(ctx context.Context, id uint) (*ReindexTask, error) {
+	res := d.DB.WithContext(ctx).Model(&ReindexTask{}).
+		Where("id = ? AND status IN ?", id, []string{ReindexReady, ReindexVerifyFailed}).
+		Updates(map[string]any{
+			"status":        ReindexRunning,
+			"catch_up_from": gorm.Expr("finished_at"),
+			"finished_at":   nil,
+			"error_msg":     "",
+		})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: 只有已完成的任务可以补做", ErrReindexConflict)
+	}
+	return d.GetReindexTask(ctx, id)
+}
+
+// RollbackReindex 将检索切回旧集合 (旧集合在切换后一直保留)
+func (d *Data) RollbackReindex(ctx context.Context, id uint) (*ReindexTask, error) {
+	return d.moveReindex(ctx, id, func(_ *gorm.DB, task *ReindexTask, live string) (string, map[string]any, error) {
+		if task.Status != ReindexCutOver || live != task.TargetModel {
+			return "", nil, fmt.Errorf("%w: 只有最近一次已切换的任务可以回滚", ErrReindexConflict)
+		}
+		return task.SourceModel, map[string]any{"status": ReindexRolledBack, "rolled_back_at": time.Now()}, nil
+	})
+}
+
+// moveReindex 在事务内校验任务状态、修改别名并更新任务，事务提交后再切换本进程缓存和向量存储的别名，
+// 事务回滚时存储别名不会被改动
+// decide 拿到事务、任务和当前别名指向的模型，返回要切换到的模型和任务需要更新的字段
+func (d *Data) moveReindex(ctx context.Context, id uint, decide func(tx *gorm.DB, task *ReindexTask, live string) (string, map[string]any, error)) (*ReindexTask, error) {
+	var task ReindexTask
+	var model string
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var alias VectorAlias
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&alias, "name = ?", SearchAlias).Error; err != nil {
+			return err
+		}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&task, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrReindexNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var fields map[string]any
+		model, fields, err = decide(tx, &task, alias.Model)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&alias).Update("model", model).Error; err != nil {
+			return err
+		}
+		return tx.Model(&task).Updates(fields).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	d.applyLiveModel(ctx, model)
+	return &task, nil
+}
+
+// ReindexDocumentsAfter 按 ID 顺序取 cursor 之后已入库成功的文档 (含已被取代的旧版本)
+func (d *Data) ReindexDocumentsAfter(ctx context.Context, cursor uint, limit int) ([]Document, error) {
+	var docs []Document
+	err := d.DB.WithContext(ctx).
+		Where("id > ? AND status = ?", cursor, DocStatusSuccess).
+		Order("id").Limit(limit).
+		Find(&docs).Error
+	return docs, err
+}
+
+// CountReindexDocuments 需要重建的文档总数
+func (d *Data) CountReindexDocuments(ctx context.Context) (int, error) {
+	var n int64
+	err := d.DB.WithContext(ctx).Model(&Document{}).Where("status = ?", DocStatusSuccess).Count(&n).Error
+	return int(n), err
+}
+
+// changedSince 入库成功、且切片在 since 之后写入或修改过的文档 (重新入库等)
+// 只看切片表: 摘要、进度等文档字段的变化不影响向量；版本标记与删除会同时写所有集合，也不需要补做。
+// 切换前的过期检查与 reembed 的补做共用这一条件
+func changedSince(since time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("documents.status = ? AND documents.id IN (?)", DocStatusSuccess,
+			db.Session(&gorm.Session{NewDB: true}).Model(&Chunk{}).Select("document_id").Where("updated_at > ?", since))
+	}
+}
+
+// DocumentsChangedSince since 之后有变动、需要重新写入目标集合的文档，cursor 不为 0 时只取不超过 cursor 的
+func (d *Data) DocumentsChangedSince(ctx context.Context, since time.Time, cursor uint) ([]Document, error) {
+	q := d.DB.WithContext(ctx).Scopes(changedSince(since))
+	if cursor != 0 {
+		q = q.Where("documents.id <= ?", cursor)
+	}
+	var docs []Document
+	err := q.Order("documents.id").Find(&docs).Error
+	return docs, err
+}
+
+// DocumentsUpdatedSince since 之后重新入库成功的文档，cursor 不为 0 时只取不超过 cursor 的
+func (d *Data) DocumentsUpdatedSince(ctx context.Context, since time.Time, cursor uint) ([]Document, error) {
+	q := d.DB.WithContext(ctx).Where("status = ? AND updated_at > ?", DocStatusSuccess, since)
+	if cursor != 0 {
+		q = q.Where("id <= ?", cursor)
+	}
+	var docs []Document
+	err := q.Order("id").Find(&docs).Error
+	return docs, err
+}
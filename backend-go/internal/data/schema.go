@@ -1,6 +1,8 @@
 package data
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -14,16 +16,34 @@ type User struct {
 	PasswordHash string `gorm:"not null" json:"-"` // 密码不返给前端
 	Email        string `gorm:"size:100" json:"email"`
 	Avatar       string `json:"avatar"`
-	Role         string `gorm:"default:'user'" json:"role"` // admin, user
+	Role         string `gorm:"default:'user'" json:"role"` // admin (平台管理员), org_admin (组织管理员), user
 
-	// 组织隔离 (v0.2.0 预留)
+	// 所属组织 (租户)，0 为默认租户
 	OrganizationID uint `gorm:"index" json:"organization_id"`
 }
 
+// Organization 租户: 文档、知识库、用户组、向量都按组织隔离
 type Organization struct {
 	gorm.Model
 	Name string `gorm:"unique;size:100" json:"name"`
-	Key  string `gorm:"uniqueIndex;size:50" json:"key"` // 用于 MinIO bucket 隔离
+	Key  string `gorm:"uniqueIndex;size:50" json:"key"` // MinIO 对象前缀，见 TenantPrefix
+}
+
+// OrganizationInvite 组织邀请，按用户名或邮箱邀请 (至少填一个)，接受或注册时核对
+// 只保存 Token 的 SHA-256，明文只在创建时返回一次
+type OrganizationInvite struct {
+	ID             uint       `gorm:"primarykey" json:"id"`
+	OrganizationID uint       `gorm:"index;not null" json:"organization_id"`
+	TokenHash      string     `gorm:"uniqueIndex;size:64;not null" json:"-"`
+	Email          string     `gorm:"size:100" json:"email,omitempty"`
+	Username       string     `gorm:"size:50" json:"username,omitempty"`
+	Role           string     `gorm:"size:20;not null" json:"role"` // user, org_admin
+	InvitedBy      uint       `json:"invited_by"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	AcceptedBy     *uint      `json:"accepted_by,omitempty"`
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
 }
 
 // ---------------------------------------------------------
@@ -40,9 +60,10 @@ type KnowledgeBase struct {
 	ParentID *uint            `gorm:"index" json:"parent_id"`
 	Children []*KnowledgeBase `gorm:"foreignKey:ParentID" json:"children,omitempty"`
 
-	// 归属
-	OwnerID  uint `gorm:"index;not null" json:"owner_id"`
-	IsPublic bool `gorm:"default:false" json:"is_public"`
+	// 归属: 子文件夹与父节点属于同一租户，公开只在租户内有效
+	OwnerID        uint `gorm:"index;not null" json:"owner_id"`
+	OrganizationID uint `gorm:"index;not null;default:0" json:"organization_id"`
+	IsPublic       bool `gorm:"default:false" json:"is_public"`
 }
 
 // ---------------------------------------------------------
@@ -54,10 +75,13 @@ type Document struct {
 	Title    string `gorm:"index" json:"title"`
 	FileName string `json:"file_name"`
 	FileSize int64  `json:"file_size"`
-	FileType string `json:"file_type"` // .pdf, .docx
-	OwnerID  uint   `gorm:"index"`
+	FileType string `gorm:"index" json:"file_type"` // .pdf, .docx
+	OwnerID  uint   `gorm:"index" json:"owner_id"`
 
-	// 存储路径: minio://bucket/org_id/kb_id/uuid.pdf
+	// 所属租户 (上传时确定，上传者之后换组织也不变)
+	OrganizationID uint `gorm:"index;not null;default:0" json:"organization_id"`
+
+	// 存储路径 (MinIO 对象名): 租户前缀/uuid.pdf，见 TenantPrefix
 	StoragePath string `gorm:"not null" json:"storage_path"`
 
 	// 关联
@@ -66,8 +90,123 @@ type Document struct {
 	// 状态机: pending -> parsing -> embedding -> success / failed
 	Status   string `gorm:"default:'pending';index" json:"status"`
 	ErrorMsg string `json:"error_msg"`
+	Progress int    `gorm:"default:0" json:"progress"` // 0 ~ 100
 
 	// 版本控制: 记录用什么解析出来的
 	ParserType string `gorm:"default:'docling'" json:"parser_type"`
 	ChunkCount int    `json:"chunk_count"`
+
+	// 去重与版本: 同一知识库内内容相同 (SHA-256) 视为重复上传；
+	// 同名文件内容变化时生成新版本，旧版本仍可下载，但解析完成后不再参与检索
+	ContentHash       string `gorm:"size:64;index" json:"content_hash"`
+	Version           int    `gorm:"default:1" json:"version"`
+	IsLatest          bool   `gorm:"default:true;index" json:"is_latest"`
+	PreviousVersionID *uint  `gorm:"index" json:"previous_version_id"`
+
+	// 摘要 (summarize 任务生成)
+	Summary string `gorm:"type:text" json:"summary"`
+}
+
+// ---------------------------------------------------------
+// 4. 会话与消息
+// ---------------------------------------------------------
+
+type Conversation struct {
+	gorm.Model
+	SessionID string `gorm:"uniqueIndex;size:64;not null" json:"session_id"`
+	UserID    uint   `gorm:"index;not null" json:"user_id"`
+	Title     string `gorm:"size:200" json:"title"`
+
+	Messages []Message `gorm:"foreignKey:ConversationID" json:"messages,omitempty"`
+}
+
+type Message struct {
+	gorm.Model
+	ConversationID uint   `gorm:"index;not null" json:"conversation_id"`
+	Role           string `gorm:"size:20;not null" json:"role"` // user, assistant
+	Content        string `gorm:"type:text" json:"content"`
+
+	// user 消息: 结合历史改写后的独立检索问题
+	RewrittenQuery string `gorm:"type:text" json:"rewritten_query,omitempty"`
+
+	// assistant 消息: 检索来源 (Cited 标记回答中实际引用的) 与耗时
+	Sources      MessageSources `gorm:"type:text" json:"sources,omitempty"`
+	Error        string         `json:"error,omitempty"`
+	RetrievalMs  int64          `json:"retrieval_ms"`
+	GenerationMs int64          `json:"generation_ms"`
+	TotalMs      int64          `json:"total_ms"`
+}
+
+// ---------------------------------------------------------
+// 5. 切片 (文本以 Postgres 为准，向量存储只是它的索引)
+// ---------------------------------------------------------
+
+type Chunk struct {
+	// 与向量存储中的 Point ID 一致，见 ChunkPointID
+	ID         string `gorm:"primaryKey;size:36" json:"id"`
+	DocumentID uint   `gorm:"index:idx_chunks_document,priority:1;not null" json:"document_id"`
+	ChunkIndex int32  `gorm:"index:idx_chunks_document,priority:2" json:"chunk_index"`
+	PageNumber int32  `json:"page_number"`
+	FileName   string `json:"file_name"`
+	Content    string `gorm:"type:text" json:"content"`
+	TokenCount int    `json:"token_count"`
+	// 切片文本的 SHA-256，用于判断内容是否变化
+	ContentHash string `gorm:"size:64;index" json:"content_hash"`
+
+	// 入库时的文档版本与向量模型
+	DocumentVersion int    `json:"document_version"`
+	EmbeddingModel  string `gorm:"size:100;index" json:"embedding_model"`
+	// 写入该切片的入库批次，与向量 Payload 的 ingest_run 相同
+	IngestRun string `gorm:"size:100;index" json:"ingest_run"`
+	// 人工排除，不参与检索
+	Excluded bool `gorm:"default:false" json:"excluded"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ChunkAudit 切片人工修改的审计记录，只增不改
+type ChunkAudit struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+	DocumentID uint      `gorm:"index;not null" json:"document_id"`
+	UserID     uint      `gorm:"index;not null" json:"user_id"`
+	Action     string    `gorm:"size:20;not null" json:"action"` // edit, exclude, include, merge, split
+
+	// 涉及的切片 ID (逗号分隔)，以及修改前后的文本
+	ChunkIDs string `json:"chunk_ids"`
+	Before   string `gorm:"type:text" json:"before,omitempty"`
+	After    string `gorm:"type:text" json:"after,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// ---------------------------------------------------------
+// 6. 用户组与知识库授权 (ACL)
+// ---------------------------------------------------------
+
+// Group 用户组，属于某个租户，只能包含本租户的用户
+type Group struct {
+	gorm.Model
+	OrganizationID uint   `gorm:"uniqueIndex:idx_groups_org_name,priority:1;not null;default:0" json:"organization_id"`
+	Name           string `gorm:"uniqueIndex:idx_groups_org_name,priority:2;size:100;not null" json:"name"`
+	Description    string `json:"description"`
+}
+
+type GroupMember struct {
+	GroupID   uint      `gorm:"primaryKey" json:"group_id"`
+	UserID    uint      `gorm:"primaryKey;index" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// KnowledgeBaseGrant 把知识库 (含子文件夹) 的某个角色授予用户或用户组
+// 同一主体在同一知识库上只有一条记录，子文件夹继承父节点的授权，取最高角色
+type KnowledgeBaseGrant struct {
+	ID              uint      `gorm:"primarykey" json:"id"`
+	KnowledgeBaseID uint      `gorm:"uniqueIndex:idx_kb_grant_subject,priority:1;not null" json:"knowledge_base_id"`
+	SubjectType     string    `gorm:"uniqueIndex:idx_kb_grant_subject,priority:2;index:idx_kb_grant_lookup,priority:1;size:10;not null" json:"subject_type"` // user, group
+	SubjectID       uint      `gorm:"uniqueIndex:idx_kb_grant_subject,priority:3;index:idx_kb_grant_lookup,priority:2;not null" json:"subject_id"`
+	Role            string    `gorm:"size:20;not null" json:"role"` // viewer, editor, manager
+	GrantedBy       uint      `json:"granted_by"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
 }
@@ -2,8 +2,11 @@ package data
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"path"
 	"path/filepath"
 
 	"github.com/google/uuid"
@@ -14,28 +17,30 @@ import (
 // MinIO 相关操作 (Storage)
 // ---------------------------------------------------------
 
-// UploadFile 将文件流上传到 MinIO
-// 返回: 存储路径(objectName), 错误
-func (d *Data) UploadFile(ctx context.Context, file io.Reader, fileSize int64, originalFilename string) (string, error) {
-	// 1. 生成安全的文件名 (UUID + 原始后缀)
-	// 例如: "550e8400-e29b-41d4-a716-446655440000.pdf"
+// UploadFile 将文件流上传到 MinIO，边上传边计算 SHA-256 (不需要把文件读两遍)
+// prefix 为租户前缀 (见 TenantPrefix)，不同租户的文件在 bucket 里互不混放
+// 返回: 存储路径(objectName), 内容哈希(hex), 错误
+func (d *Data) UploadFile(ctx context.Context, file io.Reader, fileSize int64, originalFilename, prefix string) (string, string, error) {
+	// 1. 生成安全的文件名 (租户前缀 + UUID + 原始后缀)
+	// 例如: "acme/550e8400-e29b-41d4-a716-446655440000.pdf"
 	ext := filepath.Ext(originalFilename)
-	objectName := fmt.Sprintf("%s%s", uuid.New().String(), ext)
+	objectName := path.Join(prefix, fmt.Sprintf("%s%s", uuid.New().String(), ext))
 
 	// 桶名称建议从 Config 中读取，这里为演示先写死或作为参数
 	bucketName := "chimera-docs"
 
-	// 2. 执行上传
-	_, err := d.Minio.PutObject(ctx, bucketName, objectName, file, fileSize, minio.PutObjectOptions{
+	// 2. 执行上传，MinIO 读流的同时哈希也算好了
+	hasher := sha256.New()
+	_, err := d.Minio.PutObject(ctx, bucketName, objectName, io.TeeReader(file, hasher), fileSize, minio.PutObjectOptions{
 		ContentType: "application/octet-stream", // 自动检测或由上层传入
 	})
 	if err != nil {
-		return "", fmt.Errorf("minio put object error: %w", err)
+		return "", "", fmt.Errorf("minio put object error: %w", err)
 	}
 
 	// 返回存储路径 (bucket/objectName 或 纯 objectName，看需求)
 	// 这里返回 objectName，方便后续拼接 URL
-	return objectName, nil
+	return objectName, hex.EncodeToString(hasher.Sum(nil)), nil
 }
 
 // GetFileStream 从 MinIO 获取文件流
@@ -60,6 +65,15 @@ func (d *Data) GetFileStream(ctx context.Context, bucketName string, objectName
 	return object, info.Size, nil
 }
 
+// DeleteObject 删除 MinIO 对象，对象不存在时视为成功 (幂等)
+func (d *Data) DeleteObject(ctx context.Context, bucketName string, objectName string) error {
+	err := d.Minio.RemoveObject(ctx, bucketName, objectName, minio.RemoveObjectOptions{})
+	if err != nil && minio.ToErrorResponse(err).Code != "NoSuchKey" {
+		return fmt.Errorf("minio remove object error: %w", err)
+	}
+	return nil
+}
+
 // ---------------------------------------------------------
 // Postgres 相关操作 (DB) - v0.2.0 新增
 // ---------------------------------------------------------
@@ -68,3 +82,21 @@ func (d *Data) GetFileStream(ctx context.Context, bucketName string, objectName
 func (d *Data) CreateDocument(ctx context.Context, doc *Document) error {
 	return d.DB.WithContext(ctx).Create(doc).Error
 }
+
+// GetDocumentByStoragePath 根据 MinIO 对象名查找文档记录
+func (d *Data) GetDocumentByStoragePath(ctx context.Context, storagePath string) (*Document, error) {
+	var doc Document
+	if err := d.DB.WithContext(ctx).Where("storage_path = ?", storagePath).First(&doc).Error; err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// GetUser 根据 ID 查找用户
+func (d *Data) GetUser(ctx context.Context, userID uint) (*User, error) {
+	var user User
+	if err := d.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
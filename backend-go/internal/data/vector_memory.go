@@ -0,0 +1,182 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"Chimera-RAG/backend-go/internal/conf"
+)
+
+// memoryStore 进程内向量存储，逐条计算相似度
+// 不依赖任何外部服务，适合本地开发和单元测试；数据量大时请用 Qdrant
+type memoryStore struct {
+	mu       sync.RWMutex
+	size     uint64
+	distance string
+	points   map[string]*memoryPoint
+}
+
+type memoryPoint struct {
+	vector []float32
+	norm   float64
+	chunk  LexicalChunk
+}
+
+// NewMemoryVectorStore size 为向量维度 (0 表示不校验)，distance 见 conf.Distance*，为空时按余弦
+func NewMemoryVectorStore(size uint64, distance string) VectorStore {
+	if distance == "" {
+		distance = conf.DistanceCosine
+	}
+	return &memoryStore{size: size, distance: distance, points: make(map[string]*memoryPoint)}
+}
+
+func (s *memoryStore) EnsureCollection(ctx context.Context) error {
+	return nil
+}
+
+func (s *memoryStore) Upsert(ctx context.Context, points []VectorPoint) error {
+	for _, p := range points {
+		if s.size > 0 && uint64(len(p.Vector)) != s.size {
+			return fmt.Errorf("向量维度不匹配: 期望 %d，实际 %d", s.size, len(p.Vector))
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range points {
+		vector := make([]float32, len(p.Vector))
+		copy(vector, p.Vector)
+		s.points[p.Chunk.ID] = &memoryPoint{vector: vector, norm: vectorNorm(vector), chunk: p.Chunk}
+	}
+	return nil
+}
+
+func (s *memoryStore) Search(ctx context.Context, vector []float32, topK uint64, filter *SearchFilter) ([]SearchResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	qNorm := vectorNorm(vector)
+	results := make([]SearchResult, 0)
+	for _, p := range s.points {
+		if !filter.matches(p.chunk) || len(p.vector) != len(vector) {
+			continue
+		}
+		results = append(results, p.chunk.toResult(s.score(vector, qNorm, p)))
+	}
+	// 与 Qdrant 一致: 欧氏距离越小越相似，其余越大越相似
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			if s.distance == conf.DistanceEuclid {
+				return results[i].Score < results[j].Score
+			}
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	if uint64(len(results)) > topK {
+		results = results[:topK]
+	}
+	return results, nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, filter *SearchFilter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, p := range s.points {
+		if filter.matches(p.chunk) {
+			delete(s.points, id)
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) Count(ctx context.Context, filter *SearchFilter) (uint64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var n uint64
+	for _, p := range s.points {
+		if filter.matches(p.chunk) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *memoryStore) SetPayload(ctx context.Context, filter *SearchFilter, patch PayloadPatch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.points {
+		if !filter.matches(p.chunk) {
+			continue
+		}
+		if patch.IsLatest != nil {
+			p.chunk.Superseded = !*patch.IsLatest
+		}
+		if patch.Excluded != nil {
+			p.chunk.Excluded = *patch.Excluded
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) Scroll(ctx context.Context, filter *SearchFilter, fn func(chunks []LexicalChunk) error) error {
+	s.mu.RLock()
+	chunks := make([]LexicalChunk, 0, len(s.points))
+	for _, p := range s.points {
+		if filter.matches(p.chunk) {
+			chunks = append(chunks, p.chunk)
+		}
+	}
+	s.mu.RUnlock()
+
+	if len(chunks) == 0 {
+		return nil
+	}
+	return fn(chunks)
+}
+
+func (s *memoryStore) Close() error {
+	return nil
+}
+
+func (s *memoryStore) score(q []float32, qNorm float64, p *memoryPoint) float32 {
+	switch s.distance {
+	case conf.DistanceDot:
+		return float32(dot(q, p.vector))
+	case conf.DistanceEuclid:
+		var sum float64
+		for i := range q {
+			d := float64(q[i]) - float64(p.vector[i])
+			sum += d * d
+		}
+		return float32(math.Sqrt(sum))
+	default:
+		return cosine(q, qNorm, p.vector, p.norm)
+	}
+}
+
+func vectorNorm(v []float32) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	return math.Sqrt(sum)
+}
+
+func cosine(a []float32, aNorm float64, b []float32, bNorm float64) float32 {
+	if aNorm == 0 || bNorm == 0 {
+		return 0
+	}
+	return float32(dot(a, b) / (aNorm * bNorm))
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
@@ -0,0 +1,67 @@
+package data
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestMemoryStoreSearch(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryVectorStore(3, "")
+	err := store.Upsert(ctx, []VectorPoint{
+		{Vector: []float32{1, 0, 0}, Chunk: LexicalChunk{ID: "x", KnowledgeBaseID: 1}},
+		{Vector: []float32{0.8, 0.6, 0}, Chunk: LexicalChunk{ID: "xy", KnowledgeBaseID: 1}},
+		{Vector: []float32{0, 1, 0}, Chunk: LexicalChunk{ID: "y", KnowledgeBaseID: 2}},
+		{Vector: []float32{0, 0, 1}, Chunk: LexicalChunk{ID: "z", KnowledgeBaseID: 2}},
+		{Vector: []float32{-1, 0, 0}, Chunk: LexicalChunk{ID: "-x", KnowledgeBaseID: 1}},
+	})
+	if err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		vector []float32
+		topK   uint64
+		filter *SearchFilter
+		want   []string
+	}{
+		{"按余弦相似度降序", []float32{1, 0, 0}, 10, nil, []string{"x", "xy", "y", "z", "-x"}},
+		{"与长度无关", []float32{5, 0, 0}, 2, nil, []string{"x", "xy"}},
+		{"分数相同按 ID 排序", []float32{0, 1, 1}, 2, nil, []string{"y", "z"}},
+		{"TopK 截断", []float32{0, 1, 0}, 1, nil, []string{"y"}},
+		{"先过滤再排序", []float32{0, 1, 0}, 10, &SearchFilter{KnowledgeBaseIDs: []uint{1}}, []string{"xy", "-x", "x"}},
+		{"零向量得分为 0，按 ID 排序", []float32{0, 0, 0}, 10, nil, []string{"-x", "x", "xy", "y", "z"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := store.Search(ctx, tt.vector, tt.topK, tt.filter)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			got := make([]string, len(results))
+			for i, r := range results {
+				got[i] = r.ID
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreUpsertDimension(t *testing.T) {
+	store := NewMemoryVectorStore(3, "")
+	err := store.Upsert(context.Background(), []VectorPoint{
+		{Vector: []float32{1, 0, 0}, Chunk: LexicalChunk{ID: "ok"}},
+		{Vector: []float32{1, 0}, Chunk: LexicalChunk{ID: "short"}},
+	})
+	if err == nil {
+		t.Fatal("维度不一致时应当报错")
+	}
+	// 整批校验通过后才写入
+	if n, _ := store.Count(context.Background(), nil); n != 0 {
+		t.Errorf("Count = %d, want 0", n)
+	}
+}
@@ -0,0 +1,389 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"Chimera-RAG/backend-go/internal/conf"
+
+	"gorm.io/gorm"
+)
+
+// ---------------------------------------------------------
+// pgvector 向量存储
+// 小规模部署可以不跑 Qdrant，切片向量直接存在 Postgres 里。
+// 切片表只存切片自身的字段，文档级元数据 (知识库、归属、版本) 检索时 JOIN documents 得到
+// ---------------------------------------------------------
+
+// pgvector 近似索引类型
+const (
+	PgVectorIndexHNSW    = "hnsw"
+	PgVectorIndexIVFFlat = "ivfflat"
+)
+
+// pgTableName 表名直接拼进 SQL，只允许小写标识符
+var pgTableName = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// pgDistance 各距离对应的 pgvector 运算符、索引操作符类和分数表达式
+// 分数与 Qdrant 保持一致: 余弦为相似度，点积为内积，欧氏为距离
+type pgDistance struct {
+	op      string
+	opClass string
+	score   string
+}
+
+var pgDistances = map[string]pgDistance{
+	conf.DistanceCosine: {op: "<=>", opClass: "vector_cosine_ops", score: "1 - (c.embedding <=> ?::vector)"},
+	conf.DistanceDot:    {op: "<#>", opClass: "vector_ip_ops", score: "-(c.embedding <#> ?::vector)"},
+	conf.DistanceEuclid: {op: "<->", opClass: "vector_l2_ops", score: "c.embedding <-> ?::vector"},
+}
+
+// pgChunkColumns 还原 LexicalChunk 需要的列
+const pgChunkColumns = `c.id, c.content, c.file_name, c.page_number, c.chunk_index, c.ingest_run, c.excluded,
+	c.document_id, d.title, d.knowledge_base_id, d.owner_id, d.organization_id,
+	d.file_type, NOT d.is_latest AS superseded`
+
+// pgvectorStore 每个模型一张表，表名即集合名
+type pgvectorStore struct {
+	db        *gorm.DB
+	table     string
+	model     conf.EmbeddingModel
+	distance  pgDistance
+	indexType string
+}
+
+func newPgvectorStore(db *gorm.DB, table string, model conf.EmbeddingModel, indexType string) (*pgvectorStore, error) {
+	if !pgTableName.MatchString(table) {
+		return nil, fmt.Errorf("无效的 pgvector 表名: %q", table)
+	}
+	switch indexType {
+	case "":
+		indexType = PgVectorIndexHNSW
+	case PgVectorIndexHNSW, PgVectorIndexIVFFlat:
+	default:
+		return nil, fmt.Errorf("未知的 pgvector 索引类型: %s", indexType)
+	}
+	return &pgvectorStore{db: db, table: table, model: model, distance: pgDistances[model.Distance], indexType: indexType}, nil
+}
+
+// from 检索用的 FROM 子句，文档的知识库、上传者、租户 (documents.organization_id) 等过滤字段都取自 documents 表
+func (s *pgvectorStore) from() string {
+	return s.table + ` c
+	JOIN documents d ON d.id = c.document_id`
+}
+
+// EnsureCollection 建扩展、建表、建索引，全部是幂等的；表已存在时校验维度
+func (s *pgvectorStore) EnsureCollection(ctx context.Context) error {
+	db := s.db.WithContext(ctx)
+	if err := db.Exec(`CREATE EXTENSION IF NOT EXISTS vector`).Error; err != nil {
+		return err
+	}
+	if err := s.checkTable(ctx); err != nil {
+		return err
+	}
+
+	stmts := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id          uuid PRIMARY KEY,
+			document_id bigint NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+			chunk_index integer NOT NULL DEFAULT 0,
+			page_number integer NOT NULL DEFAULT 0,
+			file_name   text NOT NULL DEFAULT '',
+			content     text NOT NULL DEFAULT '',
+			ingest_run  text NOT NULL DEFAULT '',
+			excluded    boolean NOT NULL DEFAULT false,
+			embedding   vector(%d) NOT NULL
+		)`, s.table, s.model.Dimension),
+		// 老表补列
+		fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS excluded boolean NOT NULL DEFAULT false`, s.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_document ON %s (document_id, chunk_index)`, s.table, s.table),
+	}
+	switch s.indexType {
+	case PgVectorIndexHNSW:
+		stmts = append(stmts, fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_embedding_hnsw ON %s USING hnsw (embedding %s)`, s.table, s.table, s.distance.opClass))
+	case PgVectorIndexIVFFlat:
+		// IVFFlat 在空表上建索引效果很差，数据量上来后建议 REINDEX
+		stmts = append(stmts, fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_embedding_ivfflat ON %s USING ivfflat (embedding %s) WITH (lists = 100)`, s.table, s.table, s.distance.opClass))
+	}
+
+	for _, stmt := range stmts {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	log.Printf("🎉 pgvector 就绪 (表 '%s'，模型 %s, %d 维，%s 索引)", s.table, s.model.Name, s.model.Dimension, s.indexType)
+	return nil
+}
+
+// checkTable 表已存在时，embedding 列的维度必须与模型配置一致
+func (s *pgvectorStore) checkTable(ctx context.Context) error {
+	var columnType string
+	err := s.db.WithContext(ctx).Raw(`SELECT format_type(atttypid, atttypmod) FROM pg_attribute
+		WHERE attrelid = to_regclass(?) AND attname = 'embedding' AND NOT attisdropped`, s.table).
+		Scan(&columnType).Error
+	if err != nil {
+		return err
+	}
+	if columnType == "" {
+		return nil // 表还不存在
+	}
+	if want := fmt.Sprintf("vector(%d)", s.model.Dimension); columnType != want {
+		return fmt.Errorf("%w: 表 '%s' 的向量列为 %s，模型 %s 配置为 %s；请为新模型指定新的集合，或通过重建索引迁移",
+			ErrDimensionMismatch, s.table, columnType, s.model.Name, want)
+	}
+	return nil
+}
+
+func (s *pgvectorStore) Upsert(ctx context.Context, points []VectorPoint) error {
+	if len(points) == 0 {
+		return nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`INSERT INTO ` + s.table + ` (id, document_id, chunk_index, page_number, file_name, content, ingest_run, excluded, embedding) VALUES `)
+	args := make([]any, 0, len(points)*9)
+	for i, p := range points {
+		if uint64(len(p.Vector)) != s.model.Dimension {
+			return fmt.Errorf("向量维度不匹配: 期望 %d，实际 %d", s.model.Dimension, len(p.Vector))
+		}
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(?, ?, ?, ?, ?, ?, ?, ?, ?::vector)")
+		c := p.Chunk
+		args = append(args, c.ID, c.DocumentID, c.ChunkIndex, c.Page, c.FileName, c.Content, c.IngestRun, c.Excluded, vectorLiteral(p.Vector))
+	}
+	sb.WriteString(` ON CONFLICT (id) DO UPDATE SET
+		document_id = EXCLUDED.document_id,
+		chunk_index = EXCLUDED.chunk_index,
+		page_number = EXCLUDED.page_number,
+		file_name   = EXCLUDED.file_name,
+		content     = EXCLUDED.content,
+		ingest_run  = EXCLUDED.ingest_run,
+		excluded    = EXCLUDED.excluded,
+		embedding   = EXCLUDED.embedding`)
+
+	return s.db.WithContext(ctx).Exec(sb.String(), args...).Error
+}
+
+// Search 按模型的距离运算符排序，已软删除的文档不参与检索
+func (s *pgvectorStore) Search(ctx context.Context, vector []float32, topK uint64, filter *SearchFilter) ([]SearchResult, error) {
+	where, args := pgConditions(filter)
+	where = append(where, "d.deleted_at IS NULL")
+	literal := vectorLiteral(vector)
+
+	query := `SELECT ` + pgChunkColumns + `, ` + s.distance.score + ` AS score
+		FROM ` + s.from() + `
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY c.embedding ` + s.distance.op + ` ?::vector
+		LIMIT ?`
+	args = append([]any{literal}, args...)
+	args = append(args, literal, topK)
+
+	var rows []pgChunkRow
+	if err := s.db.WithContext(ctx).Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, 0, len(rows))
+	for _, r := range rows {
+		results = append(results, r.chunk().toResult(r.Score))
+	}
+	return results, nil
+}
+
+func (s *pgvectorStore) Delete(ctx context.Context, filter *SearchFilter) error {
+	where, args := pgConditions(filter)
+	query := `DELETE FROM ` + s.table + ` WHERE id IN (
+		SELECT c.id FROM ` + s.from() + ` WHERE ` + strings.Join(where, " AND ") + `)`
+	return s.db.WithContext(ctx).Exec(query, args...).Error
+}
+
+func (s *pgvectorStore) Count(ctx context.Context, filter *SearchFilter) (uint64, error) {
+	where, args := pgConditions(filter)
+	var n uint64
+	err := s.db.WithContext(ctx).
+		Raw(`SELECT COUNT(*) FROM `+s.from()+` WHERE `+strings.Join(where, " AND "), args...).
+		Scan(&n).Error
+	return n, err
+}
+
+// SetPayload 版本标记存在 documents 表上，这里改的是命中切片所属文档；排除标记在切片表自身
+func (s *pgvectorStore) SetPayload(ctx context.Context, filter *SearchFilter, patch PayloadPatch) error {
+	where, args := pgConditions(filter)
+	db := s.db.WithContext(ctx)
+	if patch.IsLatest != nil {
+		query := `UPDATE documents SET is_latest = ? WHERE id IN (
+			SELECT DISTINCT c.document_id FROM ` + s.from() + ` WHERE ` + strings.Join(where, " AND ") + `)`
+		if err := db.Exec(query, append([]any{*patch.IsLatest}, args...)...).Error; err != nil {
+			return err
+		}
+	}
+	if patch.Excluded != nil {
+		query := `UPDATE ` + s.table + ` SET excluded = ? WHERE id IN (
+			SELECT c.id FROM ` + s.from() + ` WHERE ` + strings.Join(where, " AND ") + `)`
+		if err := db.Exec(query, append([]any{*patch.Excluded}, args...)...).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Scroll 按主键翻页
+func (s *pgvectorStore) Scroll(ctx context.Context, filter *SearchFilter, fn func(chunks []LexicalChunk) error) error {
+	const limit = 256
+	where, args := pgConditions(filter)
+	after := ""
+
+	for {
+		query := `SELECT ` + pgChunkColumns + ` FROM ` + s.from() + `
+			WHERE ` + strings.Join(where, " AND ") + ` AND c.id::text > ?
+			ORDER BY c.id::text
+			LIMIT ?`
+		var rows []pgChunkRow
+		if err := s.db.WithContext(ctx).Raw(query, append(args, after, limit)...).Scan(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		chunks := make([]LexicalChunk, 0, len(rows))
+		for _, r := range rows {
+			chunks = append(chunks, r.chunk())
+		}
+		if err := fn(chunks); err != nil {
+			return err
+		}
+
+		if len(rows) < limit {
+			return nil
+		}
+		after = rows[len(rows)-1].ID
+	}
+}
+
+// Close 连接由 Data 统一关闭
+func (s *pgvectorStore) Close() error {
+	return nil
+}
+
+// pgChunkRow 查询结果行
+type pgChunkRow struct {
+	ID              string
+	Content         string
+	FileName        string
+	PageNumber      int32
+	ChunkIndex      int32
+	IngestRun       string
+	Excluded        bool
+	DocumentID      uint
+	Title           string
+	KnowledgeBaseID uint
+	OwnerID         uint
+	OrganizationID  uint
+	FileType        string
+	Superseded      bool
+	Score           float32
+}
+
+func (r pgChunkRow) chunk() LexicalChunk {
+	return LexicalChunk{
+		ID:              r.ID,
+		Content:         r.Content,
+		FileName:        r.FileName,
+		Title:           r.Title,
+		Page:            r.PageNumber,
+		ChunkIndex:      r.ChunkIndex,
+		DocumentID:      r.DocumentID,
+		KnowledgeBaseID: r.KnowledgeBaseID,
+		OwnerID:         r.OwnerID,
+		OrganizationID:  r.OrganizationID,
+		FileType:        r.FileType,
+		Superseded:      r.Superseded,
+		IngestRun:       r.IngestRun,
+		Excluded:        r.Excluded,
+	}
+}
+
+// pgConditions 将过滤条件翻译为 SQL (基于 from() 的别名)，语义与 toQdrant / matches 保持一致
+func pgConditions(f *SearchFilter) ([]string, []any) {
+	where := []string{"TRUE"}
+	var args []any
+	if f == nil {
+		return where, args
+	}
+
+	add := func(cond string, arg ...any) {
+		where = append(where, cond)
+		args = append(args, arg...)
+	}
+	if len(f.KnowledgeBaseIDs) > 0 {
+		add("d.knowledge_base_id IN ?", f.KnowledgeBaseIDs)
+	}
+	if len(f.DocumentIDs) > 0 {
+		add("c.document_id IN ?", f.DocumentIDs)
+	}
+	if len(f.ChunkIDs) > 0 {
+		add("c.id::text IN ?", f.ChunkIDs)
+	}
+	if len(f.OwnerIDs) > 0 {
+		add("d.owner_id IN ?", f.OwnerIDs)
+	}
+	if len(f.OrganizationIDs) > 0 {
+		add("d.organization_id IN ?", f.OrganizationIDs)
+	}
+	if len(f.FileTypes) > 0 {
+		add("d.file_type IN ?", f.FileTypes)
+	}
+	if len(f.FileNames) > 0 {
+		add("d.title IN ?", f.FileNames)
+	}
+	if f.PageFrom > 0 {
+		add("c.page_number >= ?", f.PageFrom)
+	}
+	if f.PageTo > 0 {
+		add("c.page_number <= ?", f.PageTo)
+	}
+	if s := f.Access; s != nil {
+		if !s.AllTenants {
+			add("d.organization_id = ?", s.OrganizationID)
+		}
+		if !s.All {
+			cond := "(d.owner_id = ?"
+			scopeArgs := []any{s.OwnerID}
+			if len(s.KnowledgeBaseIDs) > 0 {
+				cond += " OR d.knowledge_base_id IN ?"
+				scopeArgs = append(scopeArgs, s.KnowledgeBaseIDs)
+			}
+			add(cond+")", scopeArgs...)
+		}
+	}
+	if !f.IncludeSuperseded {
+		add("d.is_latest")
+	}
+	if !f.IncludeExcluded {
+		add("NOT c.excluded")
+	}
+	if f.ExceptIngestRun != "" {
+		add("c.ingest_run <> ?", f.ExceptIngestRun)
+	}
+	return where, args
+}
+
+// vectorLiteral 转换为 pgvector 的文本格式 [1,2,3]
+func vectorLiteral(v []float32) string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i, x := range v {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.FormatFloat(float64(x), 'f', -1, 32))
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
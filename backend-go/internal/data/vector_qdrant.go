@@ -0,0 +1,313 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"slices"
+	"strconv"
+
+	"Chimera-RAG/backend-go/internal/conf"
+
+	// Qdrant 官方 Go SDK
+	"github.com/qdrant/go-client/qdrant"
+)
+
+// qdrantStore 基于 Qdrant 的向量存储
+type qdrantStore struct {
+	client     *qdrant.Client
+	collection string
+	model      conf.EmbeddingModel
+}
+
+// qdrantDistances 配置中的距离名到 Qdrant 枚举
+var qdrantDistances = map[string]qdrant.Distance{
+	conf.DistanceCosine: qdrant.Distance_Cosine,
+	conf.DistanceDot:    qdrant.Distance_Dot,
+	conf.DistanceEuclid: qdrant.Distance_Euclid,
+}
+
+// newQdrantStore addr 形如 host:port (gRPC 端口)
+func newQdrantStore(addr, collection string, model conf.EmbeddingModel) (*qdrantStore, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 Qdrant 地址 %q: %w", addr, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 Qdrant 端口 %q: %w", portStr, err)
+	}
+
+	client, err := qdrant.NewClient(&qdrant.Config{Host: host, Port: port})
+	if err != nil {
+		return nil, err
+	}
+	return &qdrantStore{client: client, collection: collection, model: model}, nil
+}
+
+func (s *qdrantStore) EnsureCollection(ctx context.Context) error {
+	// ⚠️ 不调用 Health()，直接通过列出集合来验证连接
+	// 这样兼容性最好，不会因为 SDK 版本变动报错
+	collections, err := s.client.ListCollections(ctx)
+	if err != nil {
+		return fmt.Errorf("无法连接 Qdrant (ListCollections 失败): %w", err)
+	}
+
+	if !slices.Contains(collections, s.collection) {
+		err := s.client.CreateCollection(ctx, &qdrant.CreateCollection{
+			CollectionName: s.collection,
+			VectorsConfig: qdrant.NewVectorsConfig(&qdrant.VectorParams{
+				Size:     s.model.Dimension,
+				Distance: qdrantDistances[s.model.Distance],
+			}),
+		})
+		if err != nil {
+			return fmt.Errorf("创建 Collection 失败: %w", err)
+		}
+		log.Printf("🎉 Qdrant Collection '%s' 创建成功 (模型 %s, %d 维)", s.collection, s.model.Name, s.model.Dimension)
+	} else {
+		if err := s.checkCollection(ctx); err != nil {
+			return err
+		}
+		log.Printf("🎉 Qdrant 连接成功 (Collection '%s' 已存在)", s.collection)
+	}
+
+	// 为过滤字段建立 Payload 索引 (重复创建是幂等的，老集合也会补上)
+	for field, fieldType := range payloadIndexes {
+		_, err := s.client.CreateFieldIndex(ctx, &qdrant.CreateFieldIndexCollection{
+			CollectionName: s.collection,
+			FieldName:      field,
+			FieldType:      qdrant.PtrOf(fieldType),
+			Wait:           qdrant.PtrOf(true),
+		})
+		if err != nil {
+			log.Printf("⚠️ 创建 Payload 索引 %s 失败: %v", field, err)
+		}
+	}
+	return nil
+}
+
+// checkCollection 已有集合的维度、距离必须与模型配置一致
+func (s *qdrantStore) checkCollection(ctx context.Context) error {
+	info, err := s.client.GetCollectionInfo(ctx, s.collection)
+	if err != nil {
+		return fmt.Errorf("读取 Collection '%s' 信息失败: %w", s.collection, err)
+	}
+	params := info.GetConfig().GetParams().GetVectorsConfig().GetParams()
+	if params == nil {
+		return fmt.Errorf("%w: Collection '%s' 使用了命名向量，请为模型 %s 指定新的集合", ErrDimensionMismatch, s.collection, s.model.Name)
+	}
+	if params.GetSize() != s.model.Dimension || params.GetDistance() != qdrantDistances[s.model.Distance] {
+		return fmt.Errorf("%w: Collection '%s' 为 %d 维 %s，模型 %s 配置为 %d 维 %s；请为新模型指定新的集合，或通过重建索引迁移",
+			ErrDimensionMismatch, s.collection, params.GetSize(), params.GetDistance(), s.model.Name, s.model.Dimension, s.model.Distance)
+	}
+	return nil
+}
+
+// SwitchAlias 让 alias 指向本集合，删旧建新在同一个请求里完成，检索不会看到中间状态
+func (s *qdrantStore) SwitchAlias(ctx context.Context, alias string) error {
+	aliases, err := s.client.ListAliases(ctx)
+	if err != nil {
+		return err
+	}
+
+	var actions []*qdrant.AliasOperations
+	for _, a := range aliases {
+		if a.GetAliasName() != alias {
+			continue
+		}
+		if a.GetCollectionName() == s.collection {
+			return nil
+		}
+		actions = append(actions, qdrant.NewAliasDelete(alias))
+	}
+	actions = append(actions, qdrant.NewAliasCreate(alias, s.collection))
+	if err := s.client.UpdateAliases(ctx, actions); err != nil {
+		return err
+	}
+	log.Printf("🔀 Qdrant 别名 '%s' -> '%s'", alias, s.collection)
+	return nil
+}
+
+func (s *qdrantStore) Upsert(ctx context.Context, points []VectorPoint) error {
+	if len(points) == 0 {
+		return nil
+	}
+	structs := make([]*qdrant.PointStruct, 0, len(points))
+	for _, p := range points {
+		structs = append(structs, &qdrant.PointStruct{
+			Id:      qdrant.NewIDUUID(p.Chunk.ID),
+			Vectors: qdrant.NewVectors(p.Vector...),
+			Payload: qdrant.NewValueMap(chunkPayload(p.Chunk)),
+		})
+	}
+	_, err := s.client.Upsert(ctx, &qdrant.UpsertPoints{
+		CollectionName: s.collection,
+		Points:         structs,
+		Wait:           qdrant.PtrOf(true),
+	})
+	return err
+}
+
+// Search 使用 Query API (这是 Qdrant 的新标准)
+func (s *qdrantStore) Search(ctx context.Context, vector []float32, topK uint64, filter *SearchFilter) ([]SearchResult, error) {
+	points, err := s.client.Query(ctx, &qdrant.QueryPoints{
+		CollectionName: s.collection,
+		Query:          qdrant.NewQuery(vector...),
+		Limit:          &topK,
+		Filter:         filter.toQdrant(),
+		WithPayload:    qdrant.NewWithPayload(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, len(points))
+	for _, point := range points {
+		results = append(results, chunkFromPayload(point.Id, point.Payload).toResult(point.Score))
+	}
+	return results, nil
+}
+
+func (s *qdrantStore) Delete(ctx context.Context, filter *SearchFilter) error {
+	_, err := s.client.Delete(ctx, &qdrant.DeletePoints{
+		CollectionName: s.collection,
+		Wait:           qdrant.PtrOf(true),
+		Points:         qdrant.NewPointsSelectorFilter(filterOrAll(filter)),
+	})
+	return err
+}
+
+func (s *qdrantStore) Count(ctx context.Context, filter *SearchFilter) (uint64, error) {
+	return s.client.Count(ctx, &qdrant.CountPoints{
+		CollectionName: s.collection,
+		Filter:         filter.toQdrant(),
+		Exact:          qdrant.PtrOf(true),
+	})
+}
+
+func (s *qdrantStore) SetPayload(ctx context.Context, filter *SearchFilter, patch PayloadPatch) error {
+	payload := map[string]any{}
+	if patch.IsLatest != nil {
+		payload[PayloadIsLatest] = *patch.IsLatest
+	}
+	if patch.Excluded != nil {
+		payload[PayloadExcluded] = *patch.Excluded
+	}
+	if len(payload) == 0 {
+		return nil
+	}
+	_, err := s.client.SetPayload(ctx, &qdrant.SetPayloadPoints{
+		CollectionName: s.collection,
+		Wait:           qdrant.PtrOf(true),
+		Payload:        qdrant.NewValueMap(payload),
+		PointsSelector: qdrant.NewPointsSelectorFilter(filterOrAll(filter)),
+	})
+	return err
+}
+
+func (s *qdrantStore) Scroll(ctx context.Context, filter *SearchFilter, fn func(chunks []LexicalChunk) error) error {
+	var offset *qdrant.PointId
+	limit := uint32(256)
+
+	for {
+		points, next, err := s.client.ScrollAndOffset(ctx, &qdrant.ScrollPoints{
+			CollectionName: s.collection,
+			Filter:         filter.toQdrant(),
+			Limit:          &limit,
+			Offset:         offset,
+			WithPayload:    qdrant.NewWithPayload(true),
+		})
+		if err != nil {
+			return err
+		}
+
+		chunks := make([]LexicalChunk, 0, len(points))
+		for _, p := range points {
+			chunks = append(chunks, chunkFromPayload(p.Id, p.Payload))
+		}
+		if err := fn(chunks); err != nil {
+			return err
+		}
+
+		if next == nil {
+			return nil
+		}
+		offset = next
+	}
+}
+
+func (s *qdrantStore) Close() error {
+	return s.client.Close()
+}
+
+// filterOrAll 删除、改 Payload 必须带 Filter，没有条件时匹配全部 Point
+func filterOrAll(f *SearchFilter) *qdrant.Filter {
+	if qf := f.toQdrant(); qf != nil {
+		return qf
+	}
+	return &qdrant.Filter{}
+}
+
+// chunkPayload 切片转换为 Qdrant Payload
+// 这些数据就是以后检索回来给 DeepSeek 看的“背景知识”
+func chunkPayload(c LexicalChunk) map[string]any {
+	return map[string]any{
+		PayloadFileName:   c.FileName,
+		PayloadContent:    c.Content, // 存正文！
+		PayloadPageNumber: c.Page,    // 存页码！
+		PayloadChunkIndex: c.ChunkIndex,
+
+		// 过滤字段
+		PayloadDocumentID:      int64(c.DocumentID),
+		PayloadKnowledgeBaseID: int64(c.KnowledgeBaseID),
+		PayloadOwnerID:         int64(c.OwnerID),
+		PayloadOrganizationID:  int64(c.OrganizationID),
+		PayloadFileType:        c.FileType,
+		PayloadTitle:           c.Title,
+		PayloadIsLatest:        !c.Superseded,
+		PayloadIngestRun:       c.IngestRun,
+		PayloadExcluded:        c.Excluded,
+	}
+}
+
+// chunkFromPayload 从 Qdrant Payload 还原切片
+func chunkFromPayload(id *qdrant.PointId, payload map[string]*qdrant.Value) LexicalChunk {
+	return LexicalChunk{
+		ID:              pointIDString(id),
+		Content:         payload[PayloadContent].GetStringValue(),
+		FileName:        payload[PayloadFileName].GetStringValue(),
+		Title:           payload[PayloadTitle].GetStringValue(),
+		Page:            int32(payload[PayloadPageNumber].GetIntegerValue()),
+		ChunkIndex:      int32(payload[PayloadChunkIndex].GetIntegerValue()),
+		DocumentID:      uint(payload[PayloadDocumentID].GetIntegerValue()),
+		KnowledgeBaseID: uint(payload[PayloadKnowledgeBaseID].GetIntegerValue()),
+		OwnerID:         uint(payload[PayloadOwnerID].GetIntegerValue()),
+		OrganizationID:  uint(payload[PayloadOrganizationID].GetIntegerValue()),
+		FileType:        payload[PayloadFileType].GetStringValue(),
+		Superseded:      isFalse(payload[PayloadIsLatest]),
+		IngestRun:       payload[PayloadIngestRun].GetStringValue(),
+		Excluded:        payload[PayloadExcluded].GetBoolValue(),
+	}
+}
+
+// isFalse 字段存在且为 false；缺省视为 true (兼容没有版本字段的老数据)
+func isFalse(v *qdrant.Value) bool {
+	if v == nil {
+		return false
+	}
+	b, ok := v.GetKind().(*qdrant.Value_BoolValue)
+	return ok && !b.BoolValue
+}
+
+// pointIDString 将 Qdrant PointId 统一转成字符串
+func pointIDString(id *qdrant.PointId) string {
+	if id == nil {
+		return ""
+	}
+	if u := id.GetUuid(); u != "" {
+		return u
+	}
+	return strconv.FormatUint(id.GetNum(), 10)
+}
@@ -0,0 +1,106 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"Chimera-RAG/backend-go/internal/conf"
+
+	"gorm.io/gorm"
+)
+
+// ---------------------------------------------------------
+// 向量存储抽象
+// ---------------------------------------------------------
+
+// 向量存储后端
+const (
+	VectorStoreQdrant   = "qdrant"
+	VectorStoreMemory   = "memory"   // 进程内暴力检索，本地开发和测试用，重启即丢失
+	VectorStorePgvector = "pgvector" // 存在 Postgres 里，小规模部署可以不跑 Qdrant
+)
+
+// VectorPoint 一个待写入的切片向量
+// Chunk.ID 即 Point ID (见 ChunkPointID)，其余字段作为 Payload 写入
+type VectorPoint struct {
+	Vector []float32
+	Chunk  LexicalChunk
+}
+
+// PayloadPatch 按过滤条件批量修改 Payload，nil 字段保持不变
+type PayloadPatch struct {
+	IsLatest *bool
+	Excluded *bool
+}
+
+// VectorStore 切片向量的存储与检索
+// 过滤条件统一使用 SearchFilter，各实现自行翻译；filter 为 nil 表示全部切片
+type VectorStore interface {
+	// EnsureCollection 确保集合及 Payload 索引存在，可重复调用
+	EnsureCollection(ctx context.Context) error
+	// Upsert 写入 (或按 ID 覆盖) 切片，返回时已可被检索到
+	Upsert(ctx context.Context, points []VectorPoint) error
+	// Search 余弦相似度 TopK
+	Search(ctx context.Context, vector []float32, topK uint64, filter *SearchFilter) ([]SearchResult, error)
+	// Delete 删除满足条件的切片，重复执行是幂等的
+	Delete(ctx context.Context, filter *SearchFilter) error
+	// Count 满足条件的切片数
+	Count(ctx context.Context, filter *SearchFilter) (uint64, error)
+	// SetPayload 修改满足条件的切片的 Payload
+	SetPayload(ctx context.Context, filter *SearchFilter, patch PayloadPatch) error
+	// Scroll 分批遍历满足条件的切片 (不含向量)，顺序不保证
+	Scroll(ctx context.Context, filter *SearchFilter, fn func(chunks []LexicalChunk) error) error
+	Close() error
+}
+
+// ErrDimensionMismatch 集合已存在，但向量维度或距离与模型配置不一致
+// 说明换了模型却沿用了旧集合，继续写入只会失败，启动时直接报错
+var ErrDimensionMismatch = errors.New("向量集合与模型配置不一致")
+
+// CollectionName 模型对应的集合名 (Qdrant 集合 / pgvector 表)
+func CollectionName(prefix string, m conf.EmbeddingModel) string {
+	if m.Collection != "" {
+		return m.Collection
+	}
+	return prefix + "_" + strings.NewReplacer("-", "_", ".", "_").Replace(strings.ToLower(m.Name))
+}
+
+// NewVectorStore 按配置为某个模型创建向量存储，并确保集合存在且维度一致
+// db 仅 pgvector 后端使用，需要在文档表迁移之后调用
+func NewVectorStore(ctx context.Context, cfg conf.DataConfig, model conf.EmbeddingModel, db *gorm.DB) (VectorStore, error) {
+	collection := CollectionName(cfg.QdrantCollection, model)
+
+	var store VectorStore
+	switch cfg.VectorStore {
+	case VectorStoreQdrant, "":
+		s, err := newQdrantStore(cfg.QdrantAddr, collection, model)
+		if err != nil {
+			return nil, err
+		}
+		store = s
+	case VectorStoreMemory:
+		log.Printf("⚠️ 模型 %s 使用内存向量存储，数据不会持久化", model.Name)
+		store = NewMemoryVectorStore(model.Dimension, model.Distance)
+	case VectorStorePgvector:
+		s, err := newPgvectorStore(db, collection, model, cfg.PgvectorIndex)
+		if err != nil {
+			return nil, err
+		}
+		store = s
+	default:
+		return nil, fmt.Errorf("未知的向量存储后端: %s", cfg.VectorStore)
+	}
+
+	if err := store.EnsureCollection(ctx); err != nil {
+		if errors.Is(err, ErrDimensionMismatch) {
+			store.Close()
+			return nil, err
+		}
+		// 与之前保持一致: 连不上只告警，不阻止启动
+		log.Printf("⚠️ 初始化向量集合失败: %v", err)
+	}
+	return store, nil
+}
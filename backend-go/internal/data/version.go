@@ -0,0 +1,101 @@
+package data
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ---------------------------------------------------------
+// 文档去重与版本
+// ---------------------------------------------------------
+
+// uploadScope 去重 / 版本判定的范围: 同一知识库；
+// 根目录 (KnowledgeBaseID = 0) 是每个人在每个租户各自的，再按上传者和租户区分
+func uploadScope(db *gorm.DB, doc *Document) *gorm.DB {
+	db = db.Where("knowledge_base_id = ?", doc.KnowledgeBaseID)
+	if doc.KnowledgeBaseID == 0 {
+		db = db.Where("owner_id = ? AND organization_id = ?", doc.OwnerID, doc.OrganizationID)
+	}
+	return db
+}
+
+// CreateDocumentVersion 创建文档记录，并处理去重与版本:
+//   - 同范围内已有相同内容哈希的最新版本: 不创建，返回已有文档和 duplicate = true
+//     (与已被取代的旧版本内容相同不算重复，作为新版本创建)
+//   - 同范围内已有同名文档: 作为新版本创建 (Version + 1，PreviousVersionID 指向上一版)
+//   - 否则作为第一版创建
+//
+// 旧版本要等新版本解析成功后才会被 MarkSuperseded 取代，期间两个版本都能检索到
+func (d *Data) CreateDocumentVersion(ctx context.Context, doc *Document) (*Document, bool, error) {
+	var existing Document
+	duplicate := false
+
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := uploadScope(tx, doc).Where("content_hash = ? AND is_latest = ?", doc.ContentHash, true).
+			Order("version DESC").First(&existing).Error
+		if err == nil {
+			duplicate = true
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		// 锁住当前最高版本，避免两个人同时上传新版本得到相同的版本号
+		var head Document
+		err = uploadScope(tx, doc).Where("title = ?", doc.Title).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Order("version DESC").First(&head).Error
+		switch {
+		case err == nil:
+			doc.Version = head.Version + 1
+			doc.PreviousVersionID = &head.ID
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			doc.Version = 1
+		default:
+			return err
+		}
+		doc.IsLatest = true
+		return tx.Create(doc).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if duplicate {
+		return &existing, true, nil
+	}
+	return doc, false, nil
+}
+
+// MarkSuperseded 将文档标记为旧版本: 数据库 is_latest = false，向量和关键词索引不再参与检索
+// 只影响检索，原文件和记录仍保留，可以继续下载
+func (d *Data) MarkSuperseded(ctx context.Context, documentID uint) error {
+	if err := d.setLatest(ctx, documentID, false); err != nil {
+		return err
+	}
+	d.Lexical.SetSuperseded(documentID, true)
+	return nil
+}
+
+// RestoreLatest 最新版本被删除时，让上一版本重新参与检索
+func (d *Data) RestoreLatest(ctx context.Context, documentID uint) error {
+	if err := d.setLatest(ctx, documentID, true); err != nil {
+		return err
+	}
+	d.Lexical.SetSuperseded(documentID, false)
+	return nil
+}
+
+func (d *Data) setLatest(ctx context.Context, documentID uint, latest bool) error {
+	err := d.DB.WithContext(ctx).Model(&Document{}).Where("id = ?", documentID).Update("is_latest", latest).Error
+	if err != nil {
+		return err
+	}
+
+	return d.eachVectorStore(func(store VectorStore) error {
+		return store.SetPayload(ctx, documentChunksFilter(documentID), PayloadPatch{IsLatest: &latest})
+	})
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"Chimera-RAG/backend-go/internal/data"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler 运维接口 (仅管理员)
+type AdminHandler struct {
+	queues []*data.TaskQueue
+}
+
+func NewAdminHandler(queues ...*data.TaskQueue) *AdminHandler {
+	return &AdminHandler{queues: queues}
+}
+
+// HandleQueueStats 队列状态: 积压、处理中、重试中、死信
+// GET /api/v1/admin/queues
+func (h *AdminHandler) HandleQueueStats(c *gin.Context) {
+	stats := make([]*data.QueueStats, 0, len(h.queues))
+	for _, q := range h.queues {
+		s, err := q.Stats(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		stats = append(stats, s)
+	}
+	c.JSON(http.StatusOK, gin.H{"queues": stats})
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"Chimera-RAG/backend-go/internal/data"
 	"Chimera-RAG/backend-go/internal/utils"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -11,11 +12,12 @@ import (
 
 // TODO: 调用biz层
 type AuthHandler struct {
-	db *gorm.DB
+	db   *gorm.DB
+	data *data.Data
 }
 
-func NewAuthHandler(db *gorm.DB) *AuthHandler {
-	return &AuthHandler{db: db}
+func NewAuthHandler(d *data.Data) *AuthHandler {
+	return &AuthHandler{db: d.DB, data: d}
 }
 
 // RegisterReq 注册请求参数
@@ -23,6 +25,8 @@ type RegisterReq struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required,min=6"`
 	Email    string `json:"email"`
+	// InviteToken 组织邀请 (可选)，带上时注册后直接加入邀请的组织
+	InviteToken string `json:"invite_token"`
 }
 
 // HandleRegister 注册接口
@@ -55,12 +59,23 @@ func (h *AuthHandler) HandleRegister(c *gin.Context) {
 		Role:         "user",
 	}
 
-	if err := h.db.Create(&newUser).Error; err != nil {
+	if req.InviteToken != "" {
+		// 邀请核对与建用户在同一个事务里，邀请无效时不会留下用户
+		_, err := h.data.RegisterWithInvite(c.Request.Context(), &newUser, req.InviteToken)
+		switch {
+		case errors.Is(err, data.ErrInviteNotFound), errors.Is(err, data.ErrInviteInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "邀请无效: " + err.Error()})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "创建用户失败"})
+			return
+		}
+	} else if err := h.db.Create(&newUser).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建用户失败"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"msg": "注册成功", "user_id": newUser.ID})
+	c.JSON(http.StatusOK, gin.H{"msg": "注册成功", "user_id": newUser.ID, "organization_id": newUser.OrganizationID})
 }
 
 // HandleLogin 登录接口
@@ -85,15 +100,17 @@ func (h *AuthHandler) HandleLogin(c *gin.Context) {
 	}
 
 	// 3. 生成 Token
-	token, err := utils.GenerateToken(user.ID, user.Username, user.Role)
+	token, err := utils.GenerateToken(user.ID, user.Username, user.Role, user.OrganizationID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Token生成失败"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"token":    token,
-		"username": user.Username,
-		"user_id":  user.ID,
+		"token":           token,
+		"username":        user.Username,
+		"user_id":         user.ID,
+		"role":            user.Role,
+		"organization_id": user.OrganizationID,
 	})
 }
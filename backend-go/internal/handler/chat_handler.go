@@ -2,12 +2,15 @@ package handler
 
 import (
 	"Chimera-RAG/backend-go/internal/biz"
+	"Chimera-RAG/backend-go/internal/data"
 	"Chimera-RAG/backend-go/internal/service"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-
-	pb "Chimera-RAG/backend-go/api/rag/v1"
+	"path"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -30,28 +33,26 @@ func (h *ChatHandler) HandleChatSSE(c *gin.Context) {
 		return
 	}
 
-	// 2. 构造 gRPC 请求对象
-	grpcReq := &pb.AskRequest{
-		Query:     jsonReq.Query,
-		SessionId: jsonReq.SessionID,
-		UseGraph:  jsonReq.UseGraph,
-	}
-
-	// 3. 获取流数据管道
+	// 2. 获取流数据管道
 	// 注意：这里传入 c.Request.Context()，如果前端断开连接，gRPC 也会感知并取消
-	respChan, err := h.svc.StreamChat(c.Request.Context(), grpcReq)
+	userID := c.GetUint("userID")
+	respChan, err := h.svc.StreamChat(c.Request.Context(), userID, &jsonReq)
+	if errors.Is(err, data.ErrConversationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "会话不存在"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to call AI service"})
 		return
 	}
 
-	// 4. 开启 SSE 流式响应
+	// 3. 开启 SSE 流式响应
 	c.Stream(func(w io.Writer) bool {
 		// 从管道读取数据
-		if msg, ok := <-respChan; ok {
-			// SSE 格式: data: <内容>\n\n
-			// Gin 的 c.SSEvent 会自动封装格式
-			c.SSEvent("message", msg)
+		if ev, ok := <-respChan; ok {
+			// SSE 格式: event: <事件名>\ndata: {"v":"v1","data":{...}}\n\n
+			// Gin 的 c.SSEvent 会自动把结构体序列化成 JSON
+			c.SSEvent(ev.Event, ev)
 			return true // 继续保持连接
 		}
 		return false // 管道关闭，断开连接
@@ -70,29 +71,60 @@ func (h *ChatHandler) HandleUpload(c *gin.Context) {
 		return
 	}
 
+	// 目标知识库 (可选，默认个人根目录)
+	var kbID uint
+	if v := c.PostForm("knowledge_base_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "知识库 ID 无效"})
+			return
+		}
+		kbID = uint(id)
+	}
+
 	// 3. 调用 Service
-	doc, err := h.svc.UploadDocument(c.Request.Context(), fileHeader, userID)
-	if err != nil {
+	result, err := h.svc.UploadDocument(c.Request.Context(), fileHeader, userID, kbID)
+	switch {
+	case errors.Is(err, data.ErrKnowledgeBaseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "知识库不存在"})
+		return
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权向该知识库上传文档"})
+		return
+	case err != nil:
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
 
 	// 4. 返回结果
+	doc := result.Document
+	msg := "上传成功"
+	if result.Duplicate {
+		msg = "文档已存在，未重复入库"
+	}
 	c.JSON(200, gin.H{
-		"msg":    "上传成功",
-		"doc_id": doc.ID,
-		"path":   doc.StoragePath,
+		"msg":               msg,
+		"doc_id":            doc.ID,
+		"path":              doc.StoragePath,
+		"version":           doc.Version,
+		"knowledge_base_id": doc.KnowledgeBaseID,
+		"duplicate":         result.Duplicate,
 	})
 }
 
 // HandleGetFile 下载/预览文件
-// GET /api/v1/file/:filename
+// GET /api/v1/file/*filename (对象名带租户前缀，如 acme/uuid.pdf)
 func (h *ChatHandler) HandleGetFile(c *gin.Context) {
-	filename := c.Param("filename")
+	filename := strings.TrimPrefix(c.Param("filename"), "/")
+	userID := c.GetUint("userID")
 
-	// 1. 调用 Service 层获取流
+	// 1. 调用 Service 层获取流 (内部会做权限校验)
 	// 注意：obj 是一个 ReadCloser，必须关闭
-	obj, size, err := h.svc.GetFile(c.Request.Context(), filename)
+	obj, size, err := h.svc.GetFile(c.Request.Context(), userID, filename)
+	if errors.Is(err, service.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该文件"})
+		return
+	}
 	if err != nil {
 		// 生产环境建议区分 "文件不存在" 和 "服务器错误"
 		c.JSON(http.StatusNotFound, gin.H{"error": "文件获取失败: " + err.Error()})
@@ -105,7 +137,7 @@ func (h *ChatHandler) HandleGetFile(c *gin.Context) {
 	// 告诉浏览器这是一个 PDF，文件大小是多少（方便显示进度条）
 	c.Header("Content-Description", "File Transfer")
 	c.Header("Content-Transfer-Encoding", "binary")
-	c.Header("Content-Disposition", "inline; filename="+filename) // inline=浏览器内预览, attachment=强制下载
+	c.Header("Content-Disposition", "inline; filename="+path.Base(filename)) // inline=浏览器内预览, attachment=强制下载
 	c.Header("Content-Type", "application/pdf")
 	c.Header("Content-Length", fmt.Sprintf("%d", size))
 
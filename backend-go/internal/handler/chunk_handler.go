@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"Chimera-RAG/backend-go/internal/data"
+	"Chimera-RAG/backend-go/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChunkHandler 文档切片浏览与人工修订
+type ChunkHandler struct {
+	svc *service.ChunkService
+}
+
+func NewChunkHandler(svc *service.ChunkService) *ChunkHandler {
+	return &ChunkHandler{svc: svc}
+}
+
+type EditChunkReq struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// ExcludeChunksReq 按切片 ID 或页码范围排除/恢复，至少指定一种
+type ExcludeChunksReq struct {
+	ChunkIDs []string `json:"chunk_ids"`
+	PageFrom int32    `json:"page_from" binding:"min=0"`
+	PageTo   int32    `json:"page_to" binding:"min=0"`
+	Excluded *bool    `json:"excluded" binding:"required"`
+}
+
+type MergeChunksReq struct {
+	ChunkIDs []string `json:"chunk_ids" binding:"required,len=2"`
+}
+
+type SplitChunkReq struct {
+	// Offset 拆分位置 (字符数)，前 Offset 个字符留在原切片
+	Offset int `json:"offset" binding:"required,min=1"`
+}
+
+// HandleList 列出文档的全部切片 (含页码、是否被排除)
+// GET /api/v1/documents/:id/chunks
+func (h *ChunkHandler) HandleList(c *gin.Context) {
+	docID, ok := parseDocumentID(c)
+	if !ok {
+		return
+	}
+	chunks, err := h.svc.List(c.Request.Context(), c.GetUint("userID"), docID)
+	if err != nil {
+		writeChunkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"chunks": chunks})
+}
+
+// HandleAudits 切片修订记录
+// GET /api/v1/documents/:id/chunks/audits
+func (h *ChunkHandler) HandleAudits(c *gin.Context) {
+	docID, ok := parseDocumentID(c)
+	if !ok {
+		return
+	}
+	audits, err := h.svc.Audits(c.Request.Context(), c.GetUint("userID"), docID)
+	if err != nil {
+		writeChunkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"audits": audits})
+}
+
+// HandleEdit 修改切片文本，自动重新向量化
+// PATCH /api/v1/documents/:id/chunks/:chunk_id
+func (h *ChunkHandler) HandleEdit(c *gin.Context) {
+	docID, ok := parseDocumentID(c)
+	if !ok {
+		return
+	}
+	var req EditChunkReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	chunk, err := h.svc.Edit(c.Request.Context(), c.GetUint("userID"), docID, c.Param("chunk_id"), req.Content)
+	if err != nil {
+		writeChunkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, chunk)
+}
+
+// HandleExclude 排除或恢复切片 / 整页
+// POST /api/v1/documents/:id/chunks/exclusion
+func (h *ChunkHandler) HandleExclude(c *gin.Context) {
+	docID, ok := parseDocumentID(c)
+	if !ok {
+		return
+	}
+	var req ExcludeChunksReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.ChunkIDs) == 0 && req.PageFrom == 0 && req.PageTo == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请指定切片 ID 或页码范围"})
+		return
+	}
+
+	ids, err := h.svc.SetExcluded(c.Request.Context(), c.GetUint("userID"), docID, data.ChunkExclusion{
+		ChunkIDs: req.ChunkIDs,
+		PageFrom: req.PageFrom,
+		PageTo:   req.PageTo,
+		Excluded: *req.Excluded,
+	})
+	if err != nil {
+		writeChunkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"chunk_ids": ids, "excluded": *req.Excluded})
+}
+
+// HandleMerge 合并相邻的两个切片
+// POST /api/v1/documents/:id/chunks/merge
+func (h *ChunkHandler) HandleMerge(c *gin.Context) {
+	docID, ok := parseDocumentID(c)
+	if !ok {
+		return
+	}
+	var req MergeChunksReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	chunk, err := h.svc.Merge(c.Request.Context(), c.GetUint("userID"), docID, req.ChunkIDs[0], req.ChunkIDs[1])
+	if err != nil {
+		writeChunkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, chunk)
+}
+
+// HandleSplit 拆分切片
+// POST /api/v1/documents/:id/chunks/:chunk_id/split
+func (h *ChunkHandler) HandleSplit(c *gin.Context) {
+	docID, ok := parseDocumentID(c)
+	if !ok {
+		return
+	}
+	var req SplitChunkReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	chunks, err := h.svc.Split(c.Request.Context(), c.GetUint("userID"), docID, c.Param("chunk_id"), req.Offset)
+	if err != nil {
+		writeChunkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"chunks": chunks})
+}
+
+// HandleResync 按切片表重新同步向量 (修订后向量同步失败时调用)
+// POST /api/v1/documents/:id/chunks/sync
+func (h *ChunkHandler) HandleResync(c *gin.Context) {
+	docID, ok := parseDocumentID(c)
+	if !ok {
+		return
+	}
+	res, err := h.svc.Resync(c.Request.Context(), c.GetUint("userID"), docID)
+	if err != nil {
+		writeChunkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func writeChunkError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, data.ErrChunkNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "切片不存在"})
+	case errors.Is(err, data.ErrChunkConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		writeDocumentError(c, err)
+	}
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"Chimera-RAG/backend-go/internal/data"
+	"Chimera-RAG/backend-go/internal/service"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ConversationHandler struct {
+	svc *service.ConversationService
+}
+
+func NewConversationHandler(svc *service.ConversationService) *ConversationHandler {
+	return &ConversationHandler{svc: svc}
+}
+
+// RenameConversationReq 重命名请求参数
+type RenameConversationReq struct {
+	Title string `json:"title" binding:"required,max=200"`
+}
+
+// HandleList 会话列表
+// GET /api/v1/conversations?page=1&page_size=20
+func (h *ConversationHandler) HandleList(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	result, err := h.svc.List(c.Request.Context(), c.GetUint("userID"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// HandleGet 会话完整记录
+// GET /api/v1/conversations/:id
+func (h *ConversationHandler) HandleGet(c *gin.Context) {
+	conv, err := h.svc.Get(c.Request.Context(), c.GetUint("userID"), c.Param("id"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, conv)
+}
+
+// HandleRename 重命名会话
+// PATCH /api/v1/conversations/:id
+func (h *ConversationHandler) HandleRename(c *gin.Context) {
+	var req RenameConversationReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.Rename(c.Request.Context(), c.GetUint("userID"), c.Param("id"), req.Title); err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "重命名成功"})
+}
+
+// HandleDelete 删除会话
+// DELETE /api/v1/conversations/:id
+func (h *ConversationHandler) HandleDelete(c *gin.Context) {
+	if err := h.svc.Delete(c.Request.Context(), c.GetUint("userID"), c.Param("id")); err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "删除成功"})
+}
+
+// HandleExport 导出会话
+// GET /api/v1/conversations/:id/export?format=markdown|json
+func (h *ConversationHandler) HandleExport(c *gin.Context) {
+	format := c.DefaultQuery("format", service.ExportMarkdown)
+	if format != service.ExportMarkdown && format != service.ExportJSON {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format 只支持 markdown 或 json"})
+		return
+	}
+
+	sessionID := c.Param("id")
+	export, err := h.svc.Export(c.Request.Context(), c.GetUint("userID"), sessionID)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	if format == service.ExportJSON {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=conversation-%s.json", sessionID))
+		c.JSON(http.StatusOK, export)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=conversation-%s.md", sessionID))
+	c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(service.RenderMarkdown(export)))
+}
+
+func (h *ConversationHandler) writeError(c *gin.Context, err error) {
+	if errors.Is(err, data.ErrConversationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "会话不存在"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"Chimera-RAG/backend-go/internal/data"
+	"Chimera-RAG/backend-go/internal/service"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DocumentHandler struct {
+	svc *service.DocumentService
+}
+
+func NewDocumentHandler(svc *service.DocumentService) *DocumentHandler {
+	return &DocumentHandler{svc: svc}
+}
+
+// documentListLimit 每页默认 / 最大条数
+const (
+	documentListLimit    = 20
+	documentListMaxLimit = 100
+)
+
+// HandleList 文档列表 (按可见范围过滤)
+// GET /api/v1/documents?knowledge_base_id=1&recursive=true&status=success,failed&q=标准&sort=created_at&order=desc&cursor=...
+// 其他过滤参数: file_type、owner_id、created_from / created_to、latest、limit
+func (h *DocumentHandler) HandleList(c *gin.Context) {
+	var opts service.DocumentListOptions
+	opts.Sort = c.DefaultQuery("sort", data.DocumentSortCreatedAt)
+	opts.Desc = c.DefaultQuery("order", "desc") != "asc"
+	opts.Cursor = c.Query("cursor")
+	opts.Title = strings.TrimSpace(c.Query("q"))
+	opts.Statuses = splitList(c.Query("status"))
+	opts.FileTypes = splitList(c.Query("file_type"))
+	opts.Recursive = c.Query("recursive") == "true"
+	opts.LatestOnly = c.Query("latest") == "true"
+
+	opts.Limit = documentListLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > documentListMaxLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit 必须在 1 ~ %d 之间", documentListMaxLimit)})
+			return
+		}
+		opts.Limit = n
+	}
+	if v := c.Query("knowledge_base_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "知识库 ID 无效"})
+			return
+		}
+		kbID := uint(id)
+		opts.KnowledgeBaseID = &kbID
+	}
+	if v := c.Query("owner_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "owner_id 无效"})
+			return
+		}
+		opts.OwnerID = uint(id)
+	}
+	var ok bool
+	if opts.CreatedFrom, ok = parseTimeQuery(c, "created_from"); !ok {
+		return
+	}
+	if opts.CreatedTo, ok = parseTimeQuery(c, "created_to"); !ok {
+		return
+	}
+
+	page, err := h.svc.List(c.Request.Context(), c.GetUint("userID"), opts)
+	if errors.Is(err, data.ErrInvalidDocumentQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeDocumentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// splitList 逗号分隔的查询参数
+func splitList(v string) []string {
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// parseTimeQuery 解析时间参数，支持 RFC3339 和 2006-01-02；参数缺省时返回 nil
+func parseTimeQuery(c *gin.Context, key string) (*time.Time, bool) {
+	v := c.Query(key)
+	if v == "" {
+		return nil, true
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+			return &t, true
+		}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": key + " 格式应为 2006-01-02 或 RFC3339"})
+	return nil, false
+}
+
+// HandleStatus 查询文档处理状态
+// GET /api/v1/documents/:id/status
+func (h *DocumentHandler) HandleStatus(c *gin.Context) {
+	docID, ok := parseDocumentID(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.svc.GetStatus(c.Request.Context(), c.GetUint("userID"), docID)
+	if err != nil {
+		writeDocumentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// HandleProgress 以 SSE 推送文档处理进度，进入终态后自动断开
+// GET /api/v1/documents/:id/progress
+func (h *DocumentHandler) HandleProgress(c *gin.Context) {
+	docID, ok := parseDocumentID(c)
+	if !ok {
+		return
+	}
+
+	updates, err := h.svc.WatchProgress(c.Request.Context(), c.GetUint("userID"), docID)
+	if err != nil {
+		writeDocumentError(c, err)
+		return
+	}
+
+	// SSE 格式: event: progress\ndata: {"document_id":1,"status":"parsing","progress":40,...}\n\n
+	c.Stream(func(w io.Writer) bool {
+		if p, ok := <-updates; ok {
+			c.SSEvent("progress", p)
+			return true
+		}
+		return false
+	})
+}
+
+// HandleDelete 删除文档 (仅上传者或管理员)，清理在后台进行
+// DELETE /api/v1/documents/:id
+func (h *DocumentHandler) HandleDelete(c *gin.Context) {
+	docID, ok := parseDocumentID(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.svc.Delete(c.Request.Context(), c.GetUint("userID"), docID)
+	if err != nil {
+		writeDocumentError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"msg": "文档已删除，正在后台清理", "job_id": job.ID})
+}
+
+func parseDocumentID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "文档 ID 无效"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func writeDocumentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, data.ErrDocumentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "文档不存在"})
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权操作该文档"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"Chimera-RAG/backend-go/internal/data"
+	"Chimera-RAG/backend-go/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GroupHandler 用户组管理 (组织管理员，只能管理本租户的用户组)
+type GroupHandler struct {
+	svc *service.GroupService
+}
+
+func NewGroupHandler(svc *service.GroupService) *GroupHandler {
+	return &GroupHandler{svc: svc}
+}
+
+type CreateGroupReq struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description"`
+}
+
+type GroupMemberReq struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+// HandleList 全部用户组
+// GET /api/v1/org/groups
+func (h *GroupHandler) HandleList(c *gin.Context) {
+	groups, err := h.svc.List(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		writeGroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"groups": groups})
+}
+
+// HandleCreate 创建用户组
+// POST /api/v1/org/groups
+func (h *GroupHandler) HandleCreate(c *gin.Context) {
+	var req CreateGroupReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	g, err := h.svc.Create(c.Request.Context(), c.GetUint("userID"), req.Name, req.Description)
+	if err != nil {
+		writeGroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, g)
+}
+
+// HandleGet 用户组详情 (含成员)
+// GET /api/v1/org/groups/:id
+func (h *GroupHandler) HandleGet(c *gin.Context) {
+	id, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+	g, err := h.svc.Get(c.Request.Context(), c.GetUint("userID"), id)
+	if err != nil {
+		writeGroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, g)
+}
+
+// HandleDelete 删除用户组
+// DELETE /api/v1/org/groups/:id
+func (h *GroupHandler) HandleDelete(c *gin.Context) {
+	id, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.Delete(c.Request.Context(), c.GetUint("userID"), id); err != nil {
+		writeGroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "用户组已删除"})
+}
+
+// HandleAddMember 加入用户组
+// POST /api/v1/org/groups/:id/members
+func (h *GroupHandler) HandleAddMember(c *gin.Context) {
+	id, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+	var req GroupMemberReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.svc.AddMember(c.Request.Context(), c.GetUint("userID"), id, req.UserID); err != nil {
+		writeGroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "已加入用户组"})
+}
+
+// HandleRemoveMember 移出用户组
+// DELETE /api/v1/org/groups/:id/members/:user_id
+func (h *GroupHandler) HandleRemoveMember(c *gin.Context) {
+	id, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "用户 ID 无效"})
+		return
+	}
+	if err := h.svc.RemoveMember(c.Request.Context(), c.GetUint("userID"), id, uint(userID)); err != nil {
+		writeGroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "已移出用户组"})
+}
+
+func parseGroupID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "用户组 ID 无效"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func writeGroupError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, data.ErrGroupNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户组不存在"})
+	case errors.Is(err, data.ErrInvalidGrant):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
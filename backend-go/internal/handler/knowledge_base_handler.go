@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"Chimera-RAG/backend-go/internal/data"
+	"Chimera-RAG/backend-go/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KnowledgeBaseHandler 知识库 (文件夹树) 管理
+type KnowledgeBaseHandler struct {
+	svc *service.KnowledgeBaseService
+}
+
+func NewKnowledgeBaseHandler(svc *service.KnowledgeBaseService) *KnowledgeBaseHandler {
+	return &KnowledgeBaseHandler{svc: svc}
+}
+
+type CreateKnowledgeBaseReq struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description"`
+	Type        string `json:"type" binding:"omitempty,oneof=folder repo"`
+	ParentID    *uint  `json:"parent_id"`
+	IsPublic    bool   `json:"is_public"`
+}
+
+// UpdateKnowledgeBaseReq 只修改传了的字段
+type UpdateKnowledgeBaseReq struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Description *string `json:"description"`
+	IsPublic    *bool   `json:"is_public"`
+}
+
+type MoveKnowledgeBaseReq struct {
+	// ParentID 为 null 表示移到根目录
+	ParentID *uint `json:"parent_id"`
+}
+
+type GrantKnowledgeBaseReq struct {
+	SubjectType string `json:"subject_type" binding:"required,oneof=user group"`
+	SubjectID   uint   `json:"subject_id" binding:"required"`
+	Role        string `json:"role" binding:"required,oneof=viewer editor manager"`
+}
+
+// HandleTree 返回可见的知识库树，附带文档数与处理状态
+// GET /api/v1/knowledge-bases
+func (h *KnowledgeBaseHandler) HandleTree(c *gin.Context) {
+	tree, err := h.svc.Tree(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		writeKnowledgeBaseError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"knowledge_bases": tree})
+}
+
+// HandleCreate 创建知识库
+// POST /api/v1/knowledge-bases
+func (h *KnowledgeBaseHandler) HandleCreate(c *gin.Context) {
+	var req CreateKnowledgeBaseReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	kb, err := h.svc.Create(c.Request.Context(), c.GetUint("userID"), service.KnowledgeBaseInput{
+		Name:        &req.Name,
+		Description: &req.Description,
+		Type:        req.Type,
+		ParentID:    req.ParentID,
+		IsPublic:    &req.IsPublic,
+	})
+	if err != nil {
+		writeKnowledgeBaseError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, kb)
+}
+
+// HandleUpdate 重命名 / 修改描述 / 修改是否公开
+// PATCH /api/v1/knowledge-bases/:id
+func (h *KnowledgeBaseHandler) HandleUpdate(c *gin.Context) {
+	id, ok := parseKnowledgeBaseID(c)
+	if !ok {
+		return
+	}
+	var req UpdateKnowledgeBaseReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	kb, err := h.svc.Update(c.Request.Context(), c.GetUint("userID"), id, service.KnowledgeBaseInput{
+		Name:        req.Name,
+		Description: req.Description,
+		IsPublic:    req.IsPublic,
+	})
+	if err != nil {
+		writeKnowledgeBaseError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, kb)
+}
+
+// HandleMove 移动到另一个文件夹下
+// POST /api/v1/knowledge-bases/:id/move
+func (h *KnowledgeBaseHandler) HandleMove(c *gin.Context) {
+	id, ok := parseKnowledgeBaseID(c)
+	if !ok {
+		return
+	}
+	var req MoveKnowledgeBaseReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	kb, err := h.svc.Move(c.Request.Context(), c.GetUint("userID"), id, req.ParentID)
+	if err != nil {
+		writeKnowledgeBaseError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, kb)
+}
+
+// HandleDelete 递归删除知识库及其中的文档，清理在后台进行
+// DELETE /api/v1/knowledge-bases/:id
+func (h *KnowledgeBaseHandler) HandleDelete(c *gin.Context) {
+	id, ok := parseKnowledgeBaseID(c)
+	if !ok {
+		return
+	}
+
+	queued, err := h.svc.Delete(c.Request.Context(), c.GetUint("userID"), id)
+	if err != nil {
+		writeKnowledgeBaseError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"msg": "知识库已删除，正在后台清理文档", "documents": queued})
+}
+
+// HandleListGrants 查看知识库的授权 (含从上级继承的)
+// GET /api/v1/knowledge-bases/:id/grants
+func (h *KnowledgeBaseHandler) HandleListGrants(c *gin.Context) {
+	id, ok := parseKnowledgeBaseID(c)
+	if !ok {
+		return
+	}
+	access, err := h.svc.Access(c.Request.Context(), c.GetUint("userID"), id)
+	if err != nil {
+		writeKnowledgeBaseError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, access)
+}
+
+// HandleGrant 授权用户或用户组，已有授权时修改角色
+// POST /api/v1/knowledge-bases/:id/grants
+func (h *KnowledgeBaseHandler) HandleGrant(c *gin.Context) {
+	id, ok := parseKnowledgeBaseID(c)
+	if !ok {
+		return
+	}
+	var req GrantKnowledgeBaseReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	grant, err := h.svc.Grant(c.Request.Context(), c.GetUint("userID"), id, req.SubjectType, req.SubjectID, req.Role)
+	if err != nil {
+		writeKnowledgeBaseError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, grant)
+}
+
+// HandleRevoke 撤销授权
+// DELETE /api/v1/knowledge-bases/:id/grants/:subject_type/:subject_id
+func (h *KnowledgeBaseHandler) HandleRevoke(c *gin.Context) {
+	id, ok := parseKnowledgeBaseID(c)
+	if !ok {
+		return
+	}
+	subjectID, err := strconv.ParseUint(c.Param("subject_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "授权主体 ID 无效"})
+		return
+	}
+
+	if err := h.svc.Revoke(c.Request.Context(), c.GetUint("userID"), id, c.Param("subject_type"), uint(subjectID)); err != nil {
+		writeKnowledgeBaseError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "授权已撤销"})
+}
+
+func parseKnowledgeBaseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "知识库 ID 无效"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func writeKnowledgeBaseError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, data.ErrKnowledgeBaseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "知识库不存在"})
+	case errors.Is(err, data.ErrGrantNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "授权不存在"})
+	case errors.Is(err, data.ErrGroupNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户组不存在"})
+	case errors.Is(err, data.ErrInvalidGrant):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, data.ErrKnowledgeBaseConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权操作该知识库"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"Chimera-RAG/backend-go/internal/data"
+	"Chimera-RAG/backend-go/internal/service"
+	"Chimera-RAG/backend-go/internal/utils"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrganizationHandler 组织 (租户) 管理
+// /org/* 供组织管理员管理本租户，/admin/organizations 供平台管理员使用
+type OrganizationHandler struct {
+	svc *service.OrganizationService
+}
+
+func NewOrganizationHandler(svc *service.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{svc: svc}
+}
+
+type CreateOrganizationReq struct {
+	Name string `json:"name" binding:"required,max=100"`
+	// Key MinIO 对象前缀，不填时使用 org-<ID>
+	Key string `json:"key" binding:"max=50"`
+}
+
+type SetUserRoleReq struct {
+	Role string `json:"role" binding:"required,oneof=user org_admin"`
+}
+
+type CreateInviteReq struct {
+	Email    string `json:"email" binding:"omitempty,email,max=100"`
+	Username string `json:"username" binding:"max=50"`
+	Role     string `json:"role" binding:"omitempty,oneof=user org_admin"`
+	// ExpiresInHours 有效期 (小时)，不填默认 7 天，最长 30 天
+	ExpiresInHours int `json:"expires_in_hours" binding:"min=0"`
+}
+
+type AcceptInviteReq struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type AssignUserReq struct {
+	// OrganizationID 为 0 表示默认租户
+	OrganizationID uint   `json:"organization_id"`
+	Role           string `json:"role" binding:"required,oneof=user org_admin"`
+}
+
+// HandleCurrent 当前组织概况
+// GET /api/v1/org
+func (h *OrganizationHandler) HandleCurrent(c *gin.Context) {
+	info, err := h.svc.Current(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+// HandleUsers 本组织的用户
+// GET /api/v1/org/users
+func (h *OrganizationHandler) HandleUsers(c *gin.Context) {
+	users, err := h.svc.Users(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"users": users})
+}
+
+// HandleSetUserRole 修改本组织用户的角色
+// PUT /api/v1/org/users/:id/role
+func (h *OrganizationHandler) HandleSetUserRole(c *gin.Context) {
+	targetID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	var req SetUserRoleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.SetUserRole(c.Request.Context(), c.GetUint("userID"), targetID, req.Role); err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "角色已修改，用户重新登录后生效"})
+}
+
+// HandleRemoveMember 把用户移出本组织，立即失去本组织知识库的访问权限
+// DELETE /api/v1/org/users/:id
+func (h *OrganizationHandler) HandleRemoveMember(c *gin.Context) {
+	targetID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.RemoveMember(c.Request.Context(), c.GetUint("userID"), targetID); err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "已移出组织"})
+}
+
+// HandleInvite 邀请用户加入本组织，返回的 token 只显示这一次
+// POST /api/v1/org/invites
+func (h *OrganizationHandler) HandleInvite(c *gin.Context) {
+	var req CreateInviteReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	inv, err := h.svc.Invite(c.Request.Context(), c.GetUint("userID"), service.InviteInput{
+		Email:    req.Email,
+		Username: req.Username,
+		Role:     req.Role,
+		TTL:      time.Duration(req.ExpiresInHours) * time.Hour,
+	})
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, inv)
+}
+
+// HandleInvites 本组织待接受的邀请
+// GET /api/v1/org/invites
+func (h *OrganizationHandler) HandleInvites(c *gin.Context) {
+	invites, err := h.svc.Invites(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"invites": invites})
+}
+
+// HandleRevokeInvite 撤销邀请
+// DELETE /api/v1/org/invites/:id
+func (h *OrganizationHandler) HandleRevokeInvite(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "邀请 ID 无效"})
+		return
+	}
+	if err := h.svc.RevokeInvite(c.Request.Context(), c.GetUint("userID"), uint(id)); err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "邀请已撤销"})
+}
+
+// HandleAcceptInvite 当前用户接受邀请并加入组织，返回带新组织的 Token
+// POST /api/v1/invites/accept
+func (h *OrganizationHandler) HandleAcceptInvite(c *gin.Context) {
+	var req AcceptInviteReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.svc.AcceptInvite(c.Request.Context(), c.GetUint("userID"), req.Token)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	// 原 Token 带的是旧组织，换发一个
+	token, err := utils.GenerateToken(user.ID, user.Username, user.Role, user.OrganizationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Token生成失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"msg":             "已加入组织",
+		"token":           token,
+		"role":            user.Role,
+		"organization_id": user.OrganizationID,
+	})
+}
+
+// HandleList 全部组织
+// GET /api/v1/admin/organizations
+func (h *OrganizationHandler) HandleList(c *gin.Context) {
+	orgs, err := h.svc.List(c.Request.Context())
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"organizations": orgs})
+}
+
+// HandleCreate 创建组织
+// POST /api/v1/admin/organizations
+func (h *OrganizationHandler) HandleCreate(c *gin.Context) {
+	var req CreateOrganizationReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	org, err := h.svc.Create(c.Request.Context(), req.Name, req.Key)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, org)
+}
+
+// HandleAssignUser 把用户分配到组织
+// PUT /api/v1/admin/users/:id/organization
+func (h *OrganizationHandler) HandleAssignUser(c *gin.Context) {
+	targetID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	var req AssignUserReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.AssignUser(c.Request.Context(), targetID, req.OrganizationID, req.Role); err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "已分配，用户重新登录后生效"})
+}
+
+func parseUserID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "用户 ID 无效"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func writeOrganizationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, data.ErrOrganizationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "组织不存在"})
+	case errors.Is(err, data.ErrInviteNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "邀请不存在"})
+	case errors.Is(err, data.ErrInviteInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, data.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+	case errors.Is(err, data.ErrOrganizationConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, data.ErrInvalidMembership):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权操作"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"Chimera-RAG/backend-go/internal/data"
+	"Chimera-RAG/backend-go/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReindexHandler 重建索引 (切换向量模型) 运维接口，仅管理员
+type ReindexHandler struct {
+	svc *service.ReindexService
+}
+
+func NewReindexHandler(svc *service.ReindexService) *ReindexHandler {
+	return &ReindexHandler{svc: svc}
+}
+
+type StartReindexReq struct {
+	TargetModel string `json:"target_model" binding:"required"`
+}
+
+type CutOverReindexReq struct {
+	// Force 切片数校验未通过 (verify_failed) 时仍然切换
+	Force bool `json:"force"`
+}
+
+// HandleStart 创建重建任务
+// POST /api/v1/admin/reindex
+func (h *ReindexHandler) HandleStart(c *gin.Context) {
+	var req StartReindexReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, err := h.svc.Start(c.Request.Context(), c.GetUint("userID"), req.TargetModel)
+	if err != nil {
+		writeReindexError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, task)
+}
+
+// HandleList 最近的重建任务
+// GET /api/v1/admin/reindex
+func (h *ReindexHandler) HandleList(c *gin.Context) {
+	tasks, err := h.svc.List(c.Request.Context())
+	if err != nil {
+		writeReindexError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tasks": tasks})
+}
+
+// HandleGet 查询任务进度
+// GET /api/v1/admin/reindex/:id
+func (h *ReindexHandler) HandleGet(c *gin.Context) {
+	id, ok := parseReindexID(c)
+	if !ok {
+		return
+	}
+	task, err := h.svc.Get(c.Request.Context(), id)
+	if err != nil {
+		writeReindexError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, task)
+}
+
+// HandleCutOver 切换检索到新集合
+// POST /api/v1/admin/reindex/:id/cutover
+func (h *ReindexHandler) HandleCutOver(c *gin.Context) {
+	id, ok := parseReindexID(c)
+	if !ok {
+		return
+	}
+	var req CutOverReindexReq
+	// 请求体可以为空
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	task, err := h.svc.CutOver(c.Request.Context(), id, req.Force)
+	if err != nil {
+		writeReindexError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, task)
+}
+
+// HandleRollback 切回旧集合
+// POST /api/v1/admin/reindex/:id/rollback
+func (h *ReindexHandler) HandleRollback(c *gin.Context) {
+	id, ok := parseReindexID(c)
+	if !ok {
+		return
+	}
+	task, requeued, err := h.svc.Rollback(c.Request.Context(), id)
+	if err != nil {
+		writeReindexError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"task": task, "requeued_documents": requeued})
+}
+
+func parseReindexID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "任务 ID 无效"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func writeReindexError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, data.ErrReindexNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "重建任务不存在"})
+	case errors.Is(err, data.ErrReindexConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"Chimera-RAG/backend-go/internal/data"
 	"Chimera-RAG/backend-go/internal/utils"
 	"net/http"
 	"strings"
@@ -38,7 +39,27 @@ func JWTAuth() gin.HandlerFunc {
 		// 4. 🔥 关键：把 UserID 存入上下文，供后续 Handler 使用
 		c.Set("userID", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("role", claims.Role)
+		c.Set("orgID", claims.OrganizationID)
+
+		// 租户以 Token 为准，放入 Request Context 交给 AccessPolicy 校验
+		c.Request = c.Request.WithContext(data.WithTenant(c.Request.Context(), claims.OrganizationID))
 
 		c.Next()
 	}
 }
+
+// RequireRole 角色校验中间件，需放在 JWTAuth 之后
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, r := range roles {
+			if r == role {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "权限不足"})
+		c.Abort()
+	}
+}
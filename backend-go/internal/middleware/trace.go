@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"Chimera-RAG/backend-go/internal/data"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Trace 链路追踪中间件
+// 沿用请求头中的 W3C traceparent，没有则新建一个，放入 Request Context 供后台任务继承
+func Trace() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tp := c.GetHeader("traceparent")
+		if tp == "" {
+			tp = newTraceParent()
+		}
+
+		c.Request = c.Request.WithContext(data.WithTraceParent(c.Request.Context(), tp))
+		c.Header("traceparent", tp)
+		c.Next()
+	}
+}
+
+// newTraceParent 生成 traceparent: 版本-traceID-spanID-标志位
+func newTraceParent() string {
+	traceID := make([]byte, 16)
+	spanID := make([]byte, 8)
+	_, _ = rand.Read(traceID)
+	_, _ = rand.Read(spanID)
+	return fmt.Sprintf("00-%s-%s-01", hex.EncodeToString(traceID), hex.EncodeToString(spanID))
+}
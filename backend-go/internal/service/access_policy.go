@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"Chimera-RAG/backend-go/internal/data"
+)
+
+// ErrForbidden 调用者无权访问目标资源
+var ErrForbidden = errors.New("permission denied")
+
+// AccessPolicy 文档与知识库访问控制的唯一入口
+// 租户隔离: 用户只能看到所在组织 (租户) 内的数据，组织以 JWT 为准；平台管理员 (admin) 不受限制。
+// 租户内的可见规则: 加入了组织的成员 (含组织管理员) 可以看到本组织的全部文档和知识库；
+// 默认租户 (未加入组织) 的用户只能看到 自己上传的文档 + 公开知识库 + 自己创建或被授权的知识库
+// (授权给本人或所在用户组，均含子文件夹) 下的文档。
+// 写权限按知识库角色 (viewer < editor < manager) 判断，子文件夹继承父节点的授权，
+// 知识库创建者在整棵子树上都是 manager。
+// 上传、删除、检索、文件下载、文档列表都必须经过这里，不要在别处重复实现。
+type AccessPolicy struct {
+	data *data.Data
+}
+
+func NewAccessPolicy(d *data.Data) *AccessPolicy {
+	return &AccessPolicy{data: d}
+}
+
+// Caller 查找调用者；请求带的租户 (JWT) 与用户当前所在组织不一致时 (Token 签发后换了组织) 拒绝访问
+func (p *AccessPolicy) Caller(ctx context.Context, userID uint) (*data.User, error) {
+	user, err := p.data.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if orgID, ok := data.TenantFromContext(ctx); ok && orgID != user.OrganizationID {
+		log.Printf("⚠️ 用户 %d 的 Token 属于组织 %d，当前组织为 %d，需要重新登录", userID, orgID, user.OrganizationID)
+		return nil, ErrForbidden
+	}
+	return user, nil
+}
+
+// TenantAdmin 查找调用者并确认他是所在租户的管理员 (平台管理员或本组织的组织管理员)
+// 角色以数据库为准，不信任 JWT 里的角色: 被降级或换了组织的用户在 Token 过期前也不能再管理租户
+func (p *AccessPolicy) TenantAdmin(ctx context.Context, userID uint) (*data.User, error) {
+	user, err := p.Caller(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isTenantAdmin(user, user.OrganizationID) {
+		log.Printf("⚠️ 用户 %d 已不是组织 %d 的管理员 (当前角色 %s)", userID, user.OrganizationID, user.Role)
+		return nil, ErrForbidden
+	}
+	return user, nil
+}
+
+// sameTenant 用户能否访问 orgID 租户的数据
+func sameTenant(user *data.User, orgID uint) bool {
+	return user.Role == data.UserRoleAdmin || user.OrganizationID == orgID
+}
+
+// isTenantAdmin 平台管理员，或该租户的组织管理员
+func isTenantAdmin(user *data.User, orgID uint) bool {
+	return user.Role == data.UserRoleAdmin || (user.Role == data.UserRoleOrgAdmin && user.OrganizationID == orgID)
+}
+
+// Scope 计算用户的可见范围，交给 data 层翻译为 Qdrant Filter / SQL 条件
+func (p *AccessPolicy) Scope(ctx context.Context, userID uint) (*data.AccessScope, error) {
+	user, err := p.Caller(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	switch user.Role {
+	case data.UserRoleAdmin:
+		return &data.AccessScope{AllTenants: true, All: true, OrganizationID: user.OrganizationID, OwnerID: user.ID}, nil
+	case data.UserRoleOrgAdmin:
+		return &data.AccessScope{All: true, OrganizationID: user.OrganizationID, OwnerID: user.ID}, nil
+	}
+	// 同组织成员互相可见；默认租户 (0) 不是一个组织，里面的用户互相不可见
+	if user.OrganizationID != 0 {
+		return &data.AccessScope{All: true, OrganizationID: user.OrganizationID, OwnerID: user.ID}, nil
+	}
+
+	kbIDs, err := p.readableKnowledgeBaseIDs(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data.AccessScope{
+		OrganizationID:   user.OrganizationID,
+		OwnerID:          user.ID,
+		KnowledgeBaseIDs: kbIDs,
+	}, nil
+}
+
+// readableKnowledgeBaseIDs 默认租户内公开、自己创建、被授权 (本人或所在用户组) 的知识库，均展开子文件夹
+func (p *AccessPolicy) readableKnowledgeBaseIDs(ctx context.Context, user *data.User) ([]uint, error) {
+	publicIDs, err := p.data.PublicKnowledgeBaseIDs(ctx, user.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	groupIDs, err := p.data.UserGroupIDs(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	owned, err := p.data.OwnedKnowledgeBaseIDs(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	granted, err := p.data.GrantedKnowledgeBaseIDs(ctx, user.ID, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := append(owned, granted...)
+	if len(roots) == 0 {
+		return publicIDs, nil
+	}
+	ids, err := p.data.DescendantKnowledgeBaseIDs(ctx, append(roots, publicIDs...))
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// CanReadDocument 判断用户能否读取某个文档 (下载、预览、查看详情)
+func (p *AccessPolicy) CanReadDocument(ctx context.Context, userID uint, doc *data.Document) (bool, error) {
+	scope, err := p.Scope(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return scope.AllowsDocument(doc), nil
+}
+
+// CanManageDocument 判断用户能否修改 / 删除某个文档: 上传者本人、管理员 (含本租户的组织管理员)，
+// 以及所在知识库的 editor 以上；都只限文档所在的租户
+func (p *AccessPolicy) CanManageDocument(ctx context.Context, userID uint, doc *data.Document) (bool, error) {
+	user, err := p.Caller(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if !sameTenant(user, doc.OrganizationID) {
+		return false, nil
+	}
+	if doc.OwnerID == userID || isTenantAdmin(user, doc.OrganizationID) {
+		return true, nil
+	}
+	if doc.KnowledgeBaseID == 0 {
+		return false, nil
+	}
+	return p.HasKnowledgeBaseRole(ctx, userID, doc.KnowledgeBaseID, data.RoleEditor)
+}
+
+// CanReadKnowledgeBase 判断用户能否看到某个知识库，规则与文档相同
+func (p *AccessPolicy) CanReadKnowledgeBase(ctx context.Context, userID uint, kb *data.KnowledgeBase) (bool, error) {
+	scope, err := p.Scope(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return scope.AllowsKnowledgeBase(kb), nil
+}
+
+// CanWriteKnowledgeBase 判断用户能否向知识库上传文档、新建子文件夹 (editor 以上)
+func (p *AccessPolicy) CanWriteKnowledgeBase(ctx context.Context, userID uint, kb *data.KnowledgeBase) (bool, error) {
+	return p.HasKnowledgeBaseRole(ctx, userID, kb.ID, data.RoleEditor)
+}
+
+// CanManageKnowledgeBase 判断用户能否修改 / 移动 / 删除知识库、管理授权 (manager)
+func (p *AccessPolicy) CanManageKnowledgeBase(ctx context.Context, userID uint, kb *data.KnowledgeBase) (bool, error) {
+	return p.HasKnowledgeBaseRole(ctx, userID, kb.ID, data.RoleManager)
+}
+
+// HasKnowledgeBaseRole 判断用户在知识库上的角色是否不低于 minRole
+func (p *AccessPolicy) HasKnowledgeBaseRole(ctx context.Context, userID, kbID uint, minRole string) (bool, error) {
+	role, err := p.KnowledgeBaseRole(ctx, userID, kbID)
+	if err != nil {
+		return false, err
+	}
+	return data.RoleRank(role) >= data.RoleRank(minRole), nil
+}
+
+// KnowledgeBaseRole 计算用户在知识库上的有效角色，没有任何权限时返回空串
+//   - 其他租户的知识库: 无权限 (平台管理员除外)
+//   - 管理员、本租户的组织管理员、知识库或任一上级的创建者: manager
+//   - 否则取知识库及所有上级上授予本人或所在用户组的最高角色
+//   - 没有授权但可见 (同组织成员，或默认租户里的公开知识库): viewer
+func (p *AccessPolicy) KnowledgeBaseRole(ctx context.Context, userID, kbID uint) (string, error) {
+	user, err := p.Caller(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	chain, err := p.data.KnowledgeBaseAncestors(ctx, kbID)
+	if err != nil {
+		return "", err
+	}
+	if !sameTenant(user, chain[0].OrganizationID) {
+		return "", nil
+	}
+	if isTenantAdmin(user, chain[0].OrganizationID) {
+		return data.RoleManager, nil
+	}
+	ids := make([]uint, 0, len(chain))
+	for _, kb := range chain {
+		if kb.OwnerID == userID {
+			return data.RoleManager, nil
+		}
+		ids = append(ids, kb.ID)
+	}
+
+	groupIDs, err := p.data.UserGroupIDs(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	grants, err := p.data.GrantsFor(ctx, ids, userID, groupIDs)
+	if err != nil {
+		return "", err
+	}
+	role := ""
+	for _, g := range grants {
+		if data.RoleRank(g.Role) > data.RoleRank(role) {
+			role = g.Role
+		}
+	}
+	if role != "" {
+		return role, nil
+	}
+
+	readable, err := p.CanReadKnowledgeBase(ctx, userID, &chain[0])
+	if err != nil || !readable {
+		return "", err
+	}
+	return data.RoleViewer, nil
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	pb "Chimera-RAG/backend-go/api/rag/v1"
+	"Chimera-RAG/backend-go/internal/data"
+)
+
+// chunkAuditLimit 修订记录接口最多返回的条数
+const chunkAuditLimit = 200
+
+// ChunkService 切片浏览与人工修订
+// 查看需要文档读权限，修改需要管理权限 (上传者或管理员)；
+// 改动先落切片表并记审计，再重新向量化写入所有模型的集合。
+// 向量化失败时切片表已经是新内容: 编辑、排除用相同参数重试即可补齐；
+// 合并、拆分不能重复提交 (原切片已经不在了)，调用 Resync 按切片表重新同步
+type ChunkService struct {
+	grpcClient pb.LLMServiceClient
+	data       *data.Data
+	policy     *AccessPolicy
+}
+
+func NewChunkService(client pb.LLMServiceClient, d *data.Data, policy *AccessPolicy) *ChunkService {
+	return &ChunkService{grpcClient: client, data: d, policy: policy}
+}
+
+// readable 查找文档并校验读权限
+func (s *ChunkService) readable(ctx context.Context, userID, docID uint) (*data.Document, error) {
+	doc, err := s.data.GetDocument(ctx, docID)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.policy.CanReadDocument(ctx, userID, doc)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrForbidden
+	}
+	return doc, nil
+}
+
+// editable 查找文档并校验管理权限；解析中的文档切片随时会被替换，不允许修改
+func (s *ChunkService) editable(ctx context.Context, userID, docID uint) (*data.Document, error) {
+	doc, err := s.data.GetDocument(ctx, docID)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.policy.CanManageDocument(ctx, userID, doc)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrForbidden
+	}
+	if doc.Status != data.DocStatusSuccess {
+		return nil, fmt.Errorf("%w: 文档状态为 %s，处理完成后才能修改切片", data.ErrChunkConflict, doc.Status)
+	}
+	return doc, nil
+}
+
+// embed 调用 AI Service 用指定模型向量化
+func (s *ChunkService) embed(ctx context.Context, model, text string) ([]float32, error) {
+	resp, err := s.grpcClient.EmbedData(ctx, &pb.EmbedRequest{Data: &pb.EmbedRequest_Text{Text: text}, Model: model})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Vector, nil
+}
+
+// List 按顺序列出文档的全部切片 (含被排除的)
+func (s *ChunkService) List(ctx context.Context, userID, docID uint) ([]data.Chunk, error) {
+	if _, err := s.readable(ctx, userID, docID); err != nil {
+		return nil, err
+	}
+	return s.data.ListChunkRecords(ctx, docID)
+}
+
+// Audits 文档的切片修订记录
+func (s *ChunkService) Audits(ctx context.Context, userID, docID uint) ([]data.ChunkAudit, error) {
+	if _, err := s.readable(ctx, userID, docID); err != nil {
+		return nil, err
+	}
+	return s.data.ListChunkAudits(ctx, docID, chunkAuditLimit)
+}
+
+// Edit 修改切片文本并重新向量化
+func (s *ChunkService) Edit(ctx context.Context, userID, docID uint, chunkID, content string) (*data.Chunk, error) {
+	if _, err := s.editable(ctx, userID, docID); err != nil {
+		return nil, err
+	}
+	chunk, err := s.data.UpdateChunkContent(ctx, userID, docID, chunkID, content)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.data.SyncChunkVectors(ctx, []string{chunk.ID}, nil, s.embed); err != nil {
+		return nil, err
+	}
+	return chunk, nil
+}
+
+// SetExcluded 排除或恢复切片 (按 ID 或页码范围)，返回命中的切片 ID
+func (s *ChunkService) SetExcluded(ctx context.Context, userID, docID uint, ex data.ChunkExclusion) ([]string, error) {
+	if _, err := s.editable(ctx, userID, docID); err != nil {
+		return nil, err
+	}
+	ids, err := s.data.SetChunksExcluded(ctx, userID, docID, ex)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.data.SyncChunkExclusion(ctx, ids, ex.Excluded); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// Merge 合并相邻的两个切片
+func (s *ChunkService) Merge(ctx context.Context, userID, docID uint, firstID, secondID string) (*data.Chunk, error) {
+	if _, err := s.editable(ctx, userID, docID); err != nil {
+		return nil, err
+	}
+	merged, err := s.data.MergeChunks(ctx, userID, docID, firstID, secondID)
+	if err != nil {
+		return nil, err
+	}
+	removed := secondID
+	if removed == merged.ID {
+		removed = firstID
+	}
+	if err := s.data.SyncChunkVectors(ctx, []string{merged.ID}, []string{removed}, s.embed); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// Split 在 offset (字符数) 处拆分切片
+func (s *ChunkService) Split(ctx context.Context, userID, docID uint, chunkID string, offset int) ([]data.Chunk, error) {
+	if _, err := s.editable(ctx, userID, docID); err != nil {
+		return nil, err
+	}
+	parts, err := s.data.SplitChunk(ctx, userID, docID, chunkID, offset)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.data.SyncChunkVectors(ctx, []string{parts[0].ID, parts[1].ID}, nil, s.embed); err != nil {
+		return nil, err
+	}
+	return parts, nil
+}
+
+// ResyncResult 重新同步的结果
+type ResyncResult struct {
+	Synced  int `json:"synced"`
+	Removed int `json:"removed"`
+}
+
+// Resync 按切片表重新同步文档的向量与关键词索引，用于修订后向量同步失败的补救
+func (s *ChunkService) Resync(ctx context.Context, userID, docID uint) (*ResyncResult, error) {
+	if _, err := s.editable(ctx, userID, docID); err != nil {
+		return nil, err
+	}
+	synced, removed, err := s.data.ResyncDocumentChunks(ctx, docID, s.embed)
+	if err != nil {
+		return nil, err
+	}
+	return &ResyncResult{Synced: synced, Removed: removed}, nil
+}
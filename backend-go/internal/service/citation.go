@@ -0,0 +1,112 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"Chimera-RAG/backend-go/internal/biz"
+)
+
+// citationPattern 匹配 LLM 输出的 <<文件名|页码>> 引用标记
+var citationPattern = regexp.MustCompile(`<<\s*([^<>|]+?)\s*\|\s*(\d+)\s*>>`)
+
+// citationTracker 在流式回答中识别引用标记，并解析到检索来源
+// 标记可能被拆在多个 delta 里，所以对累计文本扫描，只处理新出现的完整标记
+type citationTracker struct {
+	sources []biz.SourceDoc
+	answer  strings.Builder
+	scanned int
+	seen    map[int]bool
+}
+
+func newCitationTracker(sources []biz.SourceDoc) *citationTracker {
+	return &citationTracker{sources: sources, seen: make(map[int]bool)}
+}
+
+// Feed 追加一段回答，返回新识别到的引用 (同一来源只返回一次)
+func (t *citationTracker) Feed(delta string) []biz.CitationData {
+	t.answer.WriteString(delta)
+	text := t.answer.String()
+
+	var found []biz.CitationData
+	base := t.scanned
+	for _, loc := range citationPattern.FindAllStringSubmatchIndex(text[base:], -1) {
+		start, end := base+loc[0], base+loc[1]
+		name := text[base+loc[2] : base+loc[3]]
+		page, _ := strconv.Atoi(text[base+loc[4] : base+loc[5]])
+
+		idx := resolveSource(t.sources, name, int32(page))
+		if idx >= 0 && !t.seen[idx] {
+			t.seen[idx] = true
+			// 只按文件名匹配上时，以标记里的页码为准，方便前端跳页
+			src := t.sources[idx]
+			src.Page = int32(page)
+			found = append(found, biz.CitationData{
+				Marker:      text[start:end],
+				SourceIndex: idx,
+				Source:      src,
+			})
+		}
+		t.scanned = end
+	}
+	return found
+}
+
+// Answer 返回累计的完整回答
+func (t *citationTracker) Answer() string {
+	return t.answer.String()
+}
+
+// resolveSource 先按 文件名+页码 精确匹配，找不到再只按文件名匹配，返回来源下标
+func resolveSource(sources []biz.SourceDoc, name string, page int32) int {
+	fallback := -1
+	for i, src := range sources {
+		if src.FileName != name && src.Title != name {
+			continue
+		}
+		if src.Page == page {
+			return i
+		}
+		if fallback < 0 {
+			fallback = i
+		}
+	}
+	return fallback
+}
+
+// Cited 返回被引用过的来源下标
+func (t *citationTracker) Cited() map[int]bool {
+	return t.seen
+}
+
+// inlineCitations 将正文中的 <<文件名|页码>> 替换为 [n] 角标 (导出用)
+// 返回替换后的正文，以及按角标顺序排列的来源
+func inlineCitations(content string, sources []biz.SourceDoc) (string, []biz.SourceDoc) {
+	var cited []biz.SourceDoc
+	numbers := make(map[string]int)
+
+	out := citationPattern.ReplaceAllStringFunc(content, func(marker string) string {
+		m := citationPattern.FindStringSubmatch(marker)
+		name := m[1]
+		page, _ := strconv.Atoi(m[2])
+
+		idx := resolveSource(sources, name, int32(page))
+		if idx < 0 {
+			return fmt.Sprintf("[%s P%d]", name, page)
+		}
+		src := sources[idx]
+		src.Page = int32(page)
+
+		key := fmt.Sprintf("%d|%s|%d", src.DocumentID, src.FileName, src.Page)
+		n, ok := numbers[key]
+		if !ok {
+			cited = append(cited, src)
+			n = len(cited)
+			numbers[key] = n
+		}
+		return fmt.Sprintf("[%d]", n)
+	})
+	return out, cited
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+
+	"Chimera-RAG/backend-go/internal/biz"
+)
+
+var testSources = []biz.SourceDoc{
+	{DocumentID: 1, FileName: "org-1/a.pdf", Title: "安全规范.pdf", Page: 3},
+	{DocumentID: 1, FileName: "org-1/a.pdf", Title: "安全规范.pdf", Page: 7},
+	{DocumentID: 2, FileName: "org-1/b.pdf", Title: "MSDS.pdf", Page: 1},
+}
+
+func TestCitationTrackerFeed(t *testing.T) {
+	tests := []struct {
+		name   string
+		deltas []string
+		// 每个 delta 新识别到的引用，按 "来源下标:页码" 表示
+		want [][]string
+	}{
+		{
+			name:   "按标题和页码精确匹配",
+			deltas: []string{"动火作业分为三级<<安全规范.pdf|7>>。"},
+			want:   [][]string{{"1:7"}},
+		},
+		{
+			name:   "按对象名匹配，允许多余空格",
+			deltas: []string{"见 << org-1/b.pdf | 1 >>"},
+			want:   [][]string{{"2:1"}},
+		},
+		{
+			name:   "标记被拆在多个 delta 里",
+			deltas: []string{"结论<<安全", "规范.pdf|", "3>>，", "另见<<MSDS.pdf|1>>"},
+			want:   [][]string{nil, nil, {"0:3"}, {"2:1"}},
+		},
+		{
+			name:   "页码对不上时按文件名匹配，以标记页码为准",
+			deltas: []string{"<<安全规范.pdf|12>>"},
+			want:   [][]string{{"0:12"}},
+		},
+		{
+			name:   "同一来源只返回一次",
+			deltas: []string{"<<MSDS.pdf|1>>", "再次<<MSDS.pdf|1>>"},
+			want:   [][]string{{"2:1"}, nil},
+		},
+		{
+			name:   "未知文件不返回",
+			deltas: []string{"<<不存在.pdf|1>>"},
+			want:   [][]string{nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newCitationTracker(testSources)
+			for i, delta := range tt.deltas {
+				var got []string
+				for _, c := range tracker.Feed(delta) {
+					if c.Source.Title != testSources[c.SourceIndex].Title {
+						t.Errorf("Source 与 SourceIndex 不一致: %+v", c)
+					}
+					got = append(got, formatCitation(c.SourceIndex, c.Source.Page))
+				}
+				if !slices.Equal(got, tt.want[i]) {
+					t.Errorf("delta %d: got %v, want %v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCitationTrackerAnswer(t *testing.T) {
+	tracker := newCitationTracker(testSources)
+	tracker.Feed("见<<MSDS")
+	tracker.Feed(".pdf|1>>")
+	if got, want := tracker.Answer(), "见<<MSDS.pdf|1>>"; got != want {
+		t.Errorf("Answer = %q, want %q", got, want)
+	}
+	if !tracker.Cited()[2] || len(tracker.Cited()) != 1 {
+		t.Errorf("Cited = %v, want map[2:true]", tracker.Cited())
+	}
+}
+
+func TestInlineCitations(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		// 按角标顺序的 "文档ID:页码"
+		wantCited []string
+	}{
+		{
+			name:      "按出现顺序编号",
+			content:   "分级<<安全规范.pdf|7>>，闪点<<MSDS.pdf|1>>。",
+			want:      "分级[1]，闪点[2]。",
+			wantCited: []string{"1:7", "2:1"},
+		},
+		{
+			name:      "同一文件同一页复用角标",
+			content:   "<<MSDS.pdf|1>> 和 <<org-1/b.pdf|1>>",
+			want:      "[1] 和 [1]",
+			wantCited: []string{"2:1"},
+		},
+		{
+			name:      "同一文件不同页分别编号",
+			content:   "<<安全规范.pdf|3>><<安全规范.pdf|7>><<安全规范.pdf|9>>",
+			want:      "[1][2][3]",
+			wantCited: []string{"1:3", "1:7", "1:9"},
+		},
+		{
+			name:      "未知文件保留文件名和页码",
+			content:   "见<<不存在.pdf|4>>",
+			want:      "见[不存在.pdf P4]",
+			wantCited: nil,
+		},
+		{
+			name:      "没有标记",
+			content:   "普通文本 <<不完整",
+			want:      "普通文本 <<不完整",
+			wantCited: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, cited := inlineCitations(tt.content, testSources)
+			if got != tt.want {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+			var gotCited []string
+			for _, src := range cited {
+				gotCited = append(gotCited, formatCitation(int(src.DocumentID), src.Page))
+			}
+			if !slices.Equal(gotCited, tt.wantCited) {
+				t.Errorf("cited = %v, want %v", gotCited, tt.wantCited)
+			}
+		})
+	}
+}
+
+func formatCitation(n int, page int32) string {
+	return strconv.Itoa(n) + ":" + strconv.Itoa(int(page))
+}
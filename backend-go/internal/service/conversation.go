@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	pb "Chimera-RAG/backend-go/api/rag/v1"
+	"Chimera-RAG/backend-go/internal/biz"
+	"Chimera-RAG/backend-go/internal/data"
+)
+
+// 历史消息带入 Prompt 时，每条最多保留的字符数
+const historyMsgLen = 500
+
+// 会话标题取首个问题的前若干个字符
+const titleLen = 50
+
+// formatHistory 将历史消息格式化为 Prompt 片段
+func formatHistory(history []data.Message) string {
+	var b strings.Builder
+	for _, m := range history {
+		role := "用户"
+		if m.Role == "assistant" {
+			role = "助手"
+		}
+		b.WriteString(fmt.Sprintf("%s: %s\n", role, truncateRunes(m.Content, historyMsgLen)))
+	}
+	return b.String()
+}
+
+// rewriteQuery 结合对话历史，把 "那第四条呢?" 这类追问改写成可以独立检索的问题
+// 改写失败时退回原始问题，不影响问答
+func (s *RagService) rewriteQuery(ctx context.Context, history []data.Message, query string) string {
+	if !s.queryRewrite || len(history) == 0 {
+		return query
+	}
+
+	prompt := fmt.Sprintf(`请根据对话历史，把用户的最新问题改写成一个可以独立用于检索的完整问题。
+只输出改写后的问题本身，不要解释，不要加引用标记。如果最新问题本身已经完整，原样输出。
+
+对话历史：
+%s
+最新问题：%s`, formatHistory(history), query)
+
+	stream, err := s.grpcClient.AskStream(ctx, &pb.AskRequest{Query: prompt})
+	if err != nil {
+		log.Printf("⚠️ 问题改写失败，使用原问题: %v", err)
+		return query
+	}
+
+	var b strings.Builder
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Printf("⚠️ 问题改写失败，使用原问题: %v", err)
+			return query
+		}
+		b.WriteString(resp.AnswerDelta)
+	}
+
+	// 只取第一行，防止模型多说
+	rewritten := strings.TrimSpace(strings.SplitN(strings.TrimSpace(b.String()), "\n", 2)[0])
+	if rewritten == "" {
+		return query
+	}
+	return rewritten
+}
+
+// saveTurn 持久化一轮问答
+// 使用独立的 context，前端中途断开也要把已生成的内容存下来
+func (s *RagService) saveTurn(ctx context.Context, conv *data.Conversation, question, answer *data.Message) {
+	if err := s.Data.SaveTurn(context.WithoutCancel(ctx), conv.ID, question, answer); err != nil {
+		log.Printf("⚠️ 保存会话 %s 失败: %v", conv.SessionID, err)
+	}
+}
+
+// toMessageSources 将本轮来源转换为入库格式，并标记被引用的来源
+func toMessageSources(sources []biz.SourceDoc, cited map[int]bool) data.MessageSources {
+	out := make(data.MessageSources, 0, len(sources))
+	for i, src := range sources {
+		out = append(out, data.MessageSource{
+			DocumentID: src.DocumentID,
+			FileName:   src.FileName,
+			Title:      src.Title,
+			Page:       src.Page,
+			Score:      src.Score,
+			Snippet:    src.Snippet,
+			Cited:      cited[i],
+		})
+	}
+	return out
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
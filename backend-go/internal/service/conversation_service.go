@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"Chimera-RAG/backend-go/internal/biz"
+	"Chimera-RAG/backend-go/internal/data"
+)
+
+// 会话导出格式
+const (
+	ExportMarkdown = "markdown"
+	ExportJSON     = "json"
+)
+
+// ConversationService 会话管理 (列表、详情、重命名、删除、导出)
+// 只能操作自己的会话，越权访问统一返回 data.ErrConversationNotFound
+type ConversationService struct {
+	data *data.Data
+}
+
+func NewConversationService(d *data.Data) *ConversationService {
+	return &ConversationService{data: d}
+}
+
+// List 分页列出会话
+func (s *ConversationService) List(ctx context.Context, userID uint, page, pageSize int) (*biz.ConversationPage, error) {
+	convs, total, err := s.data.ListConversations(ctx, userID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]biz.ConversationSummary, 0, len(convs))
+	for _, c := range convs {
+		items = append(items, biz.ConversationSummary{
+			SessionID: c.SessionID,
+			Title:     c.Title,
+			CreatedAt: c.CreatedAt,
+			UpdatedAt: c.UpdatedAt,
+		})
+	}
+	return &biz.ConversationPage{Items: items, Total: total, Page: page, PageSize: pageSize}, nil
+}
+
+// Get 获取会话完整记录
+func (s *ConversationService) Get(ctx context.Context, userID uint, sessionID string) (*data.Conversation, error) {
+	return s.data.GetConversation(ctx, sessionID, userID)
+}
+
+// Rename 重命名会话
+func (s *ConversationService) Rename(ctx context.Context, userID uint, sessionID, title string) error {
+	return s.data.RenameConversation(ctx, sessionID, userID, title)
+}
+
+// Delete 删除会话 (软删除)
+func (s *ConversationService) Delete(ctx context.Context, userID uint, sessionID string) error {
+	return s.data.DeleteConversation(ctx, sessionID, userID)
+}
+
+// Export 导出会话，引用标记会被替换为 [n] 角标并附上来源列表
+func (s *ConversationService) Export(ctx context.Context, userID uint, sessionID string) (*biz.ConversationExport, error) {
+	conv, err := s.data.GetConversation(ctx, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &biz.ConversationExport{
+		SessionID:  conv.SessionID,
+		Title:      conv.Title,
+		CreatedAt:  conv.CreatedAt,
+		ExportedAt: time.Now(),
+		Messages:   make([]biz.ExportedMessage, 0, len(conv.Messages)),
+	}
+	for _, m := range conv.Messages {
+		msg := biz.ExportedMessage{Role: m.Role, Content: m.Content, CreatedAt: m.CreatedAt}
+		if m.Role == "assistant" {
+			msg.Content, msg.Citations = inlineCitations(m.Content, fromMessageSources(m.Sources))
+			if m.Error != "" {
+				msg.Content += fmt.Sprintf("\n\n(出错: %s)", m.Error)
+			}
+		}
+		export.Messages = append(export.Messages, msg)
+	}
+	return export, nil
+}
+
+// RenderMarkdown 将导出结果渲染为 Markdown
+func RenderMarkdown(export *biz.ConversationExport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", export.Title)
+	fmt.Fprintf(&b, "- 会话 ID: `%s`\n", export.SessionID)
+	fmt.Fprintf(&b, "- 创建时间: %s\n", export.CreatedAt.Format(time.DateTime))
+	fmt.Fprintf(&b, "- 导出时间: %s\n", export.ExportedAt.Format(time.DateTime))
+
+	for _, m := range export.Messages {
+		role := "👤 用户"
+		if m.Role == "assistant" {
+			role = "🤖 助手"
+		}
+		fmt.Fprintf(&b, "\n## %s (%s)\n\n%s\n", role, m.CreatedAt.Format(time.DateTime), m.Content)
+
+		if len(m.Citations) > 0 {
+			b.WriteString("\n**参考来源**\n\n")
+			for i, c := range m.Citations {
+				name := c.Title
+				if name == "" {
+					name = c.FileName
+				}
+				fmt.Fprintf(&b, "%d. %s (P%d): %s\n", i+1, name, c.Page, strings.ReplaceAll(c.Snippet, "\n", " "))
+			}
+		}
+	}
+	return b.String()
+}
+
+// fromMessageSources 入库格式 -> 展示格式
+func fromMessageSources(sources data.MessageSources) []biz.SourceDoc {
+	out := make([]biz.SourceDoc, 0, len(sources))
+	for _, s := range sources {
+		out = append(out, biz.SourceDoc{
+			DocumentID: s.DocumentID,
+			FileName:   s.FileName,
+			Title:      s.Title,
+			Page:       s.Page,
+			Score:      s.Score,
+			Snippet:    s.Snippet,
+		})
+	}
+	return out
+}
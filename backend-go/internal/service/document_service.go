@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+
+	"Chimera-RAG/backend-go/internal/data"
+)
+
+// DocumentService 文档管理 (处理状态、进度订阅)
+type DocumentService struct {
+	data   *data.Data
+	policy *AccessPolicy
+}
+
+func NewDocumentService(d *data.Data, policy *AccessPolicy) *DocumentService {
+	return &DocumentService{data: d, policy: policy}
+}
+
+// readable 查找文档并校验读权限，无权访问时返回 ErrForbidden
+func (s *DocumentService) readable(ctx context.Context, userID, docID uint) (*data.Document, error) {
+	doc, err := s.data.GetDocument(ctx, docID)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.policy.CanReadDocument(ctx, userID, doc)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrForbidden
+	}
+	return doc, nil
+}
+
+// Delete 删除文档: 先软删除 (立即对所有人不可见)，再投递后台任务清理向量、原文件和数据库记录
+// 已软删除但尚未清理完的文档再次删除时会重新投递任务，因此可以安全重试
+func (s *DocumentService) Delete(ctx context.Context, userID, docID uint) (*data.Job, error) {
+	doc, err := s.data.GetDocumentUnscoped(ctx, docID)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.policy.CanManageDocument(ctx, userID, doc)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrForbidden
+	}
+
+	if !doc.DeletedAt.Valid {
+		if err := s.data.SoftDeleteDocument(ctx, doc.ID); err != nil {
+			return nil, err
+		}
+		// 删掉的是最新版本时，上一版本重新参与检索
+		if doc.IsLatest && doc.PreviousVersionID != nil {
+			if err := s.data.RestoreLatest(ctx, *doc.PreviousVersionID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	job := data.NewJob(ctx, data.JobDelete, doc)
+	if err := s.data.EnqueueJob(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// DocumentListOptions 文档列表参数，KnowledgeBaseID 为 nil 时不按知识库过滤
+type DocumentListOptions struct {
+	data.DocumentQuery
+	KnowledgeBaseID *uint
+	// Recursive 同时列出子文件夹中的文档
+	Recursive bool
+}
+
+// DocumentPage 一页文档，NextCursor 为空表示没有下一页
+type DocumentPage struct {
+	Documents  []data.Document `json:"documents"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// List 按条件列出调用者可见的文档
+func (s *DocumentService) List(ctx context.Context, userID uint, opts DocumentListOptions) (*DocumentPage, error) {
+	scope, err := s.policy.Scope(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	q := opts.DocumentQuery
+	q.Access = scope
+
+	if opts.KnowledgeBaseID != nil {
+		q.KnowledgeBaseIDs = []uint{*opts.KnowledgeBaseID}
+		if opts.Recursive && *opts.KnowledgeBaseID != 0 {
+			if q.KnowledgeBaseIDs, err = s.data.DescendantKnowledgeBaseIDs(ctx, q.KnowledgeBaseIDs); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	docs, next, err := s.data.ListDocuments(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if docs == nil {
+		docs = []data.Document{}
+	}
+	return &DocumentPage{Documents: docs, NextCursor: next}, nil
+}
+
+// GetStatus 查询文档当前处理状态
+func (s *DocumentService) GetStatus(ctx context.Context, userID, docID uint) (*data.DocumentProgress, error) {
+	doc, err := s.readable(ctx, userID, docID)
+	if err != nil {
+		return nil, err
+	}
+	return data.ProgressOf(doc), nil
+}
+
+// WatchProgress 订阅文档处理进度
+// 第一条是当前状态，之后每次状态或进度变化推送一条，进入终态 (success / failed) 后关闭
+func (s *DocumentService) WatchProgress(ctx context.Context, userID, docID uint) (<-chan *data.DocumentProgress, error) {
+	if _, err := s.readable(ctx, userID, docID); err != nil {
+		return nil, err
+	}
+
+	// 先订阅再查当前状态，两者之间发生的变化不会丢
+	ctx, cancel := context.WithCancel(ctx)
+	updates, err := s.data.SubscribeDocumentProgress(ctx, docID)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	doc, err := s.data.GetDocument(ctx, docID)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	out := make(chan *data.DocumentProgress, 8)
+	go func() {
+		defer close(out)
+		defer cancel()
+
+		current := data.ProgressOf(doc)
+		out <- current
+		if current.Done() {
+			return
+		}
+		for p := range updates {
+			select {
+			case out <- p:
+			case <-ctx.Done():
+				return
+			}
+			if p.Done() {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
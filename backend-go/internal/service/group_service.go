@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"Chimera-RAG/backend-go/internal/data"
+)
+
+// GroupService 用户组管理 (组织管理员)，用户组用于批量授权知识库
+// 用户组属于调用者所在的租户，看不到也改不了其他租户的用户组
+type GroupService struct {
+	data   *data.Data
+	policy *AccessPolicy
+}
+
+func NewGroupService(d *data.Data, policy *AccessPolicy) *GroupService {
+	return &GroupService{data: d, policy: policy}
+}
+
+// GroupDetail 用户组及其成员
+type GroupDetail struct {
+	data.Group
+	MemberIDs []uint `json:"member_ids"`
+}
+
+// tenantGroup 查找调用者租户内的用户组，其他租户的视为不存在
+// 调用者必须 (以数据库为准) 仍是本租户的管理员
+func (s *GroupService) tenantGroup(ctx context.Context, userID, id uint) (*data.Group, error) {
+	user, err := s.policy.TenantAdmin(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	g, err := s.data.GetGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if g.OrganizationID != user.OrganizationID {
+		return nil, data.ErrGroupNotFound
+	}
+	return g, nil
+}
+
+// List 租户内的全部用户组
+func (s *GroupService) List(ctx context.Context, userID uint) ([]data.Group, error) {
+	user, err := s.policy.TenantAdmin(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.data.ListGroups(ctx, user.OrganizationID)
+}
+
+// Create 在调用者的租户内创建用户组
+func (s *GroupService) Create(ctx context.Context, userID uint, name, description string) (*data.Group, error) {
+	user, err := s.policy.TenantAdmin(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	g := &data.Group{OrganizationID: user.OrganizationID, Name: name, Description: description}
+	if err := s.data.CreateGroup(ctx, g); err != nil {
+		return nil, err
+	}
+	log.Printf("👥 组织 %d 创建用户组 %d (%s)", g.OrganizationID, g.ID, g.Name)
+	return g, nil
+}
+
+// Get 用户组详情
+func (s *GroupService) Get(ctx context.Context, userID, id uint) (*GroupDetail, error) {
+	g, err := s.tenantGroup(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.data.GroupMemberIDs(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if members == nil {
+		members = []uint{}
+	}
+	return &GroupDetail{Group: *g, MemberIDs: members}, nil
+}
+
+// Delete 删除用户组，授权给该组的知识库权限一并收回
+func (s *GroupService) Delete(ctx context.Context, userID, id uint) error {
+	if _, err := s.tenantGroup(ctx, userID, id); err != nil {
+		return err
+	}
+	if err := s.data.DeleteGroup(ctx, id); err != nil {
+		return err
+	}
+	log.Printf("👥 删除用户组 %d", id)
+	return nil
+}
+
+// AddMember 把本租户的用户加入用户组
+func (s *GroupService) AddMember(ctx context.Context, userID, groupID, memberID uint) error {
+	g, err := s.tenantGroup(ctx, userID, groupID)
+	if err != nil {
+		return err
+	}
+	member, err := s.data.GetUser(ctx, memberID)
+	if err != nil || member.OrganizationID != g.OrganizationID {
+		return fmt.Errorf("%w: 用户 %d 不存在", data.ErrInvalidGrant, memberID)
+	}
+	return s.data.AddGroupMember(ctx, groupID, memberID)
+}
+
+// RemoveMember 把用户移出用户组
+func (s *GroupService) RemoveMember(ctx context.Context, userID, groupID, memberID uint) error {
+	if _, err := s.tenantGroup(ctx, userID, groupID); err != nil {
+		return err
+	}
+	return s.data.RemoveGroupMember(ctx, groupID, memberID)
+}
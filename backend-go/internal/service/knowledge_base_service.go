@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"Chimera-RAG/backend-go/internal/data"
+)
+
+// 知识库节点的汇总状态 (含子文件夹)
+const (
+	KnowledgeBaseEmpty      = "empty"      // 没有文档
+	KnowledgeBaseProcessing = "processing" // 有文档在排队或解析中
+	KnowledgeBaseFailed     = "failed"     // 没有在处理的，但有解析失败的
+	KnowledgeBaseReady      = "ready"      // 全部解析成功
+)
+
+// KnowledgeBaseService 知识库 (文件夹树) 管理
+type KnowledgeBaseService struct {
+	data   *data.Data
+	policy *AccessPolicy
+}
+
+func NewKnowledgeBaseService(d *data.Data, policy *AccessPolicy) *KnowledgeBaseService {
+	return &KnowledgeBaseService{data: d, policy: policy}
+}
+
+// KnowledgeBaseNode 树上的一个节点
+// DocumentCount / StatusCounts 只统计本节点，TotalDocuments / Status 包含整棵子树
+type KnowledgeBaseNode struct {
+	data.KnowledgeBase
+	data.KnowledgeBaseStats
+	TotalDocuments int64                `json:"total_documents"`
+	Status         string               `json:"status"`
+	Children       []*KnowledgeBaseNode `json:"children"`
+}
+
+// KnowledgeBaseInput 创建 / 修改知识库的参数，修改时 nil 字段保持不变
+type KnowledgeBaseInput struct {
+	Name        *string
+	Description *string
+	Type        string
+	ParentID    *uint
+	IsPublic    *bool
+}
+
+// authorize 查找知识库并校验用户角色不低于 minRole
+func (s *KnowledgeBaseService) authorize(ctx context.Context, userID, id uint, minRole string) (*data.KnowledgeBase, error) {
+	kb, err := s.data.GetKnowledgeBase(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.policy.HasKnowledgeBaseRole(ctx, userID, kb.ID, minRole)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrForbidden
+	}
+	return kb, nil
+}
+
+// Tree 返回用户可见的知识库树
+// 父节点不可见的节点 (例如公开知识库里别人的子文件夹被单独公开) 作为根节点返回
+func (s *KnowledgeBaseService) Tree(ctx context.Context, userID uint) ([]*KnowledgeBaseNode, error) {
+	scope, err := s.policy.Scope(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	kbs, err := s.data.ListKnowledgeBases(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(kbs))
+	for _, kb := range kbs {
+		ids = append(ids, kb.ID)
+	}
+	stats, err := s.data.KnowledgeBaseStats(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[uint]*KnowledgeBaseNode, len(kbs))
+	for _, kb := range kbs {
+		node := &KnowledgeBaseNode{KnowledgeBase: kb, Children: []*KnowledgeBaseNode{}}
+		if st := stats[kb.ID]; st != nil {
+			node.KnowledgeBaseStats = *st
+		} else {
+			node.StatusCounts = map[string]int64{}
+		}
+		nodes[kb.ID] = node
+	}
+
+	// kbs 已按名称排序，按原顺序挂到父节点下，子节点也是有序的
+	roots := make([]*KnowledgeBaseNode, 0)
+	for _, kb := range kbs {
+		node := nodes[kb.ID]
+		if kb.ParentID != nil {
+			if parent, ok := nodes[*kb.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	for _, root := range roots {
+		summarize(root)
+	}
+	return roots, nil
+}
+
+// summarize 自底向上汇总子树的文档数与状态，返回子树中各状态的文档数
+func summarize(node *KnowledgeBaseNode) map[string]int64 {
+	counts := make(map[string]int64, len(node.StatusCounts))
+	for status, n := range node.StatusCounts {
+		counts[status] += n
+	}
+	for _, child := range node.Children {
+		for status, n := range summarize(child) {
+			counts[status] += n
+		}
+	}
+
+	var total int64
+	for _, n := range counts {
+		total += n
+	}
+	node.TotalDocuments = total
+
+	switch {
+	case total == 0:
+		node.Status = KnowledgeBaseEmpty
+	case counts[data.DocStatusPending]+counts[data.DocStatusParsing]+counts[data.DocStatusEmbedding] > 0:
+		node.Status = KnowledgeBaseProcessing
+	case counts[data.DocStatusFailed] > 0:
+		node.Status = KnowledgeBaseFailed
+	default:
+		node.Status = KnowledgeBaseReady
+	}
+	return counts
+}
+
+// Create 创建知识库，放在父节点下时需要父节点的 editor 以上角色
+func (s *KnowledgeBaseService) Create(ctx context.Context, userID uint, in KnowledgeBaseInput) (*data.KnowledgeBase, error) {
+	if in.ParentID != nil {
+		if _, err := s.authorize(ctx, userID, *in.ParentID, data.RoleEditor); err != nil {
+			return nil, err
+		}
+	}
+	user, err := s.policy.Caller(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	// 根节点属于创建者所在的租户，子文件夹跟随父节点 (见 data.CreateKnowledgeBase)
+	kb := &data.KnowledgeBase{
+		Type:           in.Type,
+		ParentID:       in.ParentID,
+		OwnerID:        userID,
+		OrganizationID: user.OrganizationID,
+	}
+	if kb.Type == "" {
+		kb.Type = data.KnowledgeBaseFolder
+	}
+	if in.Name != nil {
+		kb.Name = *in.Name
+	}
+	if in.Description != nil {
+		kb.Description = *in.Description
+	}
+	if in.IsPublic != nil {
+		kb.IsPublic = *in.IsPublic
+	}
+	if err := s.data.CreateKnowledgeBase(ctx, kb); err != nil {
+		return nil, err
+	}
+	return kb, nil
+}
+
+// Update 修改名称、描述、是否公开 (manager)
+func (s *KnowledgeBaseService) Update(ctx context.Context, userID, id uint, in KnowledgeBaseInput) (*data.KnowledgeBase, error) {
+	if _, err := s.authorize(ctx, userID, id, data.RoleManager); err != nil {
+		return nil, err
+	}
+	fields := map[string]any{}
+	if in.Name != nil {
+		fields["name"] = *in.Name
+	}
+	if in.Description != nil {
+		fields["description"] = *in.Description
+	}
+	if in.IsPublic != nil {
+		fields["is_public"] = *in.IsPublic
+	}
+	if len(fields) > 0 {
+		if err := s.data.UpdateKnowledgeBase(ctx, id, fields); err != nil {
+			return nil, err
+		}
+	}
+	return s.data.GetKnowledgeBase(ctx, id)
+}
+
+// Move 移动到 parentID 下 (nil 表示根)，需要节点本身的 manager 和新父节点的 editor 以上角色
+// 移动后继承的授权随新位置变化
+func (s *KnowledgeBaseService) Move(ctx context.Context, userID, id uint, parentID *uint) (*data.KnowledgeBase, error) {
+	if _, err := s.authorize(ctx, userID, id, data.RoleManager); err != nil {
+		return nil, err
+	}
+	if parentID != nil {
+		if _, err := s.authorize(ctx, userID, *parentID, data.RoleEditor); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.data.MoveKnowledgeBase(ctx, id, parentID); err != nil {
+		return nil, err
+	}
+	return s.data.GetKnowledgeBase(ctx, id)
+}
+
+// Delete 递归删除知识库 (manager): 整棵子树和其中的文档立即不可见，文档的向量和原文件由后台任务清理
+// 返回投递的清理任务数
+func (s *KnowledgeBaseService) Delete(ctx context.Context, userID, id uint) (int, error) {
+	if _, err := s.authorize(ctx, userID, id, data.RoleManager); err != nil {
+		return 0, err
+	}
+	docs, err := s.data.DeleteKnowledgeBaseTree(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	var firstErr error
+	for i := range docs {
+		doc := &docs[i]
+		if err := s.data.EnqueueJob(ctx, data.NewJob(ctx, data.JobDelete, doc)); err != nil {
+			// 文档已软删除，不会再被检索到；清理任务可以通过 DELETE /documents/:id 重新投递
+			log.Printf("⚠️ 投递文档 %d 的清理任务失败: %v", doc.ID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		queued++
+	}
+	if firstErr != nil {
+		return queued, fmt.Errorf("%d 个文档的清理任务投递失败: %w", len(docs)-queued, firstErr)
+	}
+	return queued, nil
+}
+
+// KnowledgeBaseAccess 知识库的授权情况
+// Grants 包括本节点和所有上级上的授权 (上级的授权对本节点同样生效)，MyRole 是调用者的有效角色
+type KnowledgeBaseAccess struct {
+	KnowledgeBaseID uint                      `json:"knowledge_base_id"`
+	OwnerID         uint                      `json:"owner_id"`
+	MyRole          string                    `json:"my_role"`
+	Grants          []data.KnowledgeBaseGrant `json:"grants"`
+}
+
+// Access 列出知识库的授权 (manager)
+func (s *KnowledgeBaseService) Access(ctx context.Context, userID, id uint) (*KnowledgeBaseAccess, error) {
+	kb, err := s.authorize(ctx, userID, id, data.RoleManager)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := s.data.KnowledgeBaseAncestors(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(chain))
+	for _, c := range chain {
+		ids = append(ids, c.ID)
+	}
+	grants, err := s.data.ListGrants(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	role, err := s.policy.KnowledgeBaseRole(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if grants == nil {
+		grants = []data.KnowledgeBaseGrant{}
+	}
+	return &KnowledgeBaseAccess{KnowledgeBaseID: kb.ID, OwnerID: kb.OwnerID, MyRole: role, Grants: grants}, nil
+}
+
+// Grant 把知识库 (含子文件夹) 的角色授予用户或用户组 (manager)，已有授权时改为新角色
+func (s *KnowledgeBaseService) Grant(ctx context.Context, userID, id uint, subjectType string, subjectID uint, role string) (*data.KnowledgeBaseGrant, error) {
+	kb, err := s.authorize(ctx, userID, id, data.RoleManager)
+	if err != nil {
+		return nil, err
+	}
+	if !data.ValidRole(role) {
+		return nil, fmt.Errorf("%w: 未知角色 %q", data.ErrInvalidGrant, role)
+	}
+	switch subjectType {
+	case data.SubjectUser:
+		// 只能授权给同一租户的用户和用户组
+		u, err := s.data.GetUser(ctx, subjectID)
+		if err != nil || u.OrganizationID != kb.OrganizationID {
+			return nil, fmt.Errorf("%w: 用户 %d 不存在", data.ErrInvalidGrant, subjectID)
+		}
+	case data.SubjectGroup:
+		g, err := s.data.GetGroup(ctx, subjectID)
+		if err != nil {
+			return nil, err
+		}
+		if g.OrganizationID != kb.OrganizationID {
+			return nil, data.ErrGroupNotFound
+		}
+	default:
+		return nil, fmt.Errorf("%w: 未知授权主体类型 %q", data.ErrInvalidGrant, subjectType)
+	}
+
+	grant := &data.KnowledgeBaseGrant{
+		KnowledgeBaseID: id,
+		SubjectType:     subjectType,
+		SubjectID:       subjectID,
+		Role:            role,
+		GrantedBy:       userID,
+	}
+	if err := s.data.SaveGrant(ctx, grant); err != nil {
+		return nil, err
+	}
+	log.Printf("🔑 知识库 %d: %s %d 授权为 %s (操作人 %d)", id, subjectType, subjectID, role, userID)
+	return grant, nil
+}
+
+// Revoke 撤销本节点上的授权 (manager)；从上级继承的授权需要在上级撤销
+func (s *KnowledgeBaseService) Revoke(ctx context.Context, userID, id uint, subjectType string, subjectID uint) error {
+	if _, err := s.authorize(ctx, userID, id, data.RoleManager); err != nil {
+		return err
+	}
+	if err := s.data.RevokeGrant(ctx, id, subjectType, subjectID); err != nil {
+		return err
+	}
+	log.Printf("🔑 知识库 %d: 撤销 %s %d 的授权 (操作人 %d)", id, subjectType, subjectID, userID)
+	return nil
+}
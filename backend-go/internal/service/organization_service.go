@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"time"
+
+	"Chimera-RAG/backend-go/internal/data"
+)
+
+// orgKeyPattern 组织 Key 会用作 MinIO 对象前缀，只允许小写字母、数字和连字符
+var orgKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+// OrganizationService 组织 (租户) 管理
+// 平台管理员创建组织、分配用户；组织管理员只能管理本租户的用户
+type OrganizationService struct {
+	data   *data.Data
+	policy *AccessPolicy
+}
+
+func NewOrganizationService(d *data.Data, policy *AccessPolicy) *OrganizationService {
+	return &OrganizationService{data: d, policy: policy}
+}
+
+// OrganizationInfo 调用者所在组织的概况
+type OrganizationInfo struct {
+	ID            uint   `json:"id"`
+	Name          string `json:"name"`
+	Key           string `json:"key"`
+	StoragePrefix string `json:"storage_prefix"`
+	MemberCount   int    `json:"member_count"`
+}
+
+// Current 调用者所在的组织，默认租户 (ID 为 0) 没有组织记录
+func (s *OrganizationService) Current(ctx context.Context, userID uint) (*OrganizationInfo, error) {
+	user, err := s.policy.Caller(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	info := &OrganizationInfo{ID: user.OrganizationID, Name: "default"}
+	if user.OrganizationID != 0 {
+		org, err := s.data.GetOrganization(ctx, user.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+		info.Name, info.Key, info.StoragePrefix = org.Name, org.Key, data.TenantPrefix(org)
+	}
+	members, err := s.data.OrganizationUsers(ctx, user.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	info.MemberCount = len(members)
+	return info, nil
+}
+
+// Users 调用者所在组织的全部用户，角色以数据库为准: 已被降级的组织管理员不能再查看
+func (s *OrganizationService) Users(ctx context.Context, userID uint) ([]data.User, error) {
+	user, err := s.policy.TenantAdmin(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.data.OrganizationUsers(ctx, user.OrganizationID)
+}
+
+// SetUserRole 组织管理员修改本租户用户的角色 (user / org_admin)
+// 不能修改自己，也不能修改平台管理员
+func (s *OrganizationService) SetUserRole(ctx context.Context, userID, targetID uint, role string) error {
+	if role != data.UserRoleUser && role != data.UserRoleOrgAdmin {
+		return fmt.Errorf("%w: 未知角色 %q", data.ErrInvalidMembership, role)
+	}
+	user, err := s.policy.TenantAdmin(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if targetID == userID {
+		return fmt.Errorf("%w: 不能修改自己的角色", data.ErrInvalidMembership)
+	}
+	target, err := s.data.GetUser(ctx, targetID)
+	if err != nil || target.OrganizationID != user.OrganizationID {
+		return data.ErrUserNotFound
+	}
+	if target.Role == data.UserRoleAdmin {
+		return ErrForbidden
+	}
+	if err := s.data.UpdateUserMembership(ctx, targetID, target.OrganizationID, role); err != nil {
+		return err
+	}
+	log.Printf("🏢 组织 %d: 用户 %d 角色改为 %s (操作人 %d)", user.OrganizationID, targetID, role, userID)
+	return nil
+}
+
+// List 全部组织 (平台管理员)
+func (s *OrganizationService) List(ctx context.Context) ([]data.Organization, error) {
+	return s.data.ListOrganizations(ctx)
+}
+
+// Create 创建组织 (平台管理员)
+func (s *OrganizationService) Create(ctx context.Context, name, key string) (*data.Organization, error) {
+	if key != "" && !orgKeyPattern.MatchString(key) {
+		return nil, fmt.Errorf("%w: Key 只能包含小写字母、数字和连字符", data.ErrInvalidMembership)
+	}
+	org := &data.Organization{Name: name, Key: key}
+	if err := s.data.CreateOrganization(ctx, org); err != nil {
+		return nil, err
+	}
+	log.Printf("🏢 创建组织 %d (%s)，对象前缀 %s", org.ID, org.Name, data.TenantPrefix(org))
+	return org, nil
+}
+
+// AssignUser 把用户分配到组织并设置角色 (平台管理员)，orgID 为 0 表示默认租户
+// 用户需要重新登录，新 Token 才会带上新的组织
+func (s *OrganizationService) AssignUser(ctx context.Context, targetID, orgID uint, role string) error {
+	if role != data.UserRoleUser && role != data.UserRoleOrgAdmin {
+		return fmt.Errorf("%w: 未知角色 %q", data.ErrInvalidMembership, role)
+	}
+	if orgID != 0 {
+		if _, err := s.data.GetOrganization(ctx, orgID); err != nil {
+			return err
+		}
+	}
+	if err := s.data.UpdateUserMembership(ctx, targetID, orgID, role); err != nil {
+		return err
+	}
+	log.Printf("🏢 用户 %d 分配到组织 %d，角色 %s", targetID, orgID, role)
+	return nil
+}
+
+// 邀请有效期
+const (
+	DefaultInviteTTL = 7 * 24 * time.Hour
+	MaxInviteTTL     = 30 * 24 * time.Hour
+)
+
+// InviteInput 创建邀请的参数，Email / Username 至少填一个
+type InviteInput struct {
+	Email    string
+	Username string
+	Role     string
+	TTL      time.Duration // 0 表示 DefaultInviteTTL
+}
+
+// CreatedInvite 新建的邀请，Token 明文只在这里返回一次
+type CreatedInvite struct {
+	data.OrganizationInvite
+	Token string `json:"token"`
+}
+
+// Invite 组织管理员邀请用户加入本组织
+func (s *OrganizationService) Invite(ctx context.Context, userID uint, in InviteInput) (*CreatedInvite, error) {
+	if in.Email == "" && in.Username == "" {
+		return nil, fmt.Errorf("%w: 请填写被邀请人的邮箱或用户名", data.ErrInvalidMembership)
+	}
+	if in.Role == "" {
+		in.Role = data.UserRoleUser
+	}
+	if in.Role != data.UserRoleUser && in.Role != data.UserRoleOrgAdmin {
+		return nil, fmt.Errorf("%w: 未知角色 %q", data.ErrInvalidMembership, in.Role)
+	}
+	if in.TTL <= 0 {
+		in.TTL = DefaultInviteTTL
+	}
+	if in.TTL > MaxInviteTTL {
+		return nil, fmt.Errorf("%w: 有效期不能超过 %s", data.ErrInvalidMembership, MaxInviteTTL)
+	}
+
+	user, err := s.policy.TenantAdmin(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	token, hash, err := data.NewInviteToken()
+	if err != nil {
+		return nil, err
+	}
+	inv := data.OrganizationInvite{
+		OrganizationID: user.OrganizationID,
+		TokenHash:      hash,
+		Email:          in.Email,
+		Username:       in.Username,
+		Role:           in.Role,
+		InvitedBy:      userID,
+		ExpiresAt:      time.Now().Add(in.TTL),
+	}
+	if err := s.data.CreateInvite(ctx, &inv); err != nil {
+		return nil, err
+	}
+	log.Printf("✉️ 组织 %d 邀请 %s%s 加入 (角色 %s，操作人 %d)", inv.OrganizationID, inv.Username, inv.Email, inv.Role, userID)
+	return &CreatedInvite{OrganizationInvite: inv, Token: token}, nil
+}
+
+// Invites 本组织待接受的邀请
+func (s *OrganizationService) Invites(ctx context.Context, userID uint) ([]data.OrganizationInvite, error) {
+	user, err := s.policy.TenantAdmin(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.data.ListInvites(ctx, user.OrganizationID)
+}
+
+// RevokeInvite 撤销本组织的邀请
+func (s *OrganizationService) RevokeInvite(ctx context.Context, userID, inviteID uint) error {
+	user, err := s.policy.TenantAdmin(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.data.RevokeInvite(ctx, user.OrganizationID, inviteID)
+}
+
+// AcceptInvite 当前用户接受邀请，加入邀请的组织 (离开原组织)，返回更新后的用户
+// 这里不校验 Token 里的租户: 接受邀请本身就是换组织，调用方应为用户签发新 Token
+func (s *OrganizationService) AcceptInvite(ctx context.Context, userID uint, token string) (*data.User, error) {
+	inv, err := s.data.AcceptInvite(ctx, token, userID)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("✉️ 用户 %d 接受邀请 %d，加入组织 %d", userID, inv.ID, inv.OrganizationID)
+	return s.data.GetUser(ctx, userID)
+}
+
+// RemoveMember 把用户移出本组织 (回到默认租户)
+// 移出后原 Token 立即失效 (见 AccessPolicy.Caller)，原组织的用户组和直接授权一并清除
+func (s *OrganizationService) RemoveMember(ctx context.Context, userID, targetID uint) error {
+	user, err := s.policy.TenantAdmin(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if targetID == userID {
+		return fmt.Errorf("%w: 不能移出自己", data.ErrInvalidMembership)
+	}
+	if user.OrganizationID == 0 {
+		return fmt.Errorf("%w: 默认租户不能移出成员", data.ErrInvalidMembership)
+	}
+	target, err := s.data.GetUser(ctx, targetID)
+	if err != nil || target.OrganizationID != user.OrganizationID {
+		return data.ErrUserNotFound
+	}
+	if target.Role == data.UserRoleAdmin {
+		return ErrForbidden
+	}
+	if err := s.data.UpdateUserMembership(ctx, targetID, 0, data.UserRoleUser); err != nil {
+		return err
+	}
+	log.Printf("🏢 组织 %d: 移出用户 %d (操作人 %d)", user.OrganizationID, targetID, userID)
+	return nil
+}
@@ -5,105 +5,284 @@ import (
 	"fmt"
 	"github.com/minio/minio-go/v7"
 	"io"
+	"log"
 	"mime/multipart"
 	"path/filepath"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	pb "Chimera-RAG/backend-go/api/rag/v1"
+	"Chimera-RAG/backend-go/internal/biz"
+	"Chimera-RAG/backend-go/internal/conf"
 	"Chimera-RAG/backend-go/internal/data"
+
+	"github.com/google/uuid"
 )
 
 // RagService 定义业务逻辑
 type RagService struct {
 	grpcClient pb.LLMServiceClient
 	Data       *data.Data
+	reranker   *Reranker
+	policy     *AccessPolicy
+
+	historyTurns int
+	queryRewrite bool
 }
 
 // NewRagService 构造函数
-func NewRagService(client pb.LLMServiceClient, data *data.Data) *RagService {
+func NewRagService(client pb.LLMServiceClient, data *data.Data, cfg *conf.Config, policy *AccessPolicy) *RagService {
 	return &RagService{
 		grpcClient: client,
 		Data:       data,
+		reranker:   NewReranker(client, cfg.AI),
+		policy:     policy,
+
+		historyTurns: cfg.AI.HistoryTurns,
+		queryRewrite: cfg.AI.QueryRewrite,
 	}
 }
 
 // StreamChat RAG 核心流程
-func (s *RagService) StreamChat(ctx context.Context, req *pb.AskRequest) (<-chan string, error) {
-	respChan := make(chan string, 10)
+// 检索范围会自动限制在 userID 有权限看到的文档之内；
+// SessionID 为空时新建会话，每轮问答都会持久化并作为下一轮的上下文
+// 返回的事件流协议见 biz/event.go
+func (s *RagService) StreamChat(ctx context.Context, userID uint, req *biz.ChatRequest) (<-chan biz.ChatEvent, error) {
+	mode := req.SearchMode
+	if mode == "" {
+		mode = biz.SearchModeHybrid
+	}
+
+	// 0. 加载会话与历史
+	sessionID := req.SessionID
+	if sessionID == "" {
+		sessionID = uuid.New().String()
+	}
+	conv, err := s.Data.GetOrCreateConversation(ctx, sessionID, userID, truncateRunes(req.Query, titleLen))
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.Data.RecentMessages(ctx, conv.ID, s.historyTurns*2)
+	if err != nil {
+		return nil, err
+	}
+
+	respChan := make(chan biz.ChatEvent, 10)
 
 	go func() {
 		defer close(respChan)
 
-		// 1. 向量化
-		respChan <- "THINKing: 正在理解意图..."
-		embResp, err := s.grpcClient.EmbedData(ctx, &pb.EmbedRequest{Data: &pb.EmbedRequest_Text{Text: req.Query}})
+		startAt := time.Now()
+		question := &data.Message{Role: "user", Content: req.Query}
+		answer := &data.Message{Role: "assistant"}
+
+		// 前端断开后 Handler 不再读取管道，这里不能阻塞，否则本轮问答无法落库
+		emit := func(event string, data any) {
+			select {
+			case respChan <- biz.NewChatEvent(event, data):
+			case <-ctx.Done():
+			}
+		}
+		thinking := func(msg string) {
+			emit(biz.EventThinking, biz.ThinkingData{Message: msg})
+		}
+		fail := func(msg string) {
+			answer.Error = msg
+			answer.TotalMs = time.Since(startAt).Milliseconds()
+			s.saveTurn(ctx, conv, question, answer)
+			emit(biz.EventError, biz.ErrorData{Message: msg})
+			emit(biz.EventDone, biz.DoneData{SessionID: conv.SessionID})
+		}
+
+		// 1. 结合历史改写追问，得到独立的检索问题
+		thinking("正在理解意图...")
+		searchQuery := s.rewriteQuery(ctx, history, req.Query)
+		if searchQuery != req.Query {
+			question.RewrittenQuery = searchQuery
+			thinking("检索问题改写为: " + searchQuery)
+		}
+
+		// 2. 向量化 (纯关键词检索不需要)
+		// 用当前检索别名指向的模型，重建索引切换后自动跟随
+		var (
+			model  string
+			vector []float32
+		)
+		if mode != biz.SearchModeKeyword {
+			model, err = s.Data.LiveModel(ctx)
+			if err != nil {
+				fail(err.Error())
+				return
+			}
+			embResp, err := s.grpcClient.EmbedData(ctx, &pb.EmbedRequest{Data: &pb.EmbedRequest_Text{Text: searchQuery}, Model: model})
+			if err != nil {
+				fail(err.Error())
+				return
+			}
+			vector = embResp.Vector
+		}
+
+		// 3. 检索 (Retrieval)，开启精排时会多召回一些候选
+		thinking("正在检索知识库...")
+		filter, err := s.buildSearchFilter(ctx, userID, req.Filter)
 		if err != nil {
-			respChan <- "ERR: " + err.Error()
+			fail(err.Error())
 			return
 		}
-
-		// 2. 检索 (Retrieval)
-		respChan <- "THINKing: 正在检索知识库..."
-		docs, err := s.Data.SearchSimilar(ctx, embResp.Vector, 15)
+		docs, err := s.retrieve(ctx, mode, searchQuery, model, vector, s.reranker.Candidates(), filter)
 		if err != nil {
-			respChan <- "ERR: " + err.Error()
+			fail(err.Error())
 			return
 		}
 
-		// 3. 组装 Prompt (Augmentation)
+		// 3.5 精排 (Rerank)，只保留最相关的 N 个片段
+		if len(docs) > 0 {
+			thinking(fmt.Sprintf("正在对 %d 个候选片段精排...", len(docs)))
+			docs = s.reranker.Rerank(ctx, searchQuery, docs)
+		}
+
+		sources := toSourceDocs(docs)
+		emit(biz.EventRetrieval, biz.RetrievalData{Mode: mode, Sources: sources})
+		answer.RetrievalMs = time.Since(startAt).Milliseconds()
+
+		// 4. 组装 Prompt (Augmentation)
 		contextText := ""
 		if len(docs) > 0 {
-			respChan <- fmt.Sprintf("THINKing: 检索到 %d 个相关片段，正在阅读...", len(docs))
+			thinking(fmt.Sprintf("检索到 %d 个相关片段，正在阅读...", len(docs)))
 
 			for _, doc := range docs {
 				// 🔥 核心修改点：格式化上下文，显式包含【文件名】和【页码】
 				// 这样 Python 端的 System Prompt 才能识别并引用
-				// 假设 doc 结构体里有 FileName 字段 (如果没有，请去 Qdrant 检索逻辑里补上)
 				contextText += fmt.Sprintf("【来源: %s | 页码: %d】\n%s\n\n", doc.FileName, doc.Page, doc.Content)
 			}
 		} else {
-			respChan <- "THINKing: 未找到相关文档，将依靠通用知识回答..."
+			thinking("未找到相关文档，将依靠通用知识回答...")
+		}
+
+		historyText := ""
+		if len(history) > 0 {
+			historyText = "对话历史：\n" + formatHistory(history)
 		}
 
 		// 构造最终 Prompt
 		// 建议加上 explicit instruction (显式指令) 强化 AI 的引用意图
 		finalPrompt := fmt.Sprintf(`
+			%s
 			背景知识：
 			%s
 			
 			用户问题：%s
 			请根据背景知识回答，并在引用处使用 <<文件名|页码>> 格式标注。
-			`, contextText, req.Query)
+			`, historyText, contextText, req.Query)
 
-		// 4. 生成 (Generation) - 调用 Python 的 AskStream
-		respChan <- "THINKing: 正在生成回答..."
-		stream, err := s.grpcClient.AskStream(ctx, &pb.AskRequest{Query: finalPrompt})
+		// 5. 生成 (Generation) - 调用 Python 的 AskStream
+		thinking("正在生成回答...")
+		genStart := time.Now()
+		stream, err := s.grpcClient.AskStream(ctx, &pb.AskRequest{Query: finalPrompt, SessionId: conv.SessionID})
 		if err != nil {
-			respChan <- "ERR: LLM 连接失败 - " + err.Error()
+			fail("LLM 连接失败 - " + err.Error())
 			return
 		}
 
+		citations := newCitationTracker(sources)
 		for {
 			resp, err := stream.Recv()
 			if err == io.EOF {
 				break
 			}
 			if err != nil {
-				respChan <- "ERR: " + err.Error()
+				answer.Content = citations.Answer()
+				answer.Sources = toMessageSources(sources, citations.Cited())
+				fail(err.Error())
 				return
 			}
-			// 将 AI 的回答推给前端
+			// 将 AI 的回答推给前端，同时识别其中的引用标记
 			if resp.AnswerDelta != "" {
-				respChan <- "ANSWER: " + resp.AnswerDelta
+				emit(biz.EventAnswerDelta, biz.AnswerDeltaData{Text: resp.AnswerDelta})
+				for _, c := range citations.Feed(resp.AnswerDelta) {
+					emit(biz.EventCitation, c)
+				}
 			}
 		}
+
+		// 6. 持久化本轮问答
+		answer.Content = citations.Answer()
+		answer.Sources = toMessageSources(sources, citations.Cited())
+		answer.GenerationMs = time.Since(genStart).Milliseconds()
+		answer.TotalMs = time.Since(startAt).Milliseconds()
+		s.saveTurn(ctx, conv, question, answer)
+
+		emit(biz.EventUsage, biz.UsageData{
+			PromptChars:  utf8.RuneCountInString(finalPrompt),
+			AnswerChars:  utf8.RuneCountInString(answer.Content),
+			SourceCount:  len(sources),
+			RetrievalMs:  answer.RetrievalMs,
+			GenerationMs: answer.GenerationMs,
+			TotalMs:      answer.TotalMs,
+		})
+		emit(biz.EventDone, biz.DoneData{SessionID: conv.SessionID})
 	}()
 
 	return respChan, nil
 }
 
+// snippetLen 来源摘要的最大字符数
+const snippetLen = 200
+
+// toSourceDocs 将检索结果转换为前端展示用的来源列表
+func toSourceDocs(docs []data.SearchResult) []biz.SourceDoc {
+	sources := make([]biz.SourceDoc, 0, len(docs))
+	for _, d := range docs {
+		sources = append(sources, biz.SourceDoc{
+			DocumentID: d.DocumentID,
+			FileName:   d.FileName,
+			Title:      d.Title,
+			Page:       d.Page,
+			Score:      d.Score,
+			Snippet:    truncateRunes(d.Content, snippetLen),
+		})
+	}
+	return sources
+}
+
+// UploadResult 上传结果
+type UploadResult struct {
+	Document  *data.Document
+	Duplicate bool // 同一知识库内已有相同内容的文档，本次上传未入库
+}
+
 // UploadDocument 处理文件上传全流程
-func (s *RagService) UploadDocument(ctx context.Context, fileHeader *multipart.FileHeader, userID uint) (*data.Document, error) {
+// knowledgeBaseID 为 0 时放在个人根目录，否则需要该知识库的 editor 以上角色
+// 文档属于目标知识库所在的租户 (根目录则是上传者所在的租户)
+func (s *RagService) UploadDocument(ctx context.Context, fileHeader *multipart.FileHeader, userID, knowledgeBaseID uint) (*UploadResult, error) {
+	// 0. 校验目标知识库，确定租户
+	user, err := s.policy.Caller(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	orgID := user.OrganizationID
+	if knowledgeBaseID != 0 {
+		kb, err := s.Data.GetKnowledgeBase(ctx, knowledgeBaseID)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := s.policy.CanWriteKnowledgeBase(ctx, userID, kb)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrForbidden
+		}
+		orgID = kb.OrganizationID
+	}
+	var org *data.Organization
+	if orgID != 0 {
+		if org, err = s.Data.GetOrganization(ctx, orgID); err != nil {
+			return nil, err
+		}
+	}
+
 	// 1. 打开文件流
 	src, err := fileHeader.Open()
 	if err != nil {
@@ -111,45 +290,68 @@ func (s *RagService) UploadDocument(ctx context.Context, fileHeader *multipart.F
 	}
 	defer src.Close()
 
-	// 2. [Data层] 上传到 MinIO
+	// 2. [Data层] 上传到 MinIO，同时计算内容哈希
 	// Service 层不需要知道 MinIO SDK 的细节，只需要给文件流
-	storagePath, err := s.Data.UploadFile(ctx, src, fileHeader.Size, fileHeader.Filename)
+	storagePath, contentHash, err := s.Data.UploadFile(ctx, src, fileHeader.Size, fileHeader.Filename, data.TenantPrefix(org))
 	if err != nil {
 		return nil, err
 	}
 
-	// 3. [Data层] 写入数据库 (v0.2.0 文件确权)
+	// 3. [Data层] 写入数据库 (v0.2.0 文件确权)，同时完成去重与版本判定
 	doc := &data.Document{
 		Title:           fileHeader.Filename,
 		FileName:        fileHeader.Filename,
 		FileSize:        fileHeader.Size,
 		FileType:        strings.ToLower(filepath.Ext(fileHeader.Filename)), // 简单的后缀判断工具函数
 		StoragePath:     storagePath,
-		KnowledgeBaseID: 0, // 默认归属根目录，后续可传参
+		ContentHash:     contentHash,
+		KnowledgeBaseID: knowledgeBaseID,
 		OwnerID:         userID,
-		Status:          "pending",
+		OrganizationID:  orgID,
+		Status:          data.DocStatusPending,
 	}
 
-	if err := s.Data.CreateDocument(ctx, doc); err != nil {
+	doc, duplicate, err := s.Data.CreateDocumentVersion(ctx, doc)
+	if err != nil {
 		// ⚠️ 进阶思考: 如果数据库写入失败，最好把 MinIO 里的垃圾文件删掉 (补偿机制)
 		// s.Data.DeleteFile(ctx, storagePath)
 		return nil, err
 	}
+	if duplicate {
+		// 重复上传: 刚传上去的对象没有记录引用它，直接删掉
+		if err := s.Data.DeleteObject(ctx, "chimera-docs", storagePath); err != nil {
+			log.Printf("⚠️ 清理重复上传的文件失败 %s: %v", storagePath, err)
+		}
+		return &UploadResult{Document: doc, Duplicate: true}, nil
+	}
 
 	// 4. [Data层] 写入 Redis 任务队列
-	// 传递 Document ID 而不是路径，Worker 可以根据 ID 查库获取更多信息
-	// 也可以传 JSON: {"doc_id": 1, "path": "xxx.pdf"}
-	err = s.Data.PushTask(ctx, "task:parse_pdf", storagePath)
+	// 任务信封带上文档 ID、归属、存储位置等，Worker 不必再靠对象名反查 (见 data/job.go)
+	err = s.Data.EnqueueJob(ctx, data.NewJob(ctx, data.JobParse, doc))
 	if err != nil {
-		// 同样，如果队列失败，考虑是否回滚数据库状态为 "failed"
+		// 入队失败时文档永远不会被处理，直接标记为失败，避免前端一直显示排队中
+		_, _ = s.Data.TransitionDocument(ctx, doc.ID, data.DocStatusFailed, data.DocumentUpdate{ErrorMsg: "任务入队失败: " + err.Error()})
 		return nil, err
 	}
 
-	return doc, nil
+	return &UploadResult{Document: doc}, nil
 }
 
 // GetFile 获取文件流用于预览
-func (s *RagService) GetFile(ctx context.Context, fileName string) (*minio.Object, int64, error) {
+// 无权访问时返回 ErrForbidden
+func (s *RagService) GetFile(ctx context.Context, userID uint, fileName string) (*minio.Object, int64, error) {
+	doc, err := s.Data.GetDocumentByStoragePath(ctx, fileName)
+	if err != nil {
+		return nil, 0, err
+	}
+	ok, err := s.policy.CanReadDocument(ctx, userID, doc)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !ok {
+		return nil, 0, ErrForbidden
+	}
+
 	// 这里硬编码 bucket 名，或者从 s.conf 读取
 	bucketName := "chimera-docs"
 
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"Chimera-RAG/backend-go/internal/data"
+)
+
+// reindexListLimit 列表接口最多返回的任务数
+const reindexListLimit = 20
+
+// ReindexService 蓝绿重建索引 (切换向量模型)，仅管理员可用
+type ReindexService struct {
+	data *data.Data
+}
+
+func NewReindexService(d *data.Data) *ReindexService {
+	return &ReindexService{data: d}
+}
+
+// ReindexStatus 任务详情，附带当前检索使用的模型
+type ReindexStatus struct {
+	*data.ReindexTask
+	Progress  int    `json:"progress"`
+	LiveModel string `json:"live_model"`
+}
+
+func (s *ReindexService) status(ctx context.Context, task *data.ReindexTask) (*ReindexStatus, error) {
+	live, err := s.data.LiveModel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &ReindexStatus{ReindexTask: task, Progress: task.Progress(), LiveModel: live}, nil
+}
+
+// Start 创建重建任务并投递到入库队列，由 ETLWorker 在后台执行
+func (s *ReindexService) Start(ctx context.Context, userID uint, targetModel string) (*ReindexStatus, error) {
+	task, err := s.data.CreateReindexTask(ctx, targetModel, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.data.EnqueueJob(ctx, data.NewReembedJob(ctx, task)); err != nil {
+		// 入队失败时任务永远不会执行，标记失败，否则会一直占着 "进行中" 的名额
+		_ = s.data.UpdateReindexTask(ctx, task.ID, map[string]any{
+			"status": data.ReindexFailed, "finished_at": time.Now(), "error_msg": "任务入队失败: " + err.Error(),
+		})
+		return nil, err
+	}
+	return s.status(ctx, task)
+}
+
+// Get 查询任务进度
+func (s *ReindexService) Get(ctx context.Context, id uint) (*ReindexStatus, error) {
+	task, err := s.data.GetReindexTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.status(ctx, task)
+}
+
+// List 最近的任务
+func (s *ReindexService) List(ctx context.Context) ([]*ReindexStatus, error) {
+	tasks, err := s.data.ListReindexTasks(ctx, reindexListLimit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*ReindexStatus, 0, len(tasks))
+	for i := range tasks {
+		st, err := s.status(ctx, &tasks[i])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+// CutOver 切换检索到新集合；切片数校验未通过时需要 force
+// 任务完成后有文档重新入库时不切换，任务回到 running 并重新投递补做，完成后需要再次切换
+func (s *ReindexService) CutOver(ctx context.Context, id uint, force bool) (*ReindexStatus, error) {
+	task, err := s.data.CutOverReindex(ctx, id, force)
+	if errors.Is(err, data.ErrReindexStale) {
+		if err := s.resume(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v，已开始补做，完成后请重新切换", data.ErrReindexConflict, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("🔀 重建任务 %d 已切换: %s -> %s", task.ID, task.SourceModel, task.TargetModel)
+	return s.Get(ctx, id)
+}
+
+// resume 让已完成的任务回到 running 并重新投递 reembed 任务
+func (s *ReindexService) resume(ctx context.Context, id uint) error {
+	task, err := s.data.ResumeReindex(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.data.EnqueueJob(ctx, data.NewReembedJob(ctx, task)); err != nil {
+		_ = s.data.UpdateReindexTask(ctx, task.ID, map[string]any{
+			"status": data.ReindexFailed, "finished_at": time.Now(), "error_msg": "补做任务入队失败: " + err.Error(),
+		})
+		return err
+	}
+	log.Printf("🔁 重建任务 %d 完成后有文档变动，开始补做", task.ID)
+	return nil
+}
+
+// Rollback 切回旧集合
+// 切换之后入库的文档只写进了新集合，回滚后重新投递解析任务，让它们也进入旧集合。
+// 返回重新投递的文档数
+func (s *ReindexService) Rollback(ctx context.Context, id uint) (*ReindexStatus, int, error) {
+	task, err := s.data.RollbackReindex(ctx, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	log.Printf("↩️ 重建任务 %d 已回滚到模型 %s", task.ID, task.SourceModel)
+
+	requeued := 0
+	if task.CutOverAt != nil {
+		docs, err := s.data.DocumentsUpdatedSince(ctx, *task.CutOverAt, 0)
+		if err != nil {
+			return nil, 0, err
+		}
+		for i := range docs {
+			if err := s.data.EnqueueJob(ctx, data.NewJob(ctx, data.JobReindex, &docs[i])); err != nil {
+				log.Printf("⚠️ 回滚后重新投递文档 %d 失败: %v", docs[i].ID, err)
+				continue
+			}
+			requeued++
+		}
+	}
+
+	st, err := s.Get(ctx, id)
+	return st, requeued, err
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+
+	pb "Chimera-RAG/backend-go/api/rag/v1"
+	"Chimera-RAG/backend-go/internal/conf"
+	"Chimera-RAG/backend-go/internal/data"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Reranker 检索与 Prompt 组装之间的精排阶段
+// 先多召回一些候选，再交给 Python 端的 Cross-Encoder 打分，只保留最好的 N 个；
+// 不做精排时与原来一样，直接使用召回的 retrievalTopK 个片段
+type Reranker struct {
+	client        pb.LLMServiceClient
+	enabled       bool
+	retrievalTopK int
+	candidates    int
+	topN          int
+
+	// AI 服务返回 Unimplemented 后置位，之后不再调用，直接用召回结果
+	unsupported atomic.Bool
+}
+
+func NewReranker(client pb.LLMServiceClient, cfg conf.AIConfig) *Reranker {
+	r := &Reranker{
+		client:        client,
+		enabled:       cfg.RerankEnabled,
+		retrievalTopK: cfg.RetrievalTopK,
+		candidates:    cfg.RerankCandidates,
+		topN:          cfg.RerankTopN,
+	}
+	if r.retrievalTopK <= 0 {
+		r.retrievalTopK = 15
+	}
+	if r.topN <= 0 {
+		r.topN = 8
+	}
+	if r.candidates < r.topN {
+		r.candidates = r.topN
+	}
+	return r
+}
+
+// Candidates 召回阶段需要拉取的候选数
+// 不做精排时召回多少就用多少，返回 retrievalTopK
+func (r *Reranker) Candidates() uint64 {
+	if !r.active() {
+		return uint64(r.retrievalTopK)
+	}
+	return uint64(r.candidates)
+}
+
+func (r *Reranker) active() bool {
+	return r.enabled && !r.unsupported.Load()
+}
+
+// Rerank 对候选片段重新排序，精排成功时返回最多 TopN 个
+// 任何失败都会降级为原始召回结果 (最多 retrievalTopK 个)，不影响问答主流程
+func (r *Reranker) Rerank(ctx context.Context, query string, docs []data.SearchResult) []data.SearchResult {
+	if !r.active() || len(docs) <= 1 {
+		return truncate(docs, r.retrievalTopK)
+	}
+
+	contents := make([]string, len(docs))
+	for i, d := range docs {
+		contents[i] = d.Content
+	}
+
+	resp, err := r.client.Rerank(ctx, &pb.RerankRequest{
+		Query:     query,
+		Documents: contents,
+		TopN:      int32(r.topN),
+	})
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			r.unsupported.Store(true)
+			log.Printf("⚠️ AI 服务不支持 Rerank，已降级为原始召回顺序")
+		} else {
+			log.Printf("⚠️ Rerank 调用失败，本次使用原始召回顺序: %v", err)
+		}
+		return truncate(docs, r.retrievalTopK)
+	}
+
+	ranked := make([]data.SearchResult, 0, len(resp.Results))
+	for _, res := range resp.Results {
+		if res.Index < 0 || int(res.Index) >= len(docs) {
+			continue
+		}
+		d := docs[res.Index]
+		d.Score = res.Score
+		ranked = append(ranked, d)
+	}
+	return truncate(ranked, r.topN)
+}
+
+func truncate(docs []data.SearchResult, n int) []data.SearchResult {
+	if len(docs) > n {
+		return docs[:n]
+	}
+	return docs
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"Chimera-RAG/backend-go/internal/biz"
+	"Chimera-RAG/backend-go/internal/data"
+)
+
+// RRF 平滑常数，沿用论文中的经验值 60
+const rrfK = 60
+
+// retrieve 按检索模式召回切片
+// vector 为空时 (纯关键词模式) 不会走向量检索；model 为生成 vector 的向量模型
+func (s *RagService) retrieve(ctx context.Context, mode string, query string, model string, vector []float32, topK uint64, filter *data.SearchFilter) ([]data.SearchResult, error) {
+	switch mode {
+	case biz.SearchModeVector:
+		return s.Data.SearchSimilar(ctx, model, vector, topK, filter)
+	case biz.SearchModeKeyword:
+		return s.Data.KeywordSearch(ctx, query, topK, filter)
+	}
+
+	// hybrid: 两路各自召回 topK，再用 RRF 融合
+	dense, err := s.Data.SearchSimilar(ctx, model, vector, topK, filter)
+	if err != nil {
+		return nil, err
+	}
+	sparse, err := s.Data.KeywordSearch(ctx, query, topK, filter)
+	if err != nil {
+		return nil, err
+	}
+	return fuseRRF(int(topK), dense, sparse), nil
+}
+
+// fuseRRF Reciprocal Rank Fusion: score = Σ 1 / (k + rank)
+// 只看名次不看原始分数，因此余弦相似度和 BM25 分数可以直接融合
+func fuseRRF(topK int, lists ...[]data.SearchResult) []data.SearchResult {
+	scores := make(map[string]float64)
+	items := make(map[string]data.SearchResult)
+	var order []string
+
+	for _, list := range lists {
+		for rank, r := range list {
+			if _, ok := items[r.ID]; !ok {
+				items[r.ID] = r
+				order = append(order, r.ID)
+			}
+			scores[r.ID] += 1.0 / float64(rrfK+rank+1)
+		}
+	}
+
+	// 稳定排序: 分数相同时保留先出现 (向量路) 的顺序
+	sort.SliceStable(order, func(i, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+	if len(order) > topK {
+		order = order[:topK]
+	}
+
+	fused := make([]data.SearchResult, 0, len(order))
+	for _, id := range order {
+		r := items[id]
+		r.Score = float32(scores[id])
+		fused = append(fused, r)
+	}
+	return fused
+}
+
+// buildSearchFilter 将前端传来的过滤条件转换为 data 层的检索过滤
+// 指定的知识库会展开为整棵子树，选中文件夹即检索其下所有文档；
+// 无论前端传什么，都会叠加调用者的访问范围
+func (s *RagService) buildSearchFilter(ctx context.Context, userID uint, f *biz.SearchFilter) (*data.SearchFilter, error) {
+	scope, err := s.policy.Scope(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return &data.SearchFilter{Access: scope}, nil
+	}
+
+	filter := &data.SearchFilter{
+		Access:          scope,
+		DocumentIDs:     f.DocumentIDs,
+		OwnerIDs:        f.OwnerIDs,
+		OrganizationIDs: f.OrganizationIDs,
+		FileTypes:       f.FileTypes,
+		FileNames:       f.FileNames,
+		PageFrom:        f.PageFrom,
+		PageTo:          f.PageTo,
+	}
+	if len(f.KnowledgeBaseIDs) > 0 {
+		ids, err := s.Data.DescendantKnowledgeBaseIDs(ctx, f.KnowledgeBaseIDs)
+		if err != nil {
+			return nil, err
+		}
+		filter.KnowledgeBaseIDs = ids
+	}
+	return filter, nil
+}
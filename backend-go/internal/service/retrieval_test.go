@@ -0,0 +1,61 @@
+package service
+
+import (
+	"slices"
+	"testing"
+
+	"Chimera-RAG/backend-go/internal/data"
+)
+
+func searchResults(ids ...string) []data.SearchResult {
+	out := make([]data.SearchResult, len(ids))
+	for i, id := range ids {
+		out[i] = data.SearchResult{ID: id, Content: id}
+	}
+	return out
+}
+
+func TestFuseRRF(t *testing.T) {
+	tests := []struct {
+		name  string
+		topK  int
+		lists [][]data.SearchResult
+		want  []string
+	}{
+		{"两路都命中的排在前面", 10, [][]data.SearchResult{searchResults("a", "b", "c"), searchResults("c", "d")}, []string{"c", "a", "b", "d"}},
+		{"名次之和相同时保留向量路的顺序", 10, [][]data.SearchResult{searchResults("a", "b"), searchResults("b", "a")}, []string{"a", "b"}},
+		{"各自第一名时保留向量路的顺序", 10, [][]data.SearchResult{searchResults("a"), searchResults("b")}, []string{"a", "b"}},
+		{"TopK 截断", 2, [][]data.SearchResult{searchResults("a", "b", "c"), searchResults("c", "b")}, []string{"c", "b"}},
+		{"只有一路", 10, [][]data.SearchResult{searchResults("a", "b"), nil}, []string{"a", "b"}},
+		{"两路都为空", 10, [][]data.SearchResult{nil, nil}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fused := fuseRRF(tt.topK, tt.lists...)
+			got := make([]string, len(fused))
+			for i, r := range fused {
+				got[i] = r.ID
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuseRRFScore(t *testing.T) {
+	dense := []data.SearchResult{{ID: "a", Content: "向量路", Score: 0.9}}
+	sparse := []data.SearchResult{{ID: "a", Content: "关键词路", Score: 12.5}}
+
+	fused := fuseRRF(10, dense, sparse)
+	if len(fused) != 1 {
+		t.Fatalf("len = %d, want 1", len(fused))
+	}
+	// 同一切片只保留第一次出现的内容，分数换成 RRF 分数
+	if fused[0].Content != "向量路" {
+		t.Errorf("Content = %q, want 向量路", fused[0].Content)
+	}
+	if want := float32(2.0 / (rrfK + 1)); fused[0].Score != want {
+		t.Errorf("Score = %v, want %v", fused[0].Score, want)
+	}
+}
@@ -15,6 +15,8 @@ type Claims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	// OrganizationID 签发时用户所在的组织 (租户)
+	OrganizationID uint `json:"org_id"`
 	jwt.RegisteredClaims
 }
 
@@ -31,12 +33,14 @@ func CheckPasswordHash(password, hash string) bool {
 }
 
 // GenerateToken 生成 JWT
-func GenerateToken(userID uint, username, role string) (string, error) {
+func GenerateToken(userID uint, username, role string, orgID uint) (string, error) {
 	expirationTime := time.Now().Add(24 * time.Hour) // 1天过期
 	claims := &Claims{
 		UserID:   userID,
 		Username: username,
 		Role:     role,
+
+		OrganizationID: orgID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			Issuer:    "chimera-rag",
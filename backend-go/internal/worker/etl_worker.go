@@ -2,128 +2,355 @@ package worker
 
 import (
 	"context"
-	"io"
+	"errors"
+	"fmt"
 	"log"
+	"os"
 	"time"
 
 	pb "Chimera-RAG/backend-go/api/rag/v1"
+	"Chimera-RAG/backend-go/internal/conf"
 	"Chimera-RAG/backend-go/internal/data"
 
-	"github.com/google/uuid"
-	"github.com/minio/minio-go/v7"
-	"github.com/qdrant/go-client/qdrant"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
 )
 
+// JobHandler 处理某一类入库任务
+// 返回 data.ErrUnsupportedJob (可包装) 表示不可恢复，任务直接进入死信队列
+type JobHandler func(ctx context.Context, job *data.Job) error
+
 // ETLWorker 负责从 Redis 拿任务，并执行 ETL 流程
 type ETLWorker struct {
 	data       *data.Data
 	grpcClient pb.LLMServiceClient
+	queue      *data.TaskQueue
+	handlers   map[string]JobHandler
+
+	autoSummary bool
+	upsert      upsertOptions
 }
 
-func NewETLWorker(data *data.Data, client pb.LLMServiceClient) *ETLWorker {
-	return &ETLWorker{
-		data:       data,
+func NewETLWorker(d *data.Data, client pb.LLMServiceClient, cfg conf.ETLConfig) *ETLWorker {
+	w := &ETLWorker{
+		data:       d,
 		grpcClient: client,
+		handlers:   make(map[string]JobHandler),
+
+		autoSummary: cfg.AutoSummary,
+		upsert:      upsertOptions{batchSize: max(cfg.UpsertBatchSize, 1), retries: max(cfg.UpsertRetries, 0)},
+		queue: d.Queue(data.QueueIngest, data.QueueOptions{
+			VisibilityTimeout: cfg.VisibilityTimeout,
+			MaxAttempts:       cfg.MaxAttempts,
+			BaseBackoff:       cfg.RetryBackoff,
+			MaxBackoff:        cfg.RetryMaxBackoff,
+		}),
 	}
+	w.queue.OnFailure(w.onTaskFailure)
+
+	w.Register(data.JobParse, w.handleParse)
+	w.Register(data.JobReindex, w.handleReindex)
+	w.Register(data.JobDelete, w.handleDelete)
+	w.Register(data.JobSummarize, w.handleSummarize)
+	w.Register(data.JobReembed, w.handleReembed)
+	return w
+}
+
+// Register 注册任务处理器，同一类型重复注册时后者覆盖前者
+func (w *ETLWorker) Register(jobType string, h JobHandler) {
+	w.handlers[jobType] = h
+}
+
+// Queue 入库队列 (运维统计用)
+func (w *ETLWorker) Queue() *data.TaskQueue {
+	return w.queue
 }
 
 // Start 启动 Worker (阻塞运行)
 func (w *ETLWorker) Start(ctx context.Context, numWorkers int) {
-	log.Printf("🚀 启动 %d 个 ETL Worker，开始监听队列 task:parse_pdf...", numWorkers)
+	log.Printf("🚀 启动 %d 个 ETL Worker，开始监听队列 %s...", numWorkers, w.queue.Name())
+
+	if err := w.queue.EnsureGroup(ctx); err != nil {
+		log.Printf("❌ 初始化消费组失败: %v", err)
+		return
+	}
+	if n, err := w.queue.MigrateLegacy(ctx); err != nil {
+		log.Printf("⚠️ 迁移旧队列任务失败: %v", err)
+	} else if n > 0 {
+		log.Printf("✅ 已将旧队列中的 %d 个任务迁移到 %s", n, w.queue.Name())
+	}
 
+	// 消费者名需要全局唯一，多实例部署时才能区分是谁领走了消息
+	host, _ := os.Hostname()
 	for i := 0; i < numWorkers; i++ {
-		go w.processLoop(ctx, i)
+		go w.processLoop(ctx, i, fmt.Sprintf("%s-%d-%d", host, os.Getpid(), i))
 	}
 }
 
-func (w *ETLWorker) processLoop(ctx context.Context, workerID int) {
+func (w *ETLWorker) processLoop(ctx context.Context, workerID int, consumer string) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		default:
-			// 1. 阻塞式获取任务 (BLPOP)
-			result, err := w.data.Redis.BLPop(ctx, 0*time.Second, "task:parse_pdf").Result()
+			// 1. 领取任务 (XREADGROUP)，同时回收到期重试和超时未确认的任务
+			task, err := w.queue.Read(ctx, consumer, 5*time.Second)
 			if err != nil {
 				// Redis 偶尔连接超时是正常的，不要 panic
 				log.Printf("[Worker-%d] 等待任务中... (%v)", workerID, err)
 				time.Sleep(3 * time.Second)
 				continue
 			}
+			if task == nil {
+				continue
+			}
 
-			fileName := result[1]
-			log.Printf("[Worker-%d] 收到任务: %s", workerID, fileName)
-
-			// 2. 执行具体处理逻辑
-			err = w.processFile(ctx, fileName)
+			// 2. 解析任务信封，无法识别的任务重试也没用，直接进死信
+			job, err := data.DecodeJob(task.Payload)
 			if err != nil {
-				log.Printf("[Worker-%d] ❌ 处理失败: %s, 错误: %v", workerID, fileName, err)
-			} else {
-				log.Printf("[Worker-%d] ✅ 处理完成: %s", workerID, fileName)
+				log.Printf("[Worker-%d] ☠️ 无法解析任务，进入死信队列: %v", workerID, err)
+				if buryErr := w.queue.Bury(ctx, task, err); buryErr != nil {
+					log.Printf("[Worker-%d] ⚠️ 写入死信队列失败: %v", workerID, buryErr)
+				}
+				continue
+			}
+			job.Attempt = task.Attempts + 1
+			log.Printf("[Worker-%d] 收到任务: %s 文档 %d (第 %d 次执行, trace=%s)", workerID, job.Type, job.DocumentID, job.Attempt, job.TraceID())
+
+			// 3. 执行具体处理逻辑，处理期间定时续期
+			err = w.runWithHeartbeat(ctx, consumer, task, func() error {
+				return w.dispatch(ctx, job)
+			})
+
+			// 4. 成功 ACK，失败按退避策略重试或进入死信队列
+			if err == nil {
+				if ackErr := w.queue.Ack(ctx, task); ackErr != nil {
+					log.Printf("[Worker-%d] ⚠️ ACK 失败: %s, 错误: %v", workerID, job.ID, ackErr)
+				}
+				log.Printf("[Worker-%d] ✅ 处理完成: %s 文档 %d", workerID, job.Type, job.DocumentID)
+				continue
+			}
+
+			if errors.Is(err, data.ErrUnsupportedJob) {
+				log.Printf("[Worker-%d] ☠️ 任务无法处理，进入死信队列: %s, 错误: %v", workerID, job.ID, err)
+				if buryErr := w.queue.Bury(ctx, task, err); buryErr != nil {
+					log.Printf("[Worker-%d] ⚠️ 写入死信队列失败: %v", workerID, buryErr)
+				}
+				continue
+			}
+
+			dead, retryErr := w.queue.Retry(ctx, task, err)
+			switch {
+			case retryErr != nil:
+				log.Printf("[Worker-%d] ❌ 处理失败且无法重新入队: %s, 错误: %v / %v", workerID, job.ID, err, retryErr)
+			case dead:
+				log.Printf("[Worker-%d] ☠️ 超过最大重试次数，进入死信队列: %s, 错误: %v", workerID, job.ID, err)
+			default:
+				log.Printf("[Worker-%d] ❌ 处理失败，稍后重试: %s, 错误: %v", workerID, job.ID, err)
+			}
+		}
+	}
+}
+
+// dispatch 按任务类型分发到对应的处理器
+func (w *ETLWorker) dispatch(ctx context.Context, job *data.Job) error {
+	h, ok := w.handlers[job.Type]
+	if !ok {
+		return fmt.Errorf("%w: type %q", data.ErrUnsupportedJob, job.Type)
+	}
+	return h(ctx, job)
+}
+
+// runWithHeartbeat 执行 fn，期间按间隔续期消息，避免长任务被其他 Worker 当作超时接管
+func (w *ETLWorker) runWithHeartbeat(ctx context.Context, consumer string, task *data.Task, fn func() error) error {
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		ticker := time.NewTicker(w.queue.HeartbeatInterval())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := w.queue.Touch(ctx, consumer, task); err != nil {
+					log.Printf("⚠️ 任务续期失败 %s: %v", task.ID, err)
+				}
 			}
 		}
+	}()
+
+	return fn()
+}
+
+// resolveDocument 按任务中的文档 ID 查找文档；兼容旧版本只带 MinIO 对象名的任务
+func (w *ETLWorker) resolveDocument(ctx context.Context, job *data.Job) (*data.Document, error) {
+	if job.DocumentID != 0 {
+		return w.data.GetDocument(ctx, job.DocumentID)
 	}
+	doc, err := w.data.GetDocumentByStoragePath(ctx, job.Storage.Object)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, data.ErrDocumentNotFound
+	}
+	return doc, err
 }
 
-// processFile 单个文件的 ETL 流程
-func (w *ETLWorker) processFile(ctx context.Context, fileName string) error {
-	// A. 从 MinIO 获取文件流
-	obj, err := w.data.Minio.GetObject(ctx, "chimera-docs", fileName, minio.GetObjectOptions{})
+// onTaskFailure 解析类任务失败后同步文档状态: 还会重试的回到 pending，进入死信的标记为 failed
+// 重建索引任务进入死信时标记为 failed
+func (w *ETLWorker) onTaskFailure(ctx context.Context, task *data.Task, cause error, dead bool) {
+	job, err := data.DecodeJob(task.Payload)
+	if err != nil {
+		return
+	}
+	if job.Type == data.JobReembed && dead && job.ReindexTaskID != 0 {
+		err := w.data.UpdateReindexTask(ctx, job.ReindexTaskID, map[string]any{
+			"status": data.ReindexFailed, "finished_at": time.Now(), "error_msg": cause.Error(),
+		})
+		if err != nil {
+			log.Printf("⚠️ 更新重建任务 %d 状态失败: %v", job.ReindexTaskID, err)
+		}
+		return
+	}
+	if job.Type != data.JobParse && job.Type != data.JobReindex {
+		return
+	}
+	doc, err := w.resolveDocument(ctx, job)
 	if err != nil {
+		return
+	}
+
+	to, msg := data.DocStatusPending, fmt.Sprintf("第 %d 次处理失败，等待重试: %v", task.Attempts+1, cause)
+	if dead {
+		to, msg = data.DocStatusFailed, cause.Error()
+	}
+	if _, err := w.data.TransitionDocument(ctx, doc.ID, to, data.DocumentUpdate{ErrorMsg: msg}); err != nil {
+		log.Printf("⚠️ 更新文档 %d 状态失败: %v", doc.ID, err)
+	}
+}
+
+// handleParse 首次解析入库
+func (w *ETLWorker) handleParse(ctx context.Context, job *data.Job) error {
+	return w.ingestDocument(ctx, job)
+}
+
+// handleReindex 重新解析，成功后替换旧切片
+func (w *ETLWorker) handleReindex(ctx context.Context, job *data.Job) error {
+	return w.ingestDocument(ctx, job)
+}
+
+// ingestDocument 单个文档的 ETL 流程
+// 每次执行都带一个批次号写入切片，成功后删除其他批次的切片:
+// 重建时替换旧切片，上一次失败重试时清理写了一半的切片，失败时旧数据仍可检索
+func (w *ETLWorker) ingestDocument(ctx context.Context, job *data.Job) error {
+	// 0. 查出文档归属信息，写入 Payload 供检索过滤
+	doc, err := w.resolveDocument(ctx, job)
+	if errors.Is(err, data.ErrDocumentNotFound) {
+		// 文档已被删除，任务直接丢弃
+		log.Printf("⚠️ 文档不存在，跳过任务: %s", job.ID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	bucket, fileName := job.Storage.Bucket, job.Storage.Object
+	if bucket == "" {
+		bucket = "chimera-docs"
+	}
+	if fileName == "" {
+		fileName = doc.StoragePath
+	}
+
+	if _, err := w.data.TransitionDocument(ctx, doc.ID, data.DocStatusParsing, data.DocumentUpdate{Progress: progressParsing}); err != nil {
 		return err
 	}
-	defer obj.Close()
 
-	// 读取文件所有字节 (注意内存安全，大文件要分片，但Demo演示先直接读)
-	fileBytes, err := io.ReadAll(obj)
+	// A ~ D. 调用 Python 进行 解析+切片+向量化，切片边收边写入当前模型的向量集合
+	model, vectors, err := w.data.LiveVectors(ctx)
 	if err != nil {
 		return err
 	}
+	run := fmt.Sprintf("%s#%d", job.ID, job.Attempt)
+	sink := newChunkSink(w.data, w.upsert, model, vectors, doc, fileName, run)
 
-	// B. 调用 Python 进行 解析+切片+向量化
-	log.Printf("📡 发送 PDF 给 Python 进行深度解析: %s", fileName)
-	parseResp, err := w.grpcClient.ParseAndEmbed(ctx, &pb.ParseRequest{
-		FileContent: fileBytes,
-		FileName:    fileName,
-	})
+	log.Printf("📡 发送 PDF 给 Python 进行深度解析: %s (parser=%s)", fileName, job.Parser.Parser)
+	err = w.parseStream(ctx, job, bucket, fileName, sink)
+	if status.Code(err) == codes.Unimplemented && sink.Count() == 0 {
+		// 老版本 AI Service 没有流式接口，退回整文件上传
+		log.Printf("⚠️ AI Service 不支持流式解析，改用 ParseAndEmbed: %s", fileName)
+		err = w.parseUnary(ctx, bucket, fileName, sink)
+	}
 	if err != nil {
 		return err
 	}
+	if err := sink.Flush(ctx); err != nil {
+		return err
+	}
 
-	// C. 批量存入 Qdrant
-	points := make([]*qdrant.PointStruct, 0, len(parseResp.Chunks))
+	// 入库期间检索切换了模型: 切片只写进了旧集合，先不清理旧批次，让任务重试写入新集合
+	if live, err := w.data.LiveModel(ctx); err != nil {
+		return err
+	} else if live != model {
+		return fmt.Errorf("入库期间检索已切换到模型 %s，重新入库", live)
+	}
 
-	for i, chunk := range parseResp.Chunks {
-		pointID := uuid.New().String()
+	// E. 清理其他批次的切片 (重建前的旧切片、之前失败时写了一半的切片)
+	if err := w.data.DeleteStaleChunks(ctx, doc.ID, run); err != nil {
+		return err
+	}
 
-		// 构造 Payload (元数据)
-		// 这些数据就是以后检索回来给 DeepSeek 看的“背景知识”
-		payloadMap := map[string]interface{}{
-			"filename":    fileName,
-			"content":     chunk.Content,    // 存正文！
-			"page_number": chunk.PageNumber, // 存页码！
-			"chunk_index": i,
+	// 处理期间文档可能已被删除，删除任务可能已经先跑完，这里补一次清理，避免留下孤儿切片
+	current, err := w.data.GetDocument(ctx, doc.ID)
+	if errors.Is(err, data.ErrDocumentNotFound) {
+		log.Printf("⚠️ 文档 %d 在处理期间被删除，清理刚写入的切片", doc.ID)
+		return w.data.DeleteDocumentChunks(ctx, doc.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	// 切片按任务开始时的 is_latest 写入；处理期间上传了更新的版本 (或本版本被恢复为最新) 时按数据库重新标记
+	if current.IsLatest != doc.IsLatest {
+		mark := w.data.MarkSuperseded
+		if current.IsLatest {
+			mark = w.data.RestoreLatest
 		}
+		if err := mark(ctx, doc.ID); err != nil {
+			return err
+		}
+		doc.IsLatest = current.IsLatest
+	}
 
-		points = append(points, &qdrant.PointStruct{
-			Id:      qdrant.NewIDUUID(pointID),
-			Vectors: qdrant.NewVectors(chunk.Vector...),
-			Payload: qdrant.NewValueMap(payloadMap),
-		})
+	_, err = w.data.TransitionDocument(ctx, doc.ID, data.DocStatusSuccess, data.DocumentUpdate{
+		Progress:   progressDone,
+		ChunkCount: sink.Count(),
+	})
+	if err != nil {
+		return err
 	}
 
-	// 批量写入 (Batch Upsert)
-	// 真实场景建议分批，每次 100 个
-	if len(points) > 0 {
-		_, err = w.data.Qdrant.Upsert(ctx, &qdrant.UpsertPoints{
-			CollectionName: "chimera_docs",
-			Points:         points,
-		})
-		if err != nil {
-			return err
+	log.Printf("✅ ETL 完成: %s 生成了 %d 个向量切片", fileName, sink.Count())
+
+	// 新版本可以检索了，再让上一版本退出检索
+	if doc.PreviousVersionID != nil && doc.IsLatest {
+		// 入库已经成功，这里失败不能让整个任务重试，只记录日志
+		if err := w.data.MarkSuperseded(ctx, *doc.PreviousVersionID); err != nil {
+			log.Printf("⚠️ 标记旧版本 %d 失败: %v", *doc.PreviousVersionID, err)
+		} else {
+			log.Printf("📚 文档 %d (v%d) 已取代上一版本 %d", doc.ID, doc.Version, *doc.PreviousVersionID)
 		}
 	}
 
-	log.Printf("✅ ETL 完成: %s 生成了 %d 个向量切片", fileName, len(points))
+	// F. 后续任务: 生成摘要 (失败不影响本次入库结果)
+	if w.autoSummary && sink.Count() > 0 {
+		next := data.NewJob(ctx, data.JobSummarize, doc)
+		next.Trace = job.Trace
+		if err := w.data.EnqueueJob(ctx, next); err != nil {
+			log.Printf("⚠️ 投递摘要任务失败: %v", err)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	pb "Chimera-RAG/backend-go/api/rag/v1"
+	"Chimera-RAG/backend-go/internal/data"
+)
+
+// summaryChunks 生成摘要时最多读取的切片数 (文档开头部分)
+const summaryChunks = 20
+
+// handleDelete 彻底删除文档: 向量与关键词索引 -> MinIO 原文件 -> 数据库记录
+// 每一步都是幂等的，中途失败重试时从头再来即可；数据库记录放在最后删，
+// 这样前面任何一步失败，文档仍处于软删除状态，可以再次发起删除
+func (w *ETLWorker) handleDelete(ctx context.Context, job *data.Job) error {
+	if job.DocumentID == 0 {
+		return fmt.Errorf("%w: delete job without document_id", data.ErrUnsupportedJob)
+	}
+
+	if err := w.data.DeleteDocumentChunks(ctx, job.DocumentID); err != nil {
+		return err
+	}
+	if job.Storage.Object != "" {
+		if err := w.data.DeleteObject(ctx, job.Storage.Bucket, job.Storage.Object); err != nil {
+			return err
+		}
+	}
+	if err := w.data.PurgeDocument(ctx, job.DocumentID); err != nil {
+		return err
+	}
+
+	log.Printf("🗑️ 文档 %d 已彻底删除", job.DocumentID)
+	return nil
+}
+
+// handleSummarize 读取文档开头的切片，调用大模型生成摘要并写回文档
+func (w *ETLWorker) handleSummarize(ctx context.Context, job *data.Job) error {
+	doc, err := w.resolveDocument(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	chunks, err := w.data.DocumentChunks(ctx, doc.ID, summaryChunks)
+	if err != nil {
+		return err
+	}
+	if len(chunks) == 0 {
+		log.Printf("⚠️ 文档 %d 还没有切片，跳过摘要", doc.ID)
+		return nil
+	}
+
+	var content strings.Builder
+	for _, c := range chunks {
+		content.WriteString(c.Content)
+		content.WriteString("\n")
+	}
+	prompt := fmt.Sprintf(`
+		请为下面的文档写一段 200 字以内的中文摘要，只输出摘要本身。
+		文档标题：%s
+		文档内容：
+		%s
+		`, doc.Title, content.String())
+
+	stream, err := w.grpcClient.AskStream(ctx, &pb.AskRequest{Query: prompt})
+	if err != nil {
+		return err
+	}
+	var summary strings.Builder
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		summary.WriteString(resp.AnswerDelta)
+	}
+
+	if err := w.data.UpdateDocumentSummary(ctx, doc.ID, strings.TrimSpace(summary.String())); err != nil {
+		return err
+	}
+	log.Printf("📝 文档 %d 摘要已生成", doc.ID)
+	return nil
+}
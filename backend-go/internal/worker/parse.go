@@ -0,0 +1,147 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	pb "Chimera-RAG/backend-go/api/rag/v1"
+	"Chimera-RAG/backend-go/internal/data"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// sendChunkSize 流式上传时每条消息携带的文件字节数
+const sendChunkSize = 1 << 20
+
+// parseStream 通过双向流解析文件: 一边从 MinIO 读文件分片发给 AI Service，一边接收切片写入 sink
+// 文件不会整体读入内存，大小不受 gRPC 单条消息上限限制
+func (w *ETLWorker) parseStream(ctx context.Context, job *data.Job, bucket, fileName string, sink *chunkSink) error {
+	obj, err := w.data.Minio.GetObject(ctx, bucket, fileName, minio.GetObjectOptions{})
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+	info, err := obj.Stat()
+	if err != nil {
+		return err
+	}
+
+	// 接收端出错返回时取消发送端
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := w.grpcClient.ParseAndEmbedStream(ctx)
+	if err != nil {
+		return err
+	}
+
+	sendErr := make(chan error, 1)
+	go func() {
+		err := sendFile(stream, obj, &pb.ParseMetadata{
+			FileName: fileName,
+			FileSize: info.Size,
+			Parser:   job.Parser.Parser,
+			Options:  job.Parser.Options,
+
+			EmbeddingModel: sink.model,
+		})
+		// 读 MinIO 失败时服务端还在等数据，取消整个流让 Recv 返回
+		if err != nil && !errors.Is(err, io.EOF) {
+			cancel()
+		}
+		sendErr <- err
+	}()
+
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// 如果是发送端先出的错，返回发送端的原因更有用
+			select {
+			case se := <-sendErr:
+				if se != nil && !errors.Is(se, io.EOF) {
+					return se
+				}
+			default:
+			}
+			return err
+		}
+
+		switch p := resp.Payload.(type) {
+		case *pb.ParseStreamResponse_Chunk:
+			if err := sink.Add(ctx, p.Chunk); err != nil {
+				return err
+			}
+		case *pb.ParseStreamResponse_Progress:
+			sink.Report(ctx, p.Progress)
+		}
+	}
+
+	// 服务端正常结束时文件一定已经发完，这里只是回收发送协程的错误
+	if err := <-sendErr; err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// sendFile 先发元数据，再按 sendChunkSize 分片发送文件内容
+func sendFile(stream pb.LLMService_ParseAndEmbedStreamClient, r io.Reader, meta *pb.ParseMetadata) error {
+	err := stream.Send(&pb.ParseStreamRequest{Payload: &pb.ParseStreamRequest_Metadata{Metadata: meta}})
+	if err != nil {
+		return err
+	}
+
+	for {
+		// gRPC 要求 Send 之后不能再修改消息 (可能还没序列化)，每个分片用新的 buf
+		buf := make([]byte, sendChunkSize)
+		n, err := io.ReadFull(r, buf)
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		if n > 0 {
+			if sendErr := stream.Send(&pb.ParseStreamRequest{Payload: &pb.ParseStreamRequest_Data{Data: buf[:n]}}); sendErr != nil {
+				return sendErr
+			}
+		}
+		if err == io.EOF {
+			return stream.CloseSend()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// parseUnary 兼容老版本 AI Service: 整个文件一次性发送，受 gRPC 消息大小限制
+func (w *ETLWorker) parseUnary(ctx context.Context, bucket, fileName string, sink *chunkSink) error {
+	obj, err := w.data.Minio.GetObject(ctx, bucket, fileName, minio.GetObjectOptions{})
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+
+	fileBytes, err := io.ReadAll(obj)
+	if err != nil {
+		return err
+	}
+
+	parseResp, err := w.grpcClient.ParseAndEmbed(ctx, &pb.ParseRequest{
+		FileContent:    fileBytes,
+		FileName:       fileName,
+		EmbeddingModel: sink.model,
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, chunk := range parseResp.Chunks {
+		chunk.ChunkIndex = int32(i)
+		if err := sink.Add(ctx, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	pb "Chimera-RAG/backend-go/api/rag/v1"
+	"Chimera-RAG/backend-go/internal/data"
+)
+
+// reembedBatch 每次从数据库取的文档数，处理完一批保存一次进度
+const reembedBatch = 20
+
+// handleReembed 用目标模型重建整个向量集合 (蓝绿切换的 "绿")
+// 直接复用切片表里的文本，通过 EmbedData 重新向量化，不重新解析原文件。
+// 按文档 ID 顺序推进并记录游标，任务失败重试时从游标处继续
+func (w *ETLWorker) handleReembed(ctx context.Context, job *data.Job) error {
+	if job.ReindexTaskID == 0 {
+		return fmt.Errorf("%w: reembed job without reindex_task_id", data.ErrUnsupportedJob)
+	}
+	task, err := w.data.GetReindexTask(ctx, job.ReindexTaskID)
+	if errors.Is(err, data.ErrReindexNotFound) {
+		return fmt.Errorf("%w: %v", data.ErrUnsupportedJob, err)
+	}
+	if err != nil {
+		return err
+	}
+	if task.Status != data.ReindexPending && task.Status != data.ReindexRunning {
+		log.Printf("⚠️ 重建任务 %d 状态为 %s，跳过", task.ID, task.Status)
+		return nil
+	}
+
+	source, ok := w.data.VectorsFor(task.SourceModel)
+	if !ok {
+		return fmt.Errorf("%w: 模型 %s 未注册", data.ErrUnsupportedJob, task.SourceModel)
+	}
+	target, ok := w.data.VectorsFor(task.TargetModel)
+	if !ok {
+		return fmt.Errorf("%w: 模型 %s 未注册", data.ErrUnsupportedJob, task.TargetModel)
+	}
+
+	if task.Status == data.ReindexPending {
+		total, err := w.data.CountReindexDocuments(ctx)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		task.Status, task.StartedAt, task.TotalDocuments = data.ReindexRunning, &now, total
+		err = w.data.UpdateReindexTask(ctx, task.ID, map[string]any{
+			"status": data.ReindexRunning, "started_at": now, "total_documents": total,
+		})
+		if err != nil {
+			return err
+		}
+		log.Printf("🔁 重建任务 %d 开始: %s -> %s，共 %d 个文档", task.ID, task.SourceModel, task.TargetModel, total)
+	}
+
+	// 1. 按文档 ID 顺序逐个重建
+	for {
+		docs, err := w.data.ReindexDocumentsAfter(ctx, task.Cursor, reembedBatch)
+		if err != nil {
+			return err
+		}
+		if len(docs) == 0 {
+			break
+		}
+		for _, doc := range docs {
+			if err := w.reembedDocument(ctx, target, task.TargetModel, doc.ID); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				// 单个文档失败不中断整个任务，最后由切片数校验兜底
+				log.Printf("⚠️ 重建任务 %d: 文档 %d 重新向量化失败: %v", task.ID, doc.ID, err)
+				task.FailedDocuments++
+			}
+			task.ProcessedDocuments++
+			task.Cursor = doc.ID
+		}
+		err = w.data.UpdateReindexTask(ctx, task.ID, map[string]any{
+			"cursor":              task.Cursor,
+			"processed_documents": task.ProcessedDocuments,
+			"failed_documents":    task.FailedDocuments,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// 2. 补做: 重建期间 (或上次完成之后) 有变动的文档，游标已经越过它们，目标集合里是旧切片
+	// 完成时间记为查询之前，查询之后才发生的变动会在切换时被发现
+	since := *task.StartedAt
+	if task.CatchUpFrom != nil {
+		since = *task.CatchUpFrom
+	}
+	checkedAt := time.Now()
+	updated, err := w.data.DocumentsChangedSince(ctx, since, task.Cursor)
+	if err != nil {
+		return err
+	}
+	for _, doc := range updated {
+		if err := w.reembedDocument(ctx, target, task.TargetModel, doc.ID); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("⚠️ 重建任务 %d: 文档 %d 补做失败: %v", task.ID, doc.ID, err)
+			task.FailedDocuments++
+		}
+	}
+
+	// 3. 校验两个集合的切片数
+	all := &data.SearchFilter{IncludeSuperseded: true, IncludeExcluded: true}
+	sourcePoints, err := source.Count(ctx, all)
+	if err != nil {
+		return err
+	}
+	targetPoints, err := target.Count(ctx, all)
+	if err != nil {
+		return err
+	}
+
+	status, msg := data.ReindexReady, ""
+	if sourcePoints != targetPoints || task.FailedDocuments > 0 {
+		status = data.ReindexVerifyFailed
+		msg = fmt.Sprintf("切片数不一致: %s=%d, %s=%d, 失败文档 %d 个",
+			task.SourceModel, sourcePoints, task.TargetModel, targetPoints, task.FailedDocuments)
+	}
+	err = w.data.UpdateReindexTask(ctx, task.ID, map[string]any{
+		"status":           status,
+		"finished_at":      checkedAt,
+		"failed_documents": task.FailedDocuments,
+		"source_points":    sourcePoints,
+		"target_points":    targetPoints,
+		"error_msg":        msg,
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("✅ 重建任务 %d 完成 (%s): %s=%d, %s=%d", task.ID, status, task.SourceModel, sourcePoints, task.TargetModel, targetPoints)
+	return nil
+}
+
+// reembedDocument 从切片表读出一个文档的切片，用 model 重新向量化后写入 target
+// 先清空目标集合中该文档的切片，重复执行的结果相同
+func (w *ETLWorker) reembedDocument(ctx context.Context, target data.VectorStore, model string, documentID uint) error {
+	chunks, err := w.data.DocumentChunks(ctx, documentID, 0)
+	if err != nil {
+		return err
+	}
+	filter := &data.SearchFilter{DocumentIDs: []uint{documentID}, IncludeSuperseded: true, IncludeExcluded: true}
+	if err := target.Delete(ctx, filter); err != nil {
+		return err
+	}
+
+	points := make([]data.VectorPoint, 0, w.upsert.batchSize)
+	for _, c := range chunks {
+		resp, err := w.grpcClient.EmbedData(ctx, &pb.EmbedRequest{Data: &pb.EmbedRequest_Text{Text: c.Content}, Model: model})
+		if err != nil {
+			return err
+		}
+		points = append(points, data.VectorPoint{Vector: resp.Vector, Chunk: c})
+		if len(points) >= w.upsert.batchSize {
+			if err := target.Upsert(ctx, points); err != nil {
+				return err
+			}
+			points = points[:0]
+		}
+	}
+	return target.Upsert(ctx, points)
+}
@@ -0,0 +1,174 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	pb "Chimera-RAG/backend-go/api/rag/v1"
+	"Chimera-RAG/backend-go/internal/data"
+)
+
+// upsertOptions 向量写入的分批与重试参数
+type upsertOptions struct {
+	batchSize int // 每批写入向量存储的切片数，避免超大文档一次请求超过大小限制
+	retries   int // 单批失败后的重试次数
+}
+
+// upsertRetryBackoff 第一次重试前的等待时间，之后翻倍
+const upsertRetryBackoff = 500 * time.Millisecond
+
+// 各阶段在文档总进度中所占的区间
+const (
+	progressParsing   = 10 // 开始处理
+	progressReceived  = 20 // 文件已传给 AI Service
+	progressParsed    = 50 // 解析切片完成，开始向量化
+	progressEmbedded  = 95 // 全部切片写入完成
+	progressDone      = 100
+	progressEmbedding = progressParsed
+)
+
+// chunkSink 接收 AI Service 返回的切片，攒够一批就写入切片表、向量存储和关键词索引
+// 切片边到边写，内存里最多只有一批。
+// Point ID 由 (文档, 版本, 切片序号) 确定，整个流程可以安全地重复执行
+type chunkSink struct {
+	data     *data.Data
+	opts     upsertOptions
+	doc      *data.Document
+	fileName string
+	run      string // 本次入库的批次号，写入 Payload，完成后据此清理旧切片
+
+	// 向量模型及其集合，AI Service 用 model 向量化，切片写入 vectors
+	model   string
+	vectors data.VectorStore
+
+	records []data.Chunk // 与 points 一一对应，写入切片表
+	points  []data.VectorPoint
+	count   int
+
+	embedding bool // 是否已进入 embedding 状态
+	progress  int  // 最近一次写入数据库的进度
+}
+
+func newChunkSink(d *data.Data, opts upsertOptions, model string, vectors data.VectorStore, doc *data.Document, fileName, run string) *chunkSink {
+	return &chunkSink{
+		data: d, opts: opts, model: model, vectors: vectors,
+		doc: doc, fileName: fileName, run: run,
+		progress: progressParsing,
+	}
+}
+
+// Add 追加一个切片，批次满了会自动写入
+func (s *chunkSink) Add(ctx context.Context, chunk *pb.DocChunk) error {
+	if err := s.enterEmbedding(ctx); err != nil {
+		return err
+	}
+
+	doc := s.doc
+	c := data.LexicalChunk{
+		ID:              data.ChunkPointID(doc.ID, doc.Version, chunk.ChunkIndex),
+		Content:         chunk.Content,
+		FileName:        s.fileName,
+		Title:           doc.Title,
+		Page:            chunk.PageNumber,
+		ChunkIndex:      chunk.ChunkIndex,
+		DocumentID:      doc.ID,
+		KnowledgeBaseID: doc.KnowledgeBaseID,
+		OwnerID:         doc.OwnerID,
+		OrganizationID:  doc.OrganizationID,
+		FileType:        doc.FileType,
+		Superseded:      !doc.IsLatest,
+		IngestRun:       s.run,
+	}
+	s.records = append(s.records, data.NewChunkRecord(c, doc.Version, s.model))
+	s.points = append(s.points, data.VectorPoint{Vector: chunk.Vector, Chunk: c})
+
+	if len(s.points) >= s.opts.batchSize {
+		return s.Flush(ctx)
+	}
+	return nil
+}
+
+// Flush 写入当前批次
+// 没有切片的文档也要经过 embedding，之后才能迁移到 success
+func (s *chunkSink) Flush(ctx context.Context) error {
+	if err := s.enterEmbedding(ctx); err != nil {
+		return err
+	}
+	if len(s.points) == 0 {
+		return nil
+	}
+	// 切片表、向量存储写入成功后会同步写入关键词索引 (混合检索用)
+	if err := s.upsertWithRetry(ctx); err != nil {
+		return err
+	}
+	s.count += len(s.points)
+	s.records = s.records[:0]
+	s.points = s.points[:0]
+	return nil
+}
+
+// upsertWithRetry 写入当前批次，失败按指数退避重试
+// ID 是确定的，重试时即使上一次其实已经写进去了也只是覆盖
+func (s *chunkSink) upsertWithRetry(ctx context.Context) error {
+	var err error
+	backoff := upsertRetryBackoff
+	for attempt := 0; attempt <= s.opts.retries; attempt++ {
+		if attempt > 0 {
+			log.Printf("⚠️ 文档 %d 写入向量失败，%v 后第 %d 次重试: %v", s.doc.ID, backoff, attempt, err)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			backoff *= 2
+		}
+
+		err = s.data.UpsertChunks(ctx, s.vectors, s.records, s.points)
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// Count 已写入的切片数
+func (s *chunkSink) Count() int {
+	return s.count
+}
+
+// enterEmbedding 收到第一个切片 (或没有切片、解析结束) 时，文档进入 embedding 状态
+func (s *chunkSink) enterEmbedding(ctx context.Context) error {
+	if s.embedding {
+		return nil
+	}
+	if _, err := s.data.TransitionDocument(ctx, s.doc.ID, data.DocStatusEmbedding, data.DocumentUpdate{Progress: max(s.progress, progressEmbedding)}); err != nil {
+		return err
+	}
+	s.embedding = true
+	s.progress = max(s.progress, progressEmbedding)
+	return nil
+}
+
+// Report 将 AI Service 的阶段进度换算为文档总进度并写入
+func (s *chunkSink) Report(ctx context.Context, p *pb.ParseProgress) {
+	var progress int
+	switch p.Stage {
+	case "receiving":
+		progress = progressParsing + (progressReceived-progressParsing)*int(p.Percent)/100
+	case "parsing":
+		progress = progressReceived + (progressParsed-progressReceived)*int(p.Percent)/100
+	case "embedding":
+		progress = progressParsed + (progressEmbedded-progressParsed)*int(p.Percent)/100
+	default:
+		return
+	}
+	// 只前进不后退，相同的值也不重复写库
+	if progress <= s.progress {
+		return
+	}
+	s.progress = progress
+	if err := s.data.UpdateDocumentProgress(ctx, s.doc.ID, progress); err != nil {
+		log.Printf("⚠️ 更新文档 %d 进度失败: %v", s.doc.ID, err)
+	}
+}